@@ -0,0 +1,708 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sendJSON marshals payload, sends it to endpoint and decodes the response
+func sendJSON[T any](ctx context.Context, c *TogglClient, method, endpoint string, payload interface{}) (T, error) {
+	var zero T
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return zero, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	resp, err := c.makeRequest(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return zero, err
+	}
+
+	return decodeResponse[T](resp)
+}
+
+// GetMe fetches the account the client's token belongs to
+func (c *TogglClient) GetMe(ctx context.Context) (UserInfo, error) {
+	resp, err := c.makeRequest(ctx, http.MethodGet, "/me", nil)
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("getting user info: %w", err)
+	}
+
+	return decodeResponse[UserInfo](resp)
+}
+
+// GetTimeEntry fetches a single time entry owned by the current user
+func (c *TogglClient) GetTimeEntry(ctx context.Context, entryID int) (TimeEntry, error) {
+	resp, err := c.makeRequest(ctx, http.MethodGet, fmt.Sprintf("/me/time_entries/%d", entryID), nil)
+	if err != nil {
+		return TimeEntry{}, fmt.Errorf("getting time entry %d: %w", entryID, err)
+	}
+
+	return decodeResponse[TimeEntry](resp)
+}
+
+// GetCurrentTimeEntry fetches the running time entry, returning
+// ErrNoRunningEntry when no timer is running
+func (c *TogglClient) GetCurrentTimeEntry(ctx context.Context) (TimeEntry, error) {
+	resp, err := c.makeRequest(ctx, http.MethodGet, "/me/time_entries/current", nil)
+	if err != nil {
+		return TimeEntry{}, fmt.Errorf("getting current time entry: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return TimeEntry{}, ErrNoRunningEntry
+	}
+
+	// The API answers with null rather than 404 when nothing is running
+	current, err := decodeResponse[*TimeEntry](resp)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	if current == nil {
+		return TimeEntry{}, ErrNoRunningEntry
+	}
+
+	return *current, nil
+}
+
+// GetTimeEntries lists the current user's time entries between the start
+// and end dates (inclusive start, exclusive end). Zero dates are left out,
+// in which case the API returns recent entries.
+func (c *TogglClient) GetTimeEntries(ctx context.Context, start, end time.Time) ([]TimeEntry, error) {
+	endpoint := "/me/time_entries"
+	params := url.Values{}
+
+	if !start.IsZero() {
+		params.Set("start_date", c.apiDate(start))
+	}
+	if !end.IsZero() {
+		params.Set("end_date", c.apiDate(end))
+	}
+
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting time entries: %w", err)
+	}
+
+	return decodeResponse[[]TimeEntry](resp)
+}
+
+// snapshotTimeEntry fetches the prior state of an entry when the change will be journaled
+func (c *TogglClient) snapshotTimeEntry(ctx context.Context, entryID int) (*TimeEntry, error) {
+	if c.journal == nil {
+		return nil, nil
+	}
+
+	entry, err := c.GetTimeEntry(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// CreateTimeEntry creates a time entry in a workspace. A duration of -1
+// starts a running timer.
+func (c *TogglClient) CreateTimeEntry(ctx context.Context, workspaceID int, entry TimeEntryRequest) (TimeEntry, error) {
+	entry.WorkspaceID = workspaceID
+
+	created, err := sendJSON[TimeEntry](
+		ctx,
+		c,
+		http.MethodPost,
+		fmt.Sprintf("/workspaces/%d/time_entries", workspaceID),
+		entry,
+	)
+	if err != nil {
+		return created, err
+	}
+
+	c.recordChange(ctx, Change{
+		Operation:   changeCreate,
+		EntityType:  entityTimeEntry,
+		WorkspaceID: workspaceID,
+		EntityID:    created.ID,
+	}, nil, created)
+
+	return created, nil
+}
+
+// UpdateTimeEntry replaces the given fields of a time entry. Fields set to
+// nil are cleared.
+func (c *TogglClient) UpdateTimeEntry(
+	ctx context.Context,
+	workspaceID, entryID int,
+	fields map[string]interface{},
+) (TimeEntry, error) {
+	before, err := c.snapshotTimeEntry(ctx, entryID)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+
+	updated, err := sendJSON[TimeEntry](
+		ctx,
+		c,
+		http.MethodPut,
+		fmt.Sprintf("/workspaces/%d/time_entries/%d", workspaceID, entryID),
+		fields,
+	)
+	if err != nil {
+		return updated, err
+	}
+
+	c.recordChange(ctx, Change{
+		Operation:   changeUpdate,
+		EntityType:  entityTimeEntry,
+		WorkspaceID: workspaceID,
+		EntityID:    entryID,
+	}, before, updated)
+
+	return updated, nil
+}
+
+// maxPatchIDs is how many time entries one batch update may address
+const maxPatchIDs = 100
+
+// PatchTimeEntries applies ops to entries, all in one workspace, through the
+// batch endpoint in requests of at most maxPatchIDs entries. A request the
+// API rejects as a whole is reported as a failure for each of its entries.
+// The entries are journaled as their state before the update.
+func (c *TogglClient) PatchTimeEntries(
+	ctx context.Context,
+	workspaceID int,
+	entries []TimeEntry,
+	ops []PatchOperation,
+) (PatchResult, error) {
+	var result PatchResult
+	before := make(map[int]TimeEntry, len(entries))
+	for start := 0; start < len(entries); start += maxPatchIDs {
+		chunk := entries[start:min(start+maxPatchIDs, len(entries))]
+		ids := make([]string, len(chunk))
+		for i, entry := range chunk {
+			ids[i] = strconv.Itoa(entry.ID)
+			before[entry.ID] = entry
+		}
+
+		patched, err := sendJSON[PatchResult](
+			ctx,
+			c,
+			http.MethodPatch,
+			fmt.Sprintf("/workspaces/%d/time_entries/%s", workspaceID, strings.Join(ids, ",")),
+			ops,
+		)
+		if err != nil {
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				return result, fmt.Errorf("updating time entries: %w", err)
+			}
+			for _, entry := range chunk {
+				result.Failure = append(result.Failure, PatchFailure{ID: entry.ID, Message: apiErr.Error()})
+			}
+			continue
+		}
+
+		result.Success = append(result.Success, patched.Success...)
+		result.Failure = append(result.Failure, patched.Failure...)
+		for _, id := range patched.Success {
+			c.recordChange(ctx, Change{
+				Operation:   changeUpdate,
+				EntityType:  entityTimeEntry,
+				WorkspaceID: workspaceID,
+				EntityID:    id,
+			}, before[id], nil)
+		}
+	}
+
+	return result, nil
+}
+
+// StopTimeEntry stops a running time entry
+func (c *TogglClient) StopTimeEntry(ctx context.Context, workspaceID, entryID int) (TimeEntry, error) {
+	before, err := c.snapshotTimeEntry(ctx, entryID)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+
+	resp, err := c.makeRequest(
+		ctx,
+		http.MethodPatch,
+		fmt.Sprintf("/workspaces/%d/time_entries/%d/stop", workspaceID, entryID),
+		nil,
+	)
+	if err != nil {
+		return TimeEntry{}, fmt.Errorf("stopping time entry %d: %w", entryID, err)
+	}
+
+	stopped, err := decodeResponse[TimeEntry](resp)
+	if err != nil {
+		return stopped, err
+	}
+
+	c.recordChange(ctx, Change{
+		Operation:   changeUpdate,
+		EntityType:  entityTimeEntry,
+		WorkspaceID: workspaceID,
+		EntityID:    entryID,
+	}, before, stopped)
+
+	return stopped, nil
+}
+
+// StopCurrentTimeEntry stops the running time entry, returning
+// ErrNoRunningEntry when no timer is running. A workspaceID of zero means the
+// running entry's own workspace.
+func (c *TogglClient) StopCurrentTimeEntry(ctx context.Context, workspaceID int) (TimeEntry, error) {
+	current, err := c.GetCurrentTimeEntry(ctx)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+
+	if workspaceID == 0 {
+		workspaceID = current.WorkspaceID
+	}
+
+	return c.StopTimeEntry(ctx, workspaceID, current.ID)
+}
+
+// DeleteTimeEntry permanently deletes a time entry
+func (c *TogglClient) DeleteTimeEntry(ctx context.Context, workspaceID, entryID int) error {
+	before, err := c.snapshotTimeEntry(ctx, entryID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.makeRequest(
+		ctx,
+		http.MethodDelete,
+		fmt.Sprintf("/workspaces/%d/time_entries/%d", workspaceID, entryID),
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("deleting time entry %d: %w", entryID, err)
+	}
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	c.recordChange(ctx, Change{
+		Operation:   changeDelete,
+		EntityType:  entityTimeEntry,
+		WorkspaceID: workspaceID,
+		EntityID:    entryID,
+	}, before, nil)
+
+	return nil
+}
+
+// GetProjects lists the projects in a workspace, optionally filtered by
+// whether they are active
+func (c *TogglClient) GetProjects(ctx context.Context, workspaceID int, active *bool) ([]Project, error) {
+	endpoint := fmt.Sprintf("/workspaces/%d/projects", workspaceID)
+	if active != nil {
+		endpoint += "?" + url.Values{"active": {strconv.FormatBool(*active)}}.Encode()
+	}
+
+	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting projects: %w", err)
+	}
+
+	return decodeResponse[[]Project](resp)
+}
+
+// projectsPageSize is how many projects GetAllProjects asks for per page,
+// the most the API returns
+const projectsPageSize = 200
+
+// GetAllProjects lists every project in a workspace, archived ones included,
+// a page at a time. Without active=both the API leaves archived projects out.
+func (c *TogglClient) GetAllProjects(ctx context.Context, workspaceID int) ([]Project, error) {
+	var all []Project
+	for page := 1; ; page++ {
+		query := url.Values{
+			"active":   {"both"},
+			"page":     {strconv.Itoa(page)},
+			"per_page": {strconv.Itoa(projectsPageSize)},
+		}
+		resp, err := c.makeRequest(ctx, http.MethodGet,
+			fmt.Sprintf("/workspaces/%d/projects?%s", workspaceID, query.Encode()), nil)
+		if err != nil {
+			return nil, fmt.Errorf("getting projects: %w", err)
+		}
+		projects, err := decodeResponse[[]Project](resp)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, projects...)
+		if len(projects) < projectsPageSize {
+			return all, nil
+		}
+	}
+}
+
+// entryProjects looks up the projects entries are assigned to, archived ones
+// included, listing each workspace's projects once
+func (c *TogglClient) entryProjects(ctx context.Context, entries []TimeEntry) (map[int]Project, error) {
+	byID := make(map[int]Project)
+	seen := make(map[int]bool)
+
+	for _, entry := range entries {
+		if entry.ProjectID == nil || seen[entry.WorkspaceID] {
+			continue
+		}
+		seen[entry.WorkspaceID] = true
+
+		projects, err := c.GetAllProjects(ctx, entry.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects {
+			byID[project.ID] = project
+		}
+	}
+
+	return byID, nil
+}
+
+// projectNames looks up the names of the projects entries are assigned to
+func (c *TogglClient) projectNames(ctx context.Context, entries []TimeEntry) (map[int]string, error) {
+	projects, err := c.entryProjects(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int]string, len(projects))
+	for id, project := range projects {
+		names[id] = project.Name
+	}
+	return names, nil
+}
+
+// GetWorkspace fetches a workspace with its default rate and currency
+func (c *TogglClient) GetWorkspace(ctx context.Context, workspaceID int) (Workspace, error) {
+	resp, err := c.makeRequest(ctx, http.MethodGet, fmt.Sprintf("/workspaces/%d", workspaceID), nil)
+	if err != nil {
+		return Workspace{}, fmt.Errorf("getting workspace %d: %w", workspaceID, err)
+	}
+
+	return decodeResponse[Workspace](resp)
+}
+
+// GetWorkspaceUsers lists the members of a workspace with their rates. The
+// API only allows workspace admins to list them.
+func (c *TogglClient) GetWorkspaceUsers(ctx context.Context, workspaceID int) ([]WorkspaceUser, error) {
+	resp, err := c.makeRequest(ctx, http.MethodGet, fmt.Sprintf("/workspaces/%d/workspace_users", workspaceID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting workspace users: %w", err)
+	}
+
+	return decodeResponse[[]WorkspaceUser](resp)
+}
+
+// GetClients lists the clients in a workspace
+func (c *TogglClient) GetClients(ctx context.Context, workspaceID int) ([]Client, error) {
+	resp, err := c.makeRequest(ctx, http.MethodGet, fmt.Sprintf("/workspaces/%d/clients", workspaceID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting clients: %w", err)
+	}
+
+	return decodeResponse[[]Client](resp)
+}
+
+// GetTags lists the tags in a workspace
+func (c *TogglClient) GetTags(ctx context.Context, workspaceID int) ([]Tag, error) {
+	resp, err := c.makeRequest(ctx, http.MethodGet, fmt.Sprintf("/workspaces/%d/tags", workspaceID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting tags: %w", err)
+	}
+
+	return decodeResponse[[]Tag](resp)
+}
+
+// projectClientNames looks up the names of the clients projects belong to,
+// listing each workspace's clients once
+func (c *TogglClient) projectClientNames(ctx context.Context, projects map[int]Project) (map[int]string, error) {
+	names := make(map[int]string)
+	seen := make(map[int]bool)
+
+	for _, project := range projects {
+		if project.ClientID == nil || seen[project.WorkspaceID] {
+			continue
+		}
+		seen[project.WorkspaceID] = true
+
+		clients, err := c.GetClients(ctx, project.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		for _, client := range clients {
+			names[client.ID] = client.Name
+		}
+	}
+
+	return names, nil
+}
+
+// GetProject fetches a single project in a workspace
+func (c *TogglClient) GetProject(ctx context.Context, workspaceID, projectID int) (Project, error) {
+	resp, err := c.makeRequest(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("/workspaces/%d/projects/%d", workspaceID, projectID),
+		nil,
+	)
+	if err != nil {
+		return Project{}, fmt.Errorf("getting project %d: %w", projectID, err)
+	}
+
+	return decodeResponse[Project](resp)
+}
+
+// snapshotProject fetches the prior state of a project when the change will be journaled
+func (c *TogglClient) snapshotProject(ctx context.Context, workspaceID, projectID int) (*Project, error) {
+	if c.journal == nil {
+		return nil, nil
+	}
+
+	project, err := c.GetProject(ctx, workspaceID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// CreateProject creates a project in a workspace
+func (c *TogglClient) CreateProject(ctx context.Context, workspaceID int, fields map[string]interface{}) (Project, error) {
+	created, err := sendJSON[Project](
+		ctx,
+		c,
+		http.MethodPost,
+		fmt.Sprintf("/workspaces/%d/projects", workspaceID),
+		fields,
+	)
+	if err != nil {
+		return created, err
+	}
+
+	c.recordChange(ctx, Change{
+		Operation:   changeCreate,
+		EntityType:  entityProject,
+		WorkspaceID: workspaceID,
+		EntityID:    created.ID,
+	}, nil, created)
+
+	return created, nil
+}
+
+// UpdateProject replaces the given fields of a project
+func (c *TogglClient) UpdateProject(
+	ctx context.Context,
+	workspaceID, projectID int,
+	fields map[string]interface{},
+) (Project, error) {
+	before, err := c.snapshotProject(ctx, workspaceID, projectID)
+	if err != nil {
+		return Project{}, err
+	}
+
+	updated, err := sendJSON[Project](
+		ctx,
+		c,
+		http.MethodPut,
+		fmt.Sprintf("/workspaces/%d/projects/%d", workspaceID, projectID),
+		fields,
+	)
+	if err != nil {
+		return updated, err
+	}
+
+	c.recordChange(ctx, Change{
+		Operation:   changeUpdate,
+		EntityType:  entityProject,
+		WorkspaceID: workspaceID,
+		EntityID:    projectID,
+	}, before, updated)
+
+	return updated, nil
+}
+
+// DeleteProject permanently deletes a project. Time entries that belonged to
+// it are kept but no longer assigned to a project.
+func (c *TogglClient) DeleteProject(ctx context.Context, workspaceID, projectID int) error {
+	before, err := c.snapshotProject(ctx, workspaceID, projectID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.makeRequest(
+		ctx,
+		http.MethodDelete,
+		fmt.Sprintf("/workspaces/%d/projects/%d", workspaceID, projectID),
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("deleting project %d: %w", projectID, err)
+	}
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	c.recordChange(ctx, Change{
+		Operation:   changeDelete,
+		EntityType:  entityProject,
+		WorkspaceID: workspaceID,
+		EntityID:    projectID,
+	}, before, nil)
+
+	return nil
+}
+
+// GetTasks lists the tasks of a project, optionally filtered by whether they
+// are active. Workspaces without tasks answer with 402 or 403.
+func (c *TogglClient) GetTasks(ctx context.Context, workspaceID, projectID int, active *bool) ([]Task, error) {
+	endpoint := fmt.Sprintf("/workspaces/%d/projects/%d/tasks", workspaceID, projectID)
+	if active != nil {
+		endpoint += "?" + url.Values{"active": {strconv.FormatBool(*active)}}.Encode()
+	}
+
+	resp, err := c.makeRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting tasks: %w", err)
+	}
+
+	return decodeResponse[[]Task](resp)
+}
+
+// GetTask fetches a single task of a project
+func (c *TogglClient) GetTask(ctx context.Context, workspaceID, projectID, taskID int) (Task, error) {
+	resp, err := c.makeRequest(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("/workspaces/%d/projects/%d/tasks/%d", workspaceID, projectID, taskID),
+		nil,
+	)
+	if err != nil {
+		return Task{}, fmt.Errorf("getting task %d: %w", taskID, err)
+	}
+
+	return decodeResponse[Task](resp)
+}
+
+// snapshotTask fetches the prior state of a task when the change will be journaled
+func (c *TogglClient) snapshotTask(ctx context.Context, workspaceID, projectID, taskID int) (*Task, error) {
+	if c.journal == nil {
+		return nil, nil
+	}
+
+	task, err := c.GetTask(ctx, workspaceID, projectID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// CreateTask creates a task in a project
+func (c *TogglClient) CreateTask(
+	ctx context.Context,
+	workspaceID, projectID int,
+	fields map[string]interface{},
+) (Task, error) {
+	created, err := sendJSON[Task](
+		ctx,
+		c,
+		http.MethodPost,
+		fmt.Sprintf("/workspaces/%d/projects/%d/tasks", workspaceID, projectID),
+		fields,
+	)
+	if err != nil {
+		return created, err
+	}
+
+	c.recordChange(ctx, Change{
+		Operation:   changeCreate,
+		EntityType:  entityTask,
+		WorkspaceID: workspaceID,
+		EntityID:    created.ID,
+	}, nil, created)
+
+	return created, nil
+}
+
+// UpdateTask replaces the given fields of a task
+func (c *TogglClient) UpdateTask(
+	ctx context.Context,
+	workspaceID, projectID, taskID int,
+	fields map[string]interface{},
+) (Task, error) {
+	before, err := c.snapshotTask(ctx, workspaceID, projectID, taskID)
+	if err != nil {
+		return Task{}, err
+	}
+
+	updated, err := sendJSON[Task](
+		ctx,
+		c,
+		http.MethodPut,
+		fmt.Sprintf("/workspaces/%d/projects/%d/tasks/%d", workspaceID, projectID, taskID),
+		fields,
+	)
+	if err != nil {
+		return updated, err
+	}
+
+	c.recordChange(ctx, Change{
+		Operation:   changeUpdate,
+		EntityType:  entityTask,
+		WorkspaceID: workspaceID,
+		EntityID:    taskID,
+	}, before, updated)
+
+	return updated, nil
+}
+
+// DeleteTask permanently deletes a task. Time entries that belonged to it
+// are kept on its project.
+func (c *TogglClient) DeleteTask(ctx context.Context, workspaceID, projectID, taskID int) error {
+	before, err := c.snapshotTask(ctx, workspaceID, projectID, taskID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.makeRequest(
+		ctx,
+		http.MethodDelete,
+		fmt.Sprintf("/workspaces/%d/projects/%d/tasks/%d", workspaceID, projectID, taskID),
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("deleting task %d: %w", taskID, err)
+	}
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	c.recordChange(ctx, Change{
+		Operation:   changeDelete,
+		EntityType:  entityTask,
+		WorkspaceID: workspaceID,
+		EntityID:    taskID,
+	}, before, nil)
+
+	return nil
+}
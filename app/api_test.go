@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestTogglClient_EntityRequests(t *testing.T) {
+	tests := []struct {
+		name         string
+		wantMethod   string
+		wantPath     string
+		status       int
+		body         interface{}
+		call         func(c *TogglClient) error
+		expectAPIErr bool
+	}{
+		{
+			name:       "GetTimeEntry",
+			wantMethod: http.MethodGet,
+			wantPath:   "/api/v9/me/time_entries/789",
+			status:     http.StatusOK,
+			body:       testTimeEntry,
+			call: func(c *TogglClient) error {
+				entry, err := c.GetTimeEntry(context.Background(), 789)
+				if err == nil && entry.ID != testTimeEntry.ID {
+					t.Errorf("expected ID %d, got %d", testTimeEntry.ID, entry.ID)
+				}
+				return err
+			},
+		},
+		{
+			name:       "DeleteTimeEntry",
+			wantMethod: http.MethodDelete,
+			wantPath:   "/api/v9/workspaces/456/time_entries/789",
+			status:     http.StatusOK,
+			call: func(c *TogglClient) error {
+				return c.DeleteTimeEntry(context.Background(), 456, 789)
+			},
+		},
+		{
+			name:       "GetProject",
+			wantMethod: http.MethodGet,
+			wantPath:   "/api/v9/workspaces/456/projects/111",
+			status:     http.StatusOK,
+			body:       testProject,
+			call: func(c *TogglClient) error {
+				_, err := c.GetProject(context.Background(), 456, 111)
+				return err
+			},
+		},
+		{
+			name:       "PatchTimeEntries",
+			wantMethod: http.MethodPatch,
+			wantPath:   "/api/v9/workspaces/456/time_entries/1,2",
+			status:     http.StatusOK,
+			body:       PatchResult{Success: []int{1, 2}},
+			call: func(c *TogglClient) error {
+				entries := []TimeEntry{{BaseEntity: BaseEntity{ID: 1}}, {BaseEntity: BaseEntity{ID: 2}}}
+				_, err := c.PatchTimeEntries(context.Background(), 456, entries,
+					[]PatchOperation{{Op: "replace", Path: "/billable", Value: true}})
+				return err
+			},
+		},
+		{
+			name:         "DeleteProject not found",
+			wantMethod:   http.MethodDelete,
+			wantPath:     "/api/v9/workspaces/456/projects/111",
+			status:       http.StatusNotFound,
+			call:         func(c *TogglClient) error { return c.DeleteProject(context.Background(), 456, 111) },
+			expectAPIErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tt.wantMethod || r.URL.Path != tt.wantPath {
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+				writeJSON(w, tt.status, tt.body)
+			})
+
+			err := tt.call(client)
+			var apiErr *APIError
+			if tt.expectAPIErr != errors.As(err, &apiErr) {
+				t.Errorf("expected APIError=%v, got %v", tt.expectAPIErr, err)
+			}
+			if !tt.expectAPIErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTogglClient_PatchTimeEntriesRejected(t *testing.T) {
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusForbidden, "workspace is locked")
+	})
+
+	entries := []TimeEntry{{BaseEntity: BaseEntity{ID: 1}}, {BaseEntity: BaseEntity{ID: 2}}}
+	result, err := client.PatchTimeEntries(context.Background(), 456, entries,
+		[]PatchOperation{{Op: "replace", Path: "/billable", Value: true}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Success) != 0 || len(result.Failure) != 2 || result.Failure[1].ID != 2 {
+		t.Errorf("expected both entries to fail, got %+v", result)
+	}
+}
+
+func TestTogglClient_GetAllProjects(t *testing.T) {
+	var pages []string
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("active") != "both" || query.Get("per_page") != "200" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		pages = append(pages, query.Get("page"))
+		count := projectsPageSize
+		if query.Get("page") == "2" {
+			count = 1
+		}
+		projects := make([]Project, count)
+		for i := range projects {
+			projects[i] = Project{BaseEntity: BaseEntity{ID: len(pages)*1000 + i}}
+		}
+		writeJSON(w, http.StatusOK, projects)
+	})
+
+	projects, err := client.GetAllProjects(context.Background(), 456)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(projects) != projectsPageSize+1 || len(pages) != 2 {
+		t.Errorf("expected 2 pages with %d projects, got %v and %d", projectsPageSize+1, pages, len(projects))
+	}
+}
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultAuditMaxSize    = 10 << 20 // 10 MiB
+	defaultAuditMaxBackups = 5
+
+	// maxAuditValueLength caps logged string arguments so large payloads
+	// such as CSV or ICS text do not end up in the audit log verbatim
+	maxAuditValueLength = 256
+)
+
+// sensitiveArguments are argument names whose values are never logged
+var sensitiveArguments = []string{"token", "password", "secret", "api_key"}
+
+// AuditEntity identifies an entity a tool call created, changed or deleted
+type AuditEntity struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+}
+
+// AuditRecord is a single line of the audit log describing one tool call
+type AuditRecord struct {
+	Time         time.Time              `json:"time"`
+	Tool         string                 `json:"tool"`
+	Arguments    map[string]interface{} `json:"arguments,omitempty"`
+	Entities     []AuditEntity          `json:"entities,omitempty"`
+	HTTPStatuses []int                  `json:"http_statuses,omitempty"`
+	LatencyMS    int64                  `json:"latency_ms"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// auditCall collects the details of an in-flight tool call
+type auditCall struct {
+	mu     sync.Mutex
+	record AuditRecord
+}
+
+// addStatus notes the status code of an API response made during the call
+func (c *auditCall) addStatus(status int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record.HTTPStatuses = append(c.record.HTTPStatuses, status)
+}
+
+// addEntity notes an entity mutated during the call
+func (c *auditCall) addEntity(entityType string, id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record.Entities = append(c.record.Entities, AuditEntity{Type: entityType, ID: id})
+}
+
+// auditKey is the context key holding the audit details of the current call
+type auditKey struct{}
+
+// auditFromContext returns the audit details of the current call, if any
+func auditFromContext(ctx context.Context) *auditCall {
+	call, _ := ctx.Value(auditKey{}).(*auditCall)
+	return call
+}
+
+// AuditLog writes one JSON line per tool call to a file, rotating it once it
+// exceeds maxSize bytes and keeping at most maxBackups rotated files
+type AuditLog struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	now        func() time.Time
+}
+
+// NewAuditLog opens (or creates) the audit log at path. Zero values for
+// maxSize and maxBackups select the defaults.
+func NewAuditLog(path string, maxSize int64, maxBackups int) (*AuditLog, error) {
+	if path == "" {
+		return nil, errors.New("audit log path is required")
+	}
+	if maxSize <= 0 {
+		maxSize = defaultAuditMaxSize
+	}
+	if maxBackups <= 0 {
+		maxBackups = defaultAuditMaxBackups
+	}
+
+	a := &AuditLog{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		now:        time.Now,
+	}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// open opens the current log file for appending. Callers must hold a.mu.
+func (a *AuditLog) open() error {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o700); err != nil {
+		return fmt.Errorf("creating audit log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("reading audit log size: %w", err)
+	}
+
+	a.file = f
+	a.size = info.Size()
+	return nil
+}
+
+// rotate shifts path.N-1 to path.N down to path to path.1 and starts a new
+// file. Callers must hold a.mu.
+func (a *AuditLog) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return fmt.Errorf("closing audit log: %w", err)
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", a.path, a.maxBackups))
+	for i := a.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", a.path, i), fmt.Sprintf("%s.%d", a.path, i+1))
+	}
+	if err := os.Rename(a.path, a.path+".1"); err != nil {
+		return fmt.Errorf("rotating audit log: %w", err)
+	}
+
+	return a.open()
+}
+
+// Write appends rec to the log, rotating first if it would exceed the size limit
+func (a *AuditLog) Write(rec AuditRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.size > 0 && a.size+int64(len(line)) > a.maxSize {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+// middleware wraps a tool handler so every call is written to the audit log
+func (a *AuditLog) middleware(
+	name string,
+	handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error),
+) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		call := &auditCall{record: AuditRecord{
+			Time:      a.now(),
+			Tool:      name,
+			Arguments: sanitizeArguments(req.GetArguments()),
+		}}
+
+		start := time.Now()
+		result, err := handler(context.WithValue(ctx, auditKey{}, call), req)
+
+		call.mu.Lock()
+		rec := call.record
+		call.mu.Unlock()
+
+		rec.LatencyMS = time.Since(start).Milliseconds()
+		switch {
+		case err != nil:
+			rec.Error = err.Error()
+		case result != nil && result.IsError:
+			rec.Error = resultText(result)
+		}
+
+		if werr := a.Write(rec); werr != nil {
+			// Auditing must not change the outcome of the call itself
+			slog.Warn("failed to write audit record",
+				slog.Any("error", werr),
+				slog.String("tool", name),
+			)
+		}
+
+		return result, err
+	}
+}
+
+// sanitizeArguments copies args, redacting secrets and truncating long strings
+func sanitizeArguments(args map[string]interface{}) map[string]interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+
+	sanitized := make(map[string]interface{}, len(args))
+	for key, val := range args {
+		if isSensitiveArgument(key) {
+			sanitized[key] = "[redacted]"
+			continue
+		}
+		if s, ok := val.(string); ok && len(s) > maxAuditValueLength {
+			sanitized[key] = fmt.Sprintf("%s... [%d bytes]", s[:maxAuditValueLength], len(s))
+			continue
+		}
+		sanitized[key] = val
+	}
+	return sanitized
+}
+
+// isSensitiveArgument reports whether an argument name looks like a secret
+func isSensitiveArgument(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveArguments {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// resultText returns the concatenated text content of a tool result
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if text, ok := c.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
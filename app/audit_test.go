@@ -0,0 +1,150 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// readAuditRecords parses every line of an audit log file
+func readAuditRecords(t *testing.T, path string) []AuditRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening audit log: %v", err)
+	}
+	defer f.Close()
+
+	var records []AuditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid audit line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestSanitizeArguments(t *testing.T) {
+	long := strings.Repeat("x", maxAuditValueLength+10)
+	got := sanitizeArguments(map[string]interface{}{
+		"description":        "Standup",
+		"workspace_id":       float64(456),
+		"confirmation_token": "abc123",
+		"API_KEY":            "secret",
+		"csv":                long,
+	})
+
+	if got["description"] != "Standup" || got["workspace_id"] != float64(456) {
+		t.Errorf("expected ordinary arguments to be kept, got %v", got)
+	}
+	if got["confirmation_token"] != "[redacted]" || got["API_KEY"] != "[redacted]" {
+		t.Errorf("expected secrets to be redacted, got %v", got)
+	}
+	if s := got["csv"].(string); s != long[:maxAuditValueLength]+"... [266 bytes]" {
+		t.Errorf("expected long value to be truncated, got %q", s)
+	}
+
+	if sanitizeArguments(nil) != nil {
+		t.Error("expected nil for empty arguments")
+	}
+}
+
+func TestAuditLog_Middleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := NewAuditLog(path, 0, 0)
+	if err != nil {
+		t.Fatalf("NewAuditLog failed: %v", err)
+	}
+	defer auditLog.Close()
+
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, testTimeEntry)
+	})
+
+	start := auditLog.middleware("start_time_entry", wrapHandler(client, handleStartTimeEntry))
+	_, err = start(context.Background(), mcp.CallToolRequest{
+		Params: testCallToolParams{Arguments: map[string]interface{}{
+			"description":  "Test task",
+			"workspace_id": float64(456),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	failing := auditLog.middleware("broken", func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, errors.New("boom")
+	})
+	if _, err := failing(context.Background(), mcp.CallToolRequest{}); err == nil {
+		t.Fatal("expected middleware to pass the handler error through")
+	}
+
+	records := readAuditRecords(t, path)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	rec := records[0]
+	if rec.Tool != "start_time_entry" || rec.Arguments["description"] != "Test task" {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+	if len(rec.HTTPStatuses) != 1 || rec.HTTPStatuses[0] != http.StatusOK {
+		t.Errorf("expected HTTP status 200, got %v", rec.HTTPStatuses)
+	}
+	if len(rec.Entities) != 1 || rec.Entities[0] != (AuditEntity{Type: entityTimeEntry, ID: testTimeEntry.ID}) {
+		t.Errorf("expected created entry in entities, got %v", rec.Entities)
+	}
+	if rec.Error != "" {
+		t.Errorf("expected no error, got %q", rec.Error)
+	}
+
+	if records[1].Error != "boom" {
+		t.Errorf("expected handler error to be recorded, got %q", records[1].Error)
+	}
+}
+
+func TestAuditLog_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := NewAuditLog(path, 300, 2)
+	if err != nil {
+		t.Fatalf("NewAuditLog failed: %v", err)
+	}
+	defer auditLog.Close()
+
+	// Each record is ~120 bytes, so every file holds two of them
+	for i := 0; i < 8; i++ {
+		if err := auditLog.Write(AuditRecord{Tool: "get_projects", Error: strings.Repeat("e", 40)}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 300 {
+			t.Errorf("%s exceeds max size: %d bytes", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("expected at most 2 backups")
+	}
+}
+
+func TestNewAuditLog_RequiresPath(t *testing.T) {
+	if _, err := NewAuditLog("", 0, 0); err == nil {
+		t.Error("expected error for empty path")
+	}
+}
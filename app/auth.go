@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TokenHeader is an alternative to bearer auth for passing a Toggl API token
+	TokenHeader = "X-Toggl-Api-Token"
+
+	// idleClientTTL is how long a per-user client is kept after its last use
+	idleClientTTL = time.Hour
+)
+
+// TokenFromRequest returns the Toggl API token carried by an HTTP request,
+// either as a bearer token or in the X-Toggl-Api-Token header
+func TokenFromRequest(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		scheme, token, ok := strings.Cut(auth, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get(TokenHeader))
+}
+
+// TokenFingerprint returns a short, non-reversible identifier for a token
+// that is safe to log or use in file names
+func TokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:6])
+}
+
+// pooledClient is a per-user client and when it was last used
+type pooledClient struct {
+	client   *TogglClient
+	lastUsed time.Time
+}
+
+// ClientPool keeps one TogglClient per API token so that every user of a
+// shared server acts as themselves. Tokens are only held inside the clients;
+// the pool is keyed by fingerprint.
+type ClientPool struct {
+	mu        sync.Mutex
+	newClient func(token string) *TogglClient
+	clients   map[string]*pooledClient
+	now       func() time.Time
+}
+
+// NewClientPool creates a pool that builds missing clients with newClient
+func NewClientPool(newClient func(token string) *TogglClient) *ClientPool {
+	return &ClientPool{
+		newClient: newClient,
+		clients:   make(map[string]*pooledClient),
+		now:       time.Now,
+	}
+}
+
+// Get returns the client for token, creating it on first use
+func (p *ClientPool) Get(token string) *TogglClient {
+	key := TokenFingerprint(token)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for k, pc := range p.clients {
+		if now.Sub(pc.lastUsed) > idleClientTTL {
+			delete(p.clients, k)
+		}
+	}
+
+	pc, ok := p.clients[key]
+	if !ok {
+		pc = &pooledClient{client: p.newClient(token)}
+		p.clients[key] = pc
+	}
+	pc.lastUsed = now
+
+	return pc.client
+}
+
+// ContextFunc returns a transport context function that attaches the client
+// for the request's token, if it carries one. It can be used with both the
+// SSE and streamable HTTP transports.
+func (p *ClientPool) ContextFunc() func(context.Context, *http.Request) context.Context {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		token := TokenFromRequest(r)
+		if token == "" {
+			return ctx
+		}
+		return WithRequestClient(ctx, p.Get(token))
+	}
+}
+
+// requestClientKey is the context key holding the client for the current request
+type requestClientKey struct{}
+
+// WithRequestClient makes tool calls made with ctx use client instead of the
+// client passed to SetupTools
+func WithRequestClient(ctx context.Context, client *TogglClient) context.Context {
+	return context.WithValue(ctx, requestClientKey{}, client)
+}
+
+// requestClientFromContext returns the client attached to ctx, if any
+func requestClientFromContext(ctx context.Context) *TogglClient {
+	client, _ := ctx.Value(requestClientKey{}).(*TogglClient)
+	return client
+}
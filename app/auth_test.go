@@ -0,0 +1,180 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestTokenFromRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{name: "bearer", headers: map[string]string{"Authorization": "Bearer abc123"}, want: "abc123"},
+		{name: "bearer case-insensitive", headers: map[string]string{"Authorization": "bearer abc123"}, want: "abc123"},
+		{name: "token header", headers: map[string]string{TokenHeader: " abc123 "}, want: "abc123"},
+		{name: "bearer wins", headers: map[string]string{"Authorization": "Bearer one", TokenHeader: "two"}, want: "one"},
+		{name: "basic auth ignored", headers: map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, want: ""},
+		{name: "none", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := TokenFromRequest(r); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTokenFingerprint(t *testing.T) {
+	a, b := TokenFingerprint("token-a"), TokenFingerprint("token-b")
+	if a == b {
+		t.Error("expected different tokens to have different fingerprints")
+	}
+	if a != TokenFingerprint("token-a") {
+		t.Error("expected fingerprint to be stable")
+	}
+	if len(a) != 12 {
+		t.Errorf("expected 12 hex characters, got %q", a)
+	}
+}
+
+func TestClientPool(t *testing.T) {
+	created := 0
+	pool := NewClientPool(func(token string) *TogglClient {
+		created++
+		return NewTogglClient(token)
+	})
+	now := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	pool.now = func() time.Time { return now }
+
+	first := pool.Get("token-a")
+	if first.APIToken != "token-a" {
+		t.Errorf("expected client for token-a, got %q", first.APIToken)
+	}
+	if pool.Get("token-a") != first {
+		t.Error("expected the same client to be reused")
+	}
+	if pool.Get("token-b") == first {
+		t.Error("expected a different client for a different token")
+	}
+	if created != 2 {
+		t.Errorf("expected 2 clients created, got %d", created)
+	}
+
+	now = now.Add(idleClientTTL + time.Minute)
+	if pool.Get("token-a") == first {
+		t.Error("expected idle client to be evicted and recreated")
+	}
+}
+
+func TestClientPoolContextFunc(t *testing.T) {
+	pool := NewClientPool(func(token string) *TogglClient { return NewTogglClient(token) })
+	fn := pool.ContextFunc()
+
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if c := requestClientFromContext(fn(context.Background(), r)); c != nil {
+		t.Error("expected no client for a request without a token")
+	}
+
+	r.Header.Set("Authorization", "Bearer user-token")
+	c := requestClientFromContext(fn(context.Background(), r))
+	if c == nil || c.APIToken != "user-token" {
+		t.Errorf("expected client for user-token, got %+v", c)
+	}
+}
+
+func TestWrapHandlerClientSelection(t *testing.T) {
+	handler := wrapHandler(nil, func(_ context.Context, c *TogglClient, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(c.APIToken), nil
+	})
+
+	result, _ := handler(context.Background(), mcp.CallToolRequest{})
+	if !result.IsError {
+		t.Error("expected error without a default or request client")
+	}
+
+	result, _ = handler(WithRequestClient(context.Background(), NewTogglClient("user-token")), mcp.CallToolRequest{})
+	if result.IsError || result.Content[0].(mcp.TextContent).Text != "user-token" {
+		t.Errorf("expected request client to be used, got %+v", result)
+	}
+
+	shared := wrapHandler(NewTogglClient("shared-token"), func(_ context.Context, c *TogglClient, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(c.APIToken), nil
+	})
+	result, _ = shared(WithRequestClient(context.Background(), NewTogglClient("user-token")), mcp.CallToolRequest{})
+	if result.Content[0].(mcp.TextContent).Text != "user-token" {
+		t.Error("expected request client to take precedence over the shared client")
+	}
+}
+
+func TestStreamableHTTPServerPerRequestToken(t *testing.T) {
+	var gotToken string
+	ts, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotToken, _, _ = r.BasicAuth()
+		writeJSON(w, http.StatusOK, testUser)
+	})
+
+	pool := NewClientPool(func(token string) *TogglClient {
+		return NewTogglClient(token, WithHTTPClient(&http.Client{
+			Transport: &testTransport{testURL: ts.URL},
+		}))
+	})
+
+	s := server.NewMCPServer("test-server", "1.0.0")
+	if err := SetupTools(s, nil); err != nil {
+		t.Fatalf("SetupTools failed: %v", err)
+	}
+
+	mcpServer := newTestHTTPTransport(t, s, NewSessionStore(SessionIdleTTL, MaxSessions),
+		server.WithHTTPContextFunc(pool.ContextFunc()))
+
+	post := func(token, sessionID, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, mcpServer.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if sessionID != "" {
+			req.Header.Set(server.HeaderKeySessionID, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("sending request: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	init := post("alice-token", "", testInitialize)
+	sessionID := init.Header.Get(server.HeaderKeySessionID)
+	if sessionID == "" {
+		t.Fatal("expected a session ID")
+	}
+
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"test_connection","arguments":{}}}`
+	if resp := post("alice-token", sessionID, call); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if gotToken != "alice-token" {
+		t.Errorf("expected Toggl API to be called with alice-token, got %q", gotToken)
+	}
+
+	if resp := post("mallory-token", sessionID, call); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for a session used with another token, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,303 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Periods work_balance totals by
+const (
+	periodDay   = "day"
+	periodWeek  = "week"
+	periodMonth = "month"
+)
+
+// BalancePeriod compares the time tracked in a day, week or month with the
+// time the schedule makes due
+type BalancePeriod struct {
+	Period         string `json:"period"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	TrackedSeconds int    `json:"tracked_seconds"`
+	TargetSeconds  int    `json:"target_seconds"`
+	// DifferenceSeconds is tracked minus target time, positive for
+	// overtime; BalanceSeconds is the running balance at the period's end
+	DifferenceSeconds int `json:"difference_seconds"`
+	BalanceSeconds    int `json:"balance_seconds"`
+	Holidays          int `json:"holidays,omitempty"`
+	VacationDays      int `json:"vacation_days,omitempty"`
+	// InProgressSeconds is the time tracked today, which only counts
+	// towards the balance once the day has ended
+	InProgressSeconds int `json:"in_progress_seconds,omitempty"`
+}
+
+// WorkBalance is the overtime balance over a date range
+type WorkBalance struct {
+	From                   string          `json:"from"`
+	To                     string          `json:"to"`
+	Period                 string          `json:"period"`
+	Periods                []BalancePeriod `json:"periods"`
+	StartingBalanceSeconds int             `json:"starting_balance_seconds"`
+	TrackedSeconds         int             `json:"tracked_seconds"`
+	TargetSeconds          int             `json:"target_seconds"`
+	BalanceSeconds         int             `json:"balance_seconds"`
+	InProgressSeconds      int             `json:"in_progress_seconds,omitempty"`
+	Rounding               string          `json:"rounding,omitempty"`
+}
+
+// periodLabel names the day, week or month containing day; weeks are named
+// by the ISO week sharing most days with them
+func periodLabel(day time.Time, period string, weekStartDay time.Weekday) string {
+	switch period {
+	case periodDay:
+		return day.Format("2006-01-02")
+	case periodMonth:
+		return day.Format("2006-01")
+	}
+	year, week := weekStart(day, weekStartDay).AddDate(0, 0, 3).ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// buildWorkBalance totals the time tracked and due on each day from from
+// to to (exclusive) by period, and keeps a running balance from
+// startingBalance. Entries count on the day they start. A day in progress
+// counts once it has ended: until then neither its target nor its tracked
+// time is in the balance, and its tracked time is reported as in progress.
+func buildWorkBalance(
+	entries []TimeEntry,
+	from, to time.Time,
+	period string,
+	weekStartDay time.Weekday,
+	schedule Schedule,
+	startingBalance int,
+	rounding Rounding,
+	now time.Time,
+) WorkBalance {
+	balance := WorkBalance{
+		From:                   from.Format("2006-01-02"),
+		To:                     to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Period:                 period,
+		Periods:                []BalancePeriod{},
+		StartingBalanceSeconds: startingBalance,
+		BalanceSeconds:         startingBalance,
+	}
+	if rounding.Enabled() {
+		balance.Rounding = rounding.String()
+	}
+
+	tracked := make(map[string]int)
+	for _, entry := range entries {
+		seconds := rounding.RoundSeconds(int(entryEnd(entry, now).Sub(entry.Start).Seconds()))
+		if seconds > 0 {
+			tracked[entry.Start.In(from.Location()).Format("2006-01-02")] += seconds
+		}
+	}
+
+	var current *BalancePeriod
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		if label := periodLabel(day, period, weekStartDay); current == nil || current.Period != label {
+			balance.Periods = append(balance.Periods, BalancePeriod{Period: label, From: date})
+			current = &balance.Periods[len(balance.Periods)-1]
+		}
+		due, reason := schedule.due(day)
+		current.To = date
+		if day.AddDate(0, 0, 1).After(now) {
+			current.InProgressSeconds += tracked[date]
+		} else {
+			current.TrackedSeconds += tracked[date]
+			current.TargetSeconds += int(due.Seconds())
+		}
+		switch reason {
+		case dayHoliday:
+			current.Holidays++
+		case dayVacation:
+			current.VacationDays++
+		}
+	}
+
+	for i := range balance.Periods {
+		p := &balance.Periods[i]
+		p.DifferenceSeconds = p.TrackedSeconds - p.TargetSeconds
+		balance.TrackedSeconds += p.TrackedSeconds
+		balance.TargetSeconds += p.TargetSeconds
+		balance.InProgressSeconds += p.InProgressSeconds
+		balance.BalanceSeconds += p.DifferenceSeconds
+		p.BalanceSeconds = balance.BalanceSeconds
+	}
+	return balance
+}
+
+// formatBalanceHours formats seconds of overtime with a sign, e.g. "-1h 30m"
+func formatBalanceHours(seconds int) string {
+	if seconds < 0 {
+		return "-" + formatHours(-seconds)
+	}
+	return "+" + formatHours(seconds)
+}
+
+// formatWorkBalance describes the balance, one line per period. Days with
+// nothing tracked or due are left out, and time tracked today is noted as
+// not yet counted.
+func formatWorkBalance(balance WorkBalance) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Work balance %s to %s by %s", balance.From, balance.To, balance.Period)
+	if balance.StartingBalanceSeconds != 0 {
+		fmt.Fprintf(&b, ", starting at %s", formatBalanceHours(balance.StartingBalanceSeconds))
+	}
+	if balance.Rounding != "" {
+		fmt.Fprintf(&b, " (rounded %s)", balance.Rounding)
+	}
+	b.WriteString(":\n")
+
+	for _, p := range balance.Periods {
+		var notes []string
+		if balance.Period == periodDay {
+			if p.TrackedSeconds == 0 && p.TargetSeconds == 0 && p.InProgressSeconds == 0 && p.Holidays == 0 && p.VacationDays == 0 {
+				continue
+			}
+			date, _ := time.Parse("2006-01-02", p.From)
+			fmt.Fprintf(&b, "- %s %s", p.Period, date.Format("Mon"))
+			if p.Holidays > 0 {
+				notes = append(notes, dayHoliday)
+			}
+			if p.VacationDays > 0 {
+				notes = append(notes, dayVacation)
+			}
+		} else {
+			fmt.Fprintf(&b, "- %s (%s to %s)", p.Period, p.From, p.To)
+			if p.Holidays > 0 {
+				notes = append(notes, plural(p.Holidays, "holiday", "holidays"))
+			}
+			if p.VacationDays > 0 {
+				notes = append(notes, plural(p.VacationDays, "vacation day", "vacation days"))
+			}
+		}
+		if p.InProgressSeconds > 0 {
+			notes = append(notes, formatHours(p.InProgressSeconds)+" today not counted yet")
+		}
+		fmt.Fprintf(&b, ": %s tracked of %s due", formatHours(p.TrackedSeconds), formatHours(p.TargetSeconds))
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(notes, ", "))
+		}
+		fmt.Fprintf(&b, ", %s; balance %s\n", formatBalanceHours(p.DifferenceSeconds), formatBalanceHours(p.BalanceSeconds))
+	}
+
+	fmt.Fprintf(&b, "\nTotal: %s tracked of %s due, %s; balance %s",
+		formatHours(balance.TrackedSeconds), formatHours(balance.TargetSeconds),
+		formatBalanceHours(balance.TrackedSeconds-balance.TargetSeconds), formatBalanceHours(balance.BalanceSeconds))
+	if balance.InProgressSeconds > 0 {
+		fmt.Fprintf(&b, "\nToday is in progress: its %s tracked so far and its hours due count once the day has ended.",
+			formatHours(balance.InProgressSeconds))
+	}
+	return b.String()
+}
+
+// plural formats a count with the singular or plural noun
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
+
+// balanceTools returns the tool that compares tracked time with the schedule
+func balanceTools(client *TogglClient, schedule Schedule, weekStartDay time.Weekday) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"work_balance",
+				mcp.WithDescription("Compare the time tracked per day, week or month with the hours due under the configured schedule, which leaves out public holidays and vacation days, and keep a running overtime balance. Positive balances are overtime."),
+				mcp.WithString("start_date", mcp.Required(), mcp.Description("First day, YYYY-MM-DD")),
+				mcp.WithString("end_date", mcp.Description("Day after the last day, YYYY-MM-DD (exclusive; default: tomorrow). Days after today are left out, and today only counts once it has ended")),
+				mcp.WithString("period",
+					mcp.Enum(periodDay, periodWeek, periodMonth),
+					mcp.Description("Total by day, week (default) or month"),
+				),
+				mcp.WithNumber("starting_balance_hours", mcp.Description("Balance carried over from before start_date, in hours; negative for undertime")),
+				mcp.WithBoolean("rounded", mcp.Description("Round each entry with the configured rounding before totalling")),
+				mcp.WithNumber("workspace_id", mcp.Description("Only count entries in this workspace (default: all workspaces)")),
+			),
+			handler: wrapHandler(client, handleWorkBalance(schedule, weekStartDay)),
+		},
+	}
+}
+
+func handleWorkBalance(
+	schedule Schedule,
+	weekStartDay time.Weekday,
+) func(context.Context, *TogglClient, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := req.GetArguments()
+		loc := client.timeZone()
+		now := time.Now().In(loc)
+		tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+
+		startDate, err := getRequiredDate(args, "start_date")
+		if err != nil {
+			return nil, err
+		}
+		from := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+		to := tomorrow
+		if getOptionalString(args, "end_date") != "" {
+			endDate, err := getRequiredDate(args, "end_date")
+			if err != nil {
+				return nil, err
+			}
+			to = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc)
+			if !to.After(from) {
+				return nil, errors.New("end_date must be after start_date")
+			}
+			if to.After(tomorrow) {
+				to = tomorrow
+			}
+		}
+		if !to.After(from) {
+			return nil, errors.New("start_date must not be after today")
+		}
+
+		period := getOptionalString(args, "period")
+		switch period {
+		case "":
+			period = periodWeek
+		case periodDay, periodWeek, periodMonth:
+		default:
+			return nil, fmt.Errorf("period must be %s, %s or %s", periodDay, periodWeek, periodMonth)
+		}
+		var startingBalance int
+		if hours, ok := args["starting_balance_hours"].(float64); ok {
+			startingBalance = int(hours * 3600)
+		}
+		rounding, err := requestedRounding(ctx, args, "rounded")
+		if err != nil {
+			return nil, err
+		}
+		workspaceID := getOptionalNumber(args, "workspace_id")
+
+		entries, err := client.GetTimeEntries(ctx, from, to)
+		if err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to compute work balance: %s", apiErr.Error())), nil
+			}
+			return nil, fmt.Errorf("computing work balance: %w", err)
+		}
+		var selected []TimeEntry
+		for _, entry := range entries {
+			if workspaceID == nil || entry.WorkspaceID == *workspaceID {
+				selected = append(selected, entry)
+			}
+		}
+
+		balance := buildWorkBalance(selected, from, to, period, weekStartDay, schedule, startingBalance, rounding, now)
+		if wantsJSON(ctx) {
+			return jsonResult(balance)
+		}
+		return mcp.NewToolResultText(formatWorkBalance(balance)), nil
+	}
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// balanceEntry is a time entry day days after Monday 2024-03-11
+func balanceEntry(id, day int, start, end string) TimeEntry {
+	e := lintEntry(id, "Work", start, end, true)
+	e.Start = e.Start.AddDate(0, 0, day-4)
+	stop := e.Stop.AddDate(0, 0, day-4)
+	e.Stop = &stop
+	return e
+}
+
+func TestBuildWorkBalance(t *testing.T) {
+	schedule, err := ScheduleConfig{
+		Hours:    DefaultConfig().Schedule.Hours,
+		Holidays: []string{"2024-03-15"},
+		Vacation: []string{"2024-03-20"},
+	}.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries := []TimeEntry{
+		balanceEntry(1, 0, "08:00", "17:00"),
+		balanceEntry(2, 1, "09:00", "17:00"),
+		balanceEntry(3, 2, "09:00", "17:00"),
+		balanceEntry(4, 3, "09:00", "17:00"),
+		balanceEntry(5, 4, "10:00", "12:00"),
+		balanceEntry(6, 7, "09:00", "17:00"),
+		balanceEntry(7, 8, "09:00", "15:00"),
+		balanceEntry(8, 10, "09:00", "17:00"),
+		balanceEntry(9, 11, "09:00", "17:00"),
+	}
+	from := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 25, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 26, 0, 0, 0, 0, time.UTC)
+
+	weekly := buildWorkBalance(entries, from, to, periodWeek, time.Monday, schedule, 3600, Rounding{}, now)
+	if len(weekly.Periods) != 2 {
+		t.Fatalf("expected 2 weeks, got %+v", weekly.Periods)
+	}
+	first, second := weekly.Periods[0], weekly.Periods[1]
+	if first.Period != "2024-W11" || first.TrackedSeconds != 35*3600 || first.TargetSeconds != 32*3600 ||
+		first.DifferenceSeconds != 3*3600 || first.BalanceSeconds != 4*3600 || first.Holidays != 1 {
+		t.Errorf("unexpected first week: %+v", first)
+	}
+	if second.From != "2024-03-18" || second.To != "2024-03-24" || second.DifferenceSeconds != -2*3600 ||
+		second.BalanceSeconds != 2*3600 || second.VacationDays != 1 {
+		t.Errorf("unexpected second week: %+v", second)
+	}
+	if weekly.BalanceSeconds != 2*3600 || weekly.TrackedSeconds != 65*3600 || weekly.TargetSeconds != 64*3600 {
+		t.Errorf("unexpected totals: %+v", weekly)
+	}
+
+	text := formatWorkBalance(weekly)
+	for _, want := range []string{
+		"Work balance 2024-03-11 to 2024-03-24 by week, starting at +1h 00m:",
+		"- 2024-W11 (2024-03-11 to 2024-03-17): 35h 00m tracked of 32h 00m due (1 holiday), +3h 00m; balance +4h 00m",
+		"- 2024-W12 (2024-03-18 to 2024-03-24): 30h 00m tracked of 32h 00m due (1 vacation day), -2h 00m; balance +2h 00m",
+		"Total: 65h 00m tracked of 64h 00m due, +1h 00m; balance +2h 00m",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+
+	daily := formatWorkBalance(buildWorkBalance(entries[:5], from, from.AddDate(0, 0, 7), periodDay, time.Monday, schedule, 0, Rounding{}, now))
+	if !strings.Contains(daily, "- 2024-03-15 Fri: 2h 00m tracked of 0h 00m due (holiday), +2h 00m; balance +3h 00m") ||
+		strings.Contains(daily, "2024-03-16") {
+		t.Errorf("unexpected daily balance:\n%s", daily)
+	}
+
+	monthly := buildWorkBalance(nil, time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		periodMonth, time.Monday, schedule, 0, Rounding{}, now)
+	if len(monthly.Periods) != 2 || monthly.Periods[0].Period != "2024-02" || monthly.Periods[0].TargetSeconds != 32*3600 ||
+		monthly.Periods[1].TargetSeconds != 16*3600 || monthly.BalanceSeconds != -48*3600 {
+		t.Errorf("unexpected monthly balance: %+v", monthly)
+	}
+
+	midday := time.Date(2024, 3, 12, 12, 0, 0, 0, time.UTC)
+	today := buildWorkBalance(entries[:2], from, from.AddDate(0, 0, 2), periodDay, time.Monday, schedule, 0, Rounding{}, midday)
+	if today.TargetSeconds != 8*3600 || today.Periods[1].TargetSeconds != 0 || today.Periods[1].TrackedSeconds != 0 ||
+		today.InProgressSeconds != 8*3600 || today.BalanceSeconds != 3600 {
+		t.Errorf("expected today not to count before the day ends: %+v", today)
+	}
+	text = formatWorkBalance(today)
+	for _, want := range []string{
+		"- 2024-03-12 Tue: 0h 00m tracked of 0h 00m due (8h 00m today not counted yet), +0h 00m; balance +1h 00m",
+		"Today is in progress: its 8h 00m tracked so far and its hours due count once the day has ended.",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestHandleWorkBalance(t *testing.T) {
+	elsewhere := balanceEntry(2, 1, "09:00", "17:00")
+	elsewhere.WorkspaceID = 789
+
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v9/me/time_entries" {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, []TimeEntry{balanceEntry(1, 0, "09:00", "18:00"), elsewhere})
+	})
+	client.location = time.UTC
+	handler := handleWorkBalance(DefaultSchedule(), time.Monday)
+
+	call := func(args map[string]interface{}) (*mcp.CallToolResult, error) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		return handler(context.Background(), client, req)
+	}
+
+	result, err := call(map[string]interface{}{"start_date": "2024-03-11", "end_date": "2024-03-13", "period": "day"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "- 2024-03-12 Tue: 8h 00m tracked of 8h 00m due, +0h 00m; balance +1h 00m") {
+		t.Errorf("unexpected balance:\n%s", text)
+	}
+
+	result, err = call(map[string]interface{}{
+		"start_date": "2024-03-11", "end_date": "2024-03-13", "workspace_id": float64(456), "starting_balance_hours": -2.5,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "balance -9h 30m") {
+		t.Errorf("expected only workspace 456 to count:\n%s", text)
+	}
+
+	ctx := context.WithValue(context.Background(), outputFormatKey{}, OutputJSON)
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"start_date": "2024-03-11", "end_date": "2024-03-18", "period": "month"}
+	result, err = handler(ctx, client, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var balance WorkBalance
+	if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &balance); err != nil {
+		t.Fatalf("decoding balance: %v", err)
+	}
+	if balance.TargetSeconds != 40*3600 || balance.BalanceSeconds != -23*3600 || len(balance.Periods) != 1 {
+		t.Errorf("unexpected balance: %+v", balance)
+	}
+
+	if _, err := call(map[string]interface{}{"start_date": time.Now().AddDate(0, 0, 2).Format("2006-01-02")}); err == nil {
+		t.Error("expected an error for a future start_date")
+	}
+}
@@ -0,0 +1,435 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Where an hourly rate comes from, in order of precedence
+const (
+	RateEntry     = "entry"
+	RateProject   = "project"
+	RateMember    = "workspace member"
+	RateWorkspace = "workspace"
+)
+
+// noClientName labels time on projects without a client
+const noClientName = "(no client)"
+
+// billingRates holds the rates and currencies that apply in a workspace
+type billingRates struct {
+	projects map[int]Project
+	// member is the current user's rate in the workspace, if any
+	member    *float64
+	workspace Workspace
+}
+
+// rate returns the hourly rate for entry and where it comes from: the
+// entry's own rate, then its project's, the member's and the workspace's.
+// ok is false when no rate applies.
+func (r billingRates) rate(entry TimeEntry) (rate float64, source string, ok bool) {
+	if entry.Rate != nil {
+		return *entry.Rate, RateEntry, true
+	}
+	if entry.ProjectID != nil {
+		if project := r.projects[*entry.ProjectID]; project.Rate != nil {
+			return *project.Rate, RateProject, true
+		}
+	}
+	if r.member != nil {
+		return *r.member, RateMember, true
+	}
+	if r.workspace.DefaultHourlyRate != nil {
+		return *r.workspace.DefaultHourlyRate, RateWorkspace, true
+	}
+	return 0, "", false
+}
+
+// currency returns the currency entry is billed in: its project's, or
+// else the workspace's
+func (r billingRates) currency(entry TimeEntry) string {
+	if entry.ProjectID != nil {
+		if project := r.projects[*entry.ProjectID]; project.Currency != nil && *project.Currency != "" {
+			return *project.Currency
+		}
+	}
+	return r.workspace.DefaultCurrency
+}
+
+// BillableRow is the time and amount billed on one project, or on one
+// task of it
+type BillableRow struct {
+	ClientID           *int   `json:"client_id,omitempty"`
+	Client             string `json:"client"`
+	ProjectID          *int   `json:"project_id,omitempty"`
+	Project            string `json:"project"`
+	TaskID             *int   `json:"task_id,omitempty"`
+	Task               string `json:"task,omitempty"`
+	BillableSeconds    int    `json:"billable_seconds"`
+	NonBillableSeconds int    `json:"non_billable_seconds"`
+	// Rate and RateSource are set when all billable time has one rate
+	Rate       *float64 `json:"rate,omitempty"`
+	RateSource string   `json:"rate_source,omitempty"`
+	Currency   string   `json:"currency,omitempty"`
+	Amount     float64  `json:"amount"`
+	// UnratedSeconds is billable time no rate applies to, which is left
+	// out of Amount
+	UnratedSeconds int `json:"unrated_seconds,omitempty"`
+
+	// mixedRates is set once billable time with different rates is seen
+	mixedRates bool
+}
+
+// BillableSummary totals billable time and amounts per client and project
+type BillableSummary struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Rounding describes the rounding applied to each entry, if any
+	Rounding           string        `json:"rounding,omitempty"`
+	Rows               []BillableRow `json:"rows"`
+	BillableSeconds    int           `json:"billable_seconds"`
+	NonBillableSeconds int           `json:"non_billable_seconds"`
+	UnratedSeconds     int           `json:"unrated_seconds,omitempty"`
+	// Totals is the amount billed in each currency
+	Totals map[string]float64 `json:"totals"`
+}
+
+// buildBillableSummary totals entries per client and project, sorted by
+// client and then project name. With taskNames set, each project's time is
+// also split by task. Running entries count up to now; with rounding
+// enabled, each entry is rounded before it is added.
+func buildBillableSummary(
+	entries []TimeEntry,
+	rates billingRates,
+	clientNames map[int]string,
+	taskNames map[int]string,
+	rounding Rounding,
+	now time.Time,
+) BillableSummary {
+	summary := BillableSummary{Totals: make(map[string]float64)}
+	if rounding.Enabled() {
+		summary.Rounding = rounding.String()
+	}
+	type rowKey struct{ project, task int }
+	rows := make(map[rowKey]*BillableRow)
+
+	for _, entry := range entries {
+		seconds := int(entryEnd(entry, now).Sub(entry.Start).Seconds())
+		if seconds <= 0 {
+			continue
+		}
+
+		var key rowKey
+		if entry.ProjectID != nil {
+			key.project = *entry.ProjectID
+		}
+		if taskNames != nil && entry.TaskID != nil {
+			key.task = *entry.TaskID
+		}
+		row, ok := rows[key]
+		if !ok {
+			row = &BillableRow{Client: noClientName, Project: noProjectName, Currency: rates.currency(entry)}
+			if key.task != 0 {
+				row.TaskID, row.Task = entry.TaskID, taskName(taskNames, key.task)
+			}
+			if entry.ProjectID != nil {
+				project := rates.projects[key.project]
+				row.ProjectID = entry.ProjectID
+				row.Project = project.Name
+				if row.Project == "" {
+					row.Project = fmt.Sprintf("Project %d", key.project)
+				}
+				if project.ClientID != nil {
+					row.ClientID = project.ClientID
+					row.Client = clientNames[*project.ClientID]
+					if row.Client == "" {
+						row.Client = fmt.Sprintf("Client %d", *project.ClientID)
+					}
+				}
+			}
+			rows[key] = row
+		}
+
+		if !entry.Billable {
+			row.NonBillableSeconds += seconds
+			summary.NonBillableSeconds += seconds
+			continue
+		}
+
+		seconds = rounding.RoundSeconds(seconds)
+		row.BillableSeconds += seconds
+		summary.BillableSeconds += seconds
+
+		rate, source, ok := rates.rate(entry)
+		if !ok {
+			row.UnratedSeconds += seconds
+			summary.UnratedSeconds += seconds
+			continue
+		}
+		row.Amount += rate * float64(seconds) / 3600
+		switch {
+		case row.mixedRates:
+		case row.Rate == nil:
+			row.Rate, row.RateSource = &rate, source
+		case *row.Rate != rate || row.RateSource != source:
+			row.Rate, row.RateSource, row.mixedRates = nil, "", true
+		}
+	}
+
+	for _, row := range rows {
+		row.Amount = roundCents(row.Amount)
+		if row.Amount != 0 {
+			summary.Totals[row.Currency] = roundCents(summary.Totals[row.Currency] + row.Amount)
+		}
+		summary.Rows = append(summary.Rows, *row)
+	}
+	sort.Slice(summary.Rows, func(i, j int) bool {
+		a, b := summary.Rows[i], summary.Rows[j]
+		if a.Client != b.Client {
+			return a.Client < b.Client
+		}
+		if a.Project != b.Project {
+			return a.Project < b.Project
+		}
+		return a.Task < b.Task
+	})
+	return summary
+}
+
+// roundCents rounds an amount to two decimal places
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// formatAmount formats an amount with its currency, e.g. "1250.00 EUR"
+func formatAmount(amount float64, currency string) string {
+	if currency == "" {
+		return fmt.Sprintf("%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// formatBillableSummary lists each client's projects with their billable
+// time and amounts, then the totals
+func formatBillableSummary(summary BillableSummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Billable summary %s to %s", summary.From, summary.To)
+	if summary.Rounding != "" {
+		fmt.Fprintf(&b, " (rounded %s)", summary.Rounding)
+	}
+	b.WriteString(":\n")
+	if len(summary.Rows) == 0 {
+		b.WriteString("No time tracked")
+		return b.String()
+	}
+
+	client := ""
+	for i, row := range summary.Rows {
+		if i == 0 || row.Client != client {
+			client = row.Client
+			fmt.Fprintf(&b, "\n%s\n", client)
+		}
+		b.WriteString("- " + row.Project)
+		if row.Task != "" {
+			b.WriteString(" / " + row.Task)
+		}
+		fmt.Fprintf(&b, ": %s billable", formatHours(row.BillableSeconds))
+		if row.NonBillableSeconds > 0 {
+			fmt.Fprintf(&b, ", %s non-billable", formatHours(row.NonBillableSeconds))
+		}
+		switch {
+		case row.BillableSeconds == 0:
+		case row.Rate != nil:
+			fmt.Fprintf(&b, " at %s/h (%s rate) = %s", formatAmount(*row.Rate, row.Currency), row.RateSource,
+				formatAmount(row.Amount, row.Currency))
+		case row.Amount != 0:
+			fmt.Fprintf(&b, " at various rates = %s", formatAmount(row.Amount, row.Currency))
+		}
+		if row.UnratedSeconds > 0 {
+			fmt.Fprintf(&b, " (%s without a rate)", formatHours(row.UnratedSeconds))
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "\nTotal: %s billable, %s non-billable", formatHours(summary.BillableSeconds),
+		formatHours(summary.NonBillableSeconds))
+	currencies := make([]string, 0, len(summary.Totals))
+	for currency := range summary.Totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for i, currency := range currencies {
+		sep := "; "
+		if i > 0 {
+			sep = " + "
+		}
+		b.WriteString(sep + formatAmount(summary.Totals[currency], currency))
+	}
+	if summary.UnratedSeconds > 0 {
+		fmt.Fprintf(&b, "\n%s of billable time has no rate; set a project or workspace rate to price it",
+			formatHours(summary.UnratedSeconds))
+	}
+	return b.String()
+}
+
+// billingTools returns the tool that totals billable time and amounts
+func billingTools(client *TogglClient) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"billable_summary",
+				mcp.WithDescription("Total the billable hours and amounts per client and project in a date range, e.g. to see how much can be invoiced. Each entry's hourly rate is its own, else its project's, else the user's workspace member rate, else the workspace default."),
+				mcp.WithString("start_date", mcp.Required(), mcp.Description("First day, YYYY-MM-DD")),
+				mcp.WithString("end_date", mcp.Required(), mcp.Description("Day after the last day, YYYY-MM-DD (exclusive)")),
+				mcp.WithString("client", mcp.Description("Only projects of this client, by name")),
+				mcp.WithString("project", mcp.Description("Only this project, by name")),
+				mcp.WithBoolean("by_task", mcp.Description("Also split each project's time by task")),
+				mcp.WithBoolean("rounded", mcp.Description("Round each entry with the configured rounding before totalling")),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
+			),
+			handler: wrapHandler(client, handleBillableSummary),
+		},
+	}
+}
+
+func handleBillableSummary(
+	ctx context.Context,
+	client *TogglClient,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	workspaceID, err := getWorkspaceID(args, client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace_id: %w", err)
+	}
+	rounding, err := requestedRounding(ctx, args, "rounded")
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := dateRangeArgs(args, client)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, clientNames, err := client.billingRates(ctx, workspaceID)
+	if err != nil {
+		return apiErrorResult("summarize billable time", err)
+	}
+
+	entries, err := client.GetTimeEntries(ctx, from, to)
+	if err != nil {
+		return apiErrorResult("summarize billable time", err)
+	}
+	clientName, projectName := getOptionalString(args, "client"), getOptionalString(args, "project")
+	var selected []TimeEntry
+	for _, entry := range entries {
+		if entry.WorkspaceID != workspaceID {
+			continue
+		}
+		var project Project
+		if entry.ProjectID != nil {
+			project = rates.projects[*entry.ProjectID]
+		}
+		if projectName != "" && !strings.EqualFold(project.Name, projectName) {
+			continue
+		}
+		if clientName != "" && (project.ClientID == nil || !strings.EqualFold(clientNames[*project.ClientID], clientName)) {
+			continue
+		}
+		selected = append(selected, entry)
+	}
+
+	var taskNames map[int]string
+	if getOptionalBool(args, "by_task") {
+		if taskNames, err = client.entryTaskNames(ctx, workspaceID, selected); err != nil {
+			return apiErrorResult("summarize billable time", err)
+		}
+	}
+
+	summary := buildBillableSummary(selected, rates, clientNames, taskNames, rounding, time.Now())
+	summary.From = from.Format("2006-01-02")
+	summary.To = to.AddDate(0, 0, -1).Format("2006-01-02")
+
+	if wantsJSON(ctx) {
+		return jsonResult(summary)
+	}
+	return mcp.NewToolResultText(formatBillableSummary(summary)), nil
+}
+
+// billingRates fetches the rates that apply in a workspace, and the names
+// of its clients. Member rates are only visible to workspace admins, so
+// when they cannot be listed the member rate is skipped.
+func (c *TogglClient) billingRates(ctx context.Context, workspaceID int) (billingRates, map[int]string, error) {
+	rates := billingRates{projects: make(map[int]Project)}
+	var err error
+	if rates.workspace, err = c.GetWorkspace(ctx, workspaceID); err != nil {
+		return rates, nil, err
+	}
+
+	// Archived projects keep their rates for the time tracked on them
+	projects, err := c.GetAllProjects(ctx, workspaceID)
+	if err != nil {
+		return rates, nil, err
+	}
+	for _, project := range projects {
+		rates.projects[project.ID] = project
+	}
+
+	clients, err := c.GetClients(ctx, workspaceID)
+	if err != nil {
+		return rates, nil, err
+	}
+	clientNames := make(map[int]string, len(clients))
+	for _, client := range clients {
+		clientNames[client.ID] = client.Name
+	}
+
+	me, err := c.GetMe(ctx)
+	if err != nil {
+		return rates, nil, err
+	}
+	members, err := c.GetWorkspaceUsers(ctx, workspaceID)
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusForbidden || apiErr.StatusCode == http.StatusPaymentRequired):
+		c.logger.Debug("skipping workspace member rates",
+			slog.Int("workspace_id", workspaceID), slog.Int("status", apiErr.StatusCode))
+	case err != nil:
+		return rates, nil, err
+	}
+	for _, member := range members {
+		if member.UserID == me.ID && member.Rate != nil {
+			rates.member = member.Rate
+		}
+	}
+	return rates, clientNames, nil
+}
+
+// dateRangeArgs reads the required start_date and end_date arguments as
+// midnights in the client's timezone
+func dateRangeArgs(args map[string]interface{}, client *TogglClient) (from, to time.Time, err error) {
+	loc := client.timeZone()
+	startDate, err := getRequiredDate(args, "start_date")
+	if err != nil {
+		return from, to, err
+	}
+	endDate, err := getRequiredDate(args, "end_date")
+	if err != nil {
+		return from, to, err
+	}
+	from = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+	to = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc)
+	if !to.After(from) {
+		return from, to, errors.New("end_date must be after start_date")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,188 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestBillingRatesRate(t *testing.T) {
+	rated := Project{BaseEntity: BaseEntity{ID: 1}, Rate: floatPtr(120)}
+	unrated := Project{BaseEntity: BaseEntity{ID: 2}}
+	projects := map[int]Project{1: rated, 2: unrated}
+	workspace := Workspace{DefaultHourlyRate: floatPtr(50)}
+
+	tests := []struct {
+		name       string
+		rates      billingRates
+		entry      TimeEntry
+		wantRate   float64
+		wantSource string
+		wantOK     bool
+	}{
+		{
+			name:  "entry",
+			rates: billingRates{projects: projects, member: floatPtr(80), workspace: workspace},
+			entry: TimeEntry{ProjectID: intPtr(1), Rate: floatPtr(150)}, wantRate: 150, wantSource: RateEntry, wantOK: true,
+		},
+		{
+			name:  "project",
+			rates: billingRates{projects: projects, member: floatPtr(80), workspace: workspace},
+			entry: TimeEntry{ProjectID: intPtr(1)}, wantRate: 120, wantSource: RateProject, wantOK: true,
+		},
+		{
+			name:  "member",
+			rates: billingRates{projects: projects, member: floatPtr(80), workspace: workspace},
+			entry: TimeEntry{ProjectID: intPtr(2)}, wantRate: 80, wantSource: RateMember, wantOK: true,
+		},
+		{
+			name:  "workspace",
+			rates: billingRates{projects: projects, workspace: workspace},
+			entry: TimeEntry{}, wantRate: 50, wantSource: RateWorkspace, wantOK: true,
+		},
+		{
+			name:  "none",
+			rates: billingRates{projects: projects},
+			entry: TimeEntry{ProjectID: intPtr(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, source, ok := tt.rates.rate(tt.entry)
+			if rate != tt.wantRate || source != tt.wantSource || ok != tt.wantOK {
+				t.Errorf("rate() = %v, %q, %v; want %v, %q, %v", rate, source, ok, tt.wantRate, tt.wantSource, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBuildBillableSummary(t *testing.T) {
+	usd := "USD"
+	rates := billingRates{
+		projects: map[int]Project{
+			111: {BaseEntity: BaseEntity{ID: 111}, Name: "Website", ClientID: intPtr(7), Rate: floatPtr(100)},
+			222: {BaseEntity: BaseEntity{ID: 222}, Name: "Support", ClientID: intPtr(7), Rate: floatPtr(90), Currency: &usd},
+		},
+		workspace: Workspace{DefaultCurrency: "EUR"},
+	}
+	entry := func(id int, project *int, start, end string, billable bool) TimeEntry {
+		e := lintEntry(id, "Work", start, end, false)
+		e.ProjectID, e.Billable = project, billable
+		return e
+	}
+	entries := []TimeEntry{
+		entry(1, intPtr(111), "09:00", "10:30", true),
+		entry(2, intPtr(111), "10:30", "10:40", true),
+		entry(3, intPtr(111), "11:00", "12:00", false),
+		entry(4, intPtr(222), "13:00", "14:00", true),
+		entry(5, nil, "14:00", "15:00", true),
+	}
+	entries[1].Rate = floatPtr(120)
+	now := time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)
+
+	summary := buildBillableSummary(entries, rates, map[int]string{7: "Acme"}, nil, Rounding{}, now)
+	if len(summary.Rows) != 3 {
+		t.Fatalf("expected 3 rows, got %+v", summary.Rows)
+	}
+	website := summary.Rows[2]
+	if website.Project != "Website" || website.BillableSeconds != 6000 || website.NonBillableSeconds != 3600 ||
+		website.Amount != 170 || website.Rate != nil {
+		t.Errorf("unexpected website row: %+v", website)
+	}
+	if support := summary.Rows[1]; support.Amount != 90 || support.Currency != "USD" || support.RateSource != RateProject {
+		t.Errorf("unexpected support row: %+v", support)
+	}
+	if none := summary.Rows[0]; none.Client != noClientName || none.UnratedSeconds != 3600 || none.Amount != 0 {
+		t.Errorf("unexpected row without project: %+v", none)
+	}
+	if summary.Totals["EUR"] != 170 || summary.Totals["USD"] != 90 || summary.UnratedSeconds != 3600 {
+		t.Errorf("unexpected totals: %+v", summary)
+	}
+
+	rounded := buildBillableSummary(entries[:2], rates, nil, nil, Rounding{Mode: RoundUp, Increment: 15 * time.Minute}, now)
+	if rounded.BillableSeconds != 6300 || rounded.Totals["EUR"] != 180 {
+		t.Errorf("unexpected rounded summary: %+v", rounded)
+	}
+
+	entries[0].TaskID, entries[1].TaskID = intPtr(11), intPtr(12)
+	byTask := buildBillableSummary(entries[:2], rates, nil, map[int]string{11: "Design"}, Rounding{}, now)
+	if len(byTask.Rows) != 2 || byTask.Rows[0].Task != "Design" || byTask.Rows[0].BillableSeconds != 5400 ||
+		byTask.Rows[1].Task != "Task 12" || byTask.Rows[1].Amount != 20 {
+		t.Errorf("unexpected rows by task: %+v", byTask.Rows)
+	}
+}
+
+func TestHandleBillableSummary(t *testing.T) {
+	project := testProject
+	project.ClientID = intPtr(7)
+	project.Rate = floatPtr(100)
+	project.Active = false
+	billable := lintEntry(1, "Work", "09:00", "11:00", true)
+	billable.Billable = true
+	other := lintEntry(2, "Other", "11:00", "12:00", false)
+	other.Billable = true
+
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/workspaces/456":
+			writeJSON(w, http.StatusOK, Workspace{ID: 456, DefaultHourlyRate: floatPtr(60), DefaultCurrency: "EUR"})
+		case "/api/v9/workspaces/456/projects":
+			// Like the API, archived projects are only listed with active=both
+			if r.URL.Query().Get("active") != "both" {
+				writeJSON(w, http.StatusOK, []Project{})
+				return
+			}
+			writeJSON(w, http.StatusOK, []Project{project})
+		case "/api/v9/workspaces/456/clients":
+			writeJSON(w, http.StatusOK, []Client{{BaseEntity: BaseEntity{ID: 7}, Name: "Acme"}})
+		case "/api/v9/me":
+			writeJSON(w, http.StatusOK, testUser)
+		case "/api/v9/workspaces/456/workspace_users":
+			writeError(w, http.StatusForbidden, "admin only")
+		case "/api/v9/me/time_entries":
+			writeJSON(w, http.StatusOK, []TimeEntry{billable, other})
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	})
+	client.location = time.UTC
+
+	call := func(args map[string]interface{}) string {
+		t.Helper()
+		args["workspace_id"] = float64(456)
+		args["start_date"], args["end_date"] = "2024-03-15", "2024-03-16"
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		result, err := handleBillableSummary(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	text := call(map[string]interface{}{})
+	for _, want := range []string{
+		"Billable summary 2024-03-15 to 2024-03-15:",
+		"\n(no client)\n- (no project): 1h 00m billable at 60.00 EUR/h (workspace rate) = 60.00 EUR",
+		"\nAcme\n- Test Project: 2h 00m billable at 100.00 EUR/h (project rate) = 200.00 EUR",
+		"Total: 3h 00m billable, 0h 00m non-billable; 260.00 EUR",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+
+	if text := call(map[string]interface{}{"client": "acme"}); strings.Contains(text, "(no project)") ||
+		!strings.Contains(text, "Total: 2h 00m billable") {
+		t.Errorf("unexpected result for client: %s", text)
+	}
+}
@@ -0,0 +1,341 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultBurnDays is how many days back the burn rate is measured over
+const defaultBurnDays = 14
+
+// ProjectStatus compares the time tracked on a project with its estimate
+// or fixed fee
+type ProjectStatus struct {
+	ProjectID int    `json:"project_id"`
+	Project   string `json:"project"`
+	Client    string `json:"client,omitempty"`
+	// ActualSeconds is the time tracked on the project so far
+	ActualSeconds int `json:"actual_seconds"`
+	// ActualSince is set when the API does not report the project's total,
+	// in which case ActualSeconds only covers the user's own entries since
+	// that day
+	ActualSince      string   `json:"actual_since,omitempty"`
+	EstimatedSeconds int      `json:"estimated_seconds,omitempty"`
+	FixedFee         *float64 `json:"fixed_fee,omitempty"`
+	Currency         string   `json:"currency,omitempty"`
+	// Rate, Cost and EffectiveRate are set for fixed-fee projects with an
+	// hourly rate: the time tracked so far at that rate, and the fee per
+	// hour tracked
+	Rate          *float64 `json:"rate,omitempty"`
+	Cost          *float64 `json:"cost,omitempty"`
+	EffectiveRate *float64 `json:"effective_rate,omitempty"`
+	// BudgetSeconds is the estimate, or else the time the fixed fee pays
+	// for at the hourly rate; zero when the project has neither
+	BudgetSeconds    int      `json:"budget_seconds,omitempty"`
+	PercentUsed      *float64 `json:"percent_used,omitempty"`
+	RemainingSeconds *int     `json:"remaining_seconds,omitempty"`
+	// BurnSecondsPerDay is the average time the user's own entries tracked
+	// per day in the burn window; the API lists no one else's entries
+	BurnSecondsPerDay float64 `json:"burn_seconds_per_day"`
+	// ExhaustionDate is when the budget runs out at the current burn rate
+	ExhaustionDate string `json:"exhaustion_date,omitempty"`
+	Exhausted      bool   `json:"exhausted,omitempty"`
+	// Threshold is the highest configured percentage the project has reached
+	Threshold int `json:"threshold,omitempty"`
+}
+
+// ProjectStatusReport lists the status of projects with a common burn window
+type ProjectStatusReport struct {
+	// BurnFrom and BurnTo are the first and last day of the burn window
+	BurnFrom   string          `json:"burn_from"`
+	BurnTo     string          `json:"burn_to"`
+	BurnDays   int             `json:"burn_days"`
+	Thresholds []int           `json:"thresholds,omitempty"`
+	Projects   []ProjectStatus `json:"projects"`
+}
+
+// buildProjectStatus works out how much of project's budget is used.
+// actual is the time tracked so far, burned the time tracked in the
+// burnDays days before today. rate is the hourly rate used to turn a fixed
+// fee into time; ok is false when there is none.
+func buildProjectStatus(
+	project Project,
+	actual, burned, burnDays int,
+	rate float64, ok bool,
+	thresholds []int,
+	today time.Time,
+) ProjectStatus {
+	status := ProjectStatus{ProjectID: project.ID, Project: project.Name, ActualSeconds: actual}
+	if project.EstimatedHours != nil && *project.EstimatedHours > 0 {
+		status.EstimatedSeconds = *project.EstimatedHours * 3600
+		status.BudgetSeconds = status.EstimatedSeconds
+	}
+	if project.FixedFee != nil && *project.FixedFee > 0 {
+		fee := *project.FixedFee
+		status.FixedFee = &fee
+		if project.Currency != nil {
+			status.Currency = *project.Currency
+		}
+		if ok && rate > 0 {
+			cost := roundCents(rate * float64(actual) / 3600)
+			status.Rate, status.Cost = &rate, &cost
+			if status.BudgetSeconds == 0 {
+				status.BudgetSeconds = int(fee / rate * 3600)
+			}
+		}
+		if actual > 0 {
+			effective := roundCents(fee / (float64(actual) / 3600))
+			status.EffectiveRate = &effective
+		}
+	}
+	if burnDays > 0 {
+		status.BurnSecondsPerDay = float64(burned) / float64(burnDays)
+	}
+	if status.BudgetSeconds == 0 {
+		return status
+	}
+
+	percent := math.Round(float64(actual)/float64(status.BudgetSeconds)*1000) / 10
+	remaining := status.BudgetSeconds - actual
+	status.PercentUsed, status.RemainingSeconds = &percent, &remaining
+	switch {
+	case remaining <= 0:
+		status.Exhausted = true
+	case status.BurnSecondsPerDay > 0:
+		days := int(math.Ceil(float64(remaining) / status.BurnSecondsPerDay))
+		status.ExhaustionDate = today.AddDate(0, 0, days).Format("2006-01-02")
+	}
+	for _, threshold := range thresholds {
+		if percent >= float64(threshold) && threshold > status.Threshold {
+			status.Threshold = threshold
+		}
+	}
+	return status
+}
+
+// formatProjectStatus lists each project's budget use, most used first
+func formatProjectStatus(report ProjectStatusReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Project status, burn rate of your own entries over %s to %s:\n", report.BurnFrom, report.BurnTo)
+	if len(report.Projects) == 0 {
+		b.WriteString("No active projects with an estimate or fixed fee")
+		return b.String()
+	}
+
+	for _, p := range report.Projects {
+		b.WriteString("\n- " + p.Project)
+		if p.Client != "" {
+			fmt.Fprintf(&b, " (%s)", p.Client)
+		}
+		if p.Threshold > 0 {
+			fmt.Fprintf(&b, " [reached %d%%]", p.Threshold)
+		}
+		b.WriteString(": ")
+
+		switch {
+		case p.BudgetSeconds == 0:
+			fmt.Fprintf(&b, "%s tracked, no estimate", formatHours(p.ActualSeconds))
+		case p.EstimatedSeconds > 0:
+			fmt.Fprintf(&b, "%s of %s estimated (%.1f%%)", formatHours(p.ActualSeconds),
+				formatHours(p.BudgetSeconds), *p.PercentUsed)
+		default:
+			fmt.Fprintf(&b, "%s of %s the fee pays for (%.1f%%)", formatHours(p.ActualSeconds),
+				formatHours(p.BudgetSeconds), *p.PercentUsed)
+		}
+		if p.ActualSince != "" {
+			fmt.Fprintf(&b, " (own entries since %s)", p.ActualSince)
+		}
+		if p.RemainingSeconds != nil {
+			if *p.RemainingSeconds > 0 {
+				fmt.Fprintf(&b, ", %s left", formatHours(*p.RemainingSeconds))
+			} else {
+				fmt.Fprintf(&b, ", %s over", formatHours(-*p.RemainingSeconds))
+			}
+		}
+
+		if p.BurnSecondsPerDay > 0 {
+			fmt.Fprintf(&b, "; you burn %s/day", formatHours(int(p.BurnSecondsPerDay)))
+			if p.ExhaustionDate != "" {
+				fmt.Fprintf(&b, ", runs out on %s at your rate", p.ExhaustionDate)
+			}
+		} else {
+			b.WriteString("; none of your entries in the burn window")
+		}
+
+		if p.FixedFee != nil {
+			fmt.Fprintf(&b, "\n  Fixed fee %s", formatAmount(*p.FixedFee, p.Currency))
+			if p.Cost != nil {
+				fmt.Fprintf(&b, ": %s at %s/h so far", formatAmount(*p.Cost, p.Currency),
+					formatAmount(*p.Rate, p.Currency))
+			}
+			if p.EffectiveRate != nil {
+				fmt.Fprintf(&b, ", effective rate %s/h", formatAmount(*p.EffectiveRate, p.Currency))
+			}
+		}
+	}
+	return b.String()
+}
+
+// budgetTools returns the tool that tracks projects against their budgets
+func budgetTools(client *TogglClient, thresholds []int) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"project_status",
+				mcp.WithDescription("Compare the time tracked on projects with their estimated hours or fixed fee: percent used, time left, the burn rate of the user's own entries, the date the budget runs out at that rate, and which configured thresholds have been reached. Without a project, every active project with an estimate or fixed fee is listed."),
+				mcp.WithString("project", mcp.Description("Only this project, by name")),
+				mcp.WithString("client", mcp.Description("Only projects of this client, by name")),
+				mcp.WithNumber("burn_days", mcp.Description(fmt.Sprintf("Days before today to measure the burn rate of your own entries over (default %d)", defaultBurnDays))),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
+			),
+			handler: wrapHandler(client, handleProjectStatus(thresholds)),
+		},
+	}
+}
+
+// handleProjectStatus reports budget use, flagging the given thresholds
+func handleProjectStatus(thresholds []int) func(
+	context.Context,
+	*TogglClient,
+	mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := req.GetArguments()
+		workspaceID, err := getWorkspaceID(args, client)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace_id: %w", err)
+		}
+		burnDays := defaultBurnDays
+		if n := getOptionalNumber(args, "burn_days"); n != nil {
+			if *n <= 0 {
+				return nil, errors.New("burn_days must be positive")
+			}
+			burnDays = *n
+		}
+		projectName, clientName := getOptionalString(args, "project"), getOptionalString(args, "client")
+
+		loc := client.timeZone()
+		now := time.Now().In(loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		burnFrom := today.AddDate(0, 0, -burnDays)
+
+		rates, clientNames, err := client.billingRates(ctx, workspaceID)
+		if err != nil {
+			return apiErrorResult("get project status", err)
+		}
+
+		var projects []Project
+		for _, project := range rates.projects {
+			if projectName != "" && !strings.EqualFold(project.Name, projectName) {
+				continue
+			}
+			if clientName != "" && (project.ClientID == nil || !strings.EqualFold(clientNames[*project.ClientID], clientName)) {
+				continue
+			}
+			hasBudget := (project.EstimatedHours != nil && *project.EstimatedHours > 0) ||
+				(project.FixedFee != nil && *project.FixedFee > 0)
+			if projectName == "" && (!project.Active || !hasBudget) {
+				continue
+			}
+			projects = append(projects, project)
+		}
+		if projectName != "" && len(projects) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("No project named %q in workspace %d", projectName, workspaceID)), nil
+		}
+
+		// Projects the API reports no total for are counted from the user's
+		// own entries since the project's start, or over the burn window
+		from := burnFrom
+		since := make(map[int]time.Time)
+		for _, project := range projects {
+			if project.ActualSeconds != nil {
+				continue
+			}
+			start := burnFrom
+			if day, err := time.ParseInLocation("2006-01-02", project.StartDate, loc); err == nil {
+				start = day
+			}
+			since[project.ID] = start
+			if start.Before(from) {
+				from = start
+			}
+		}
+
+		// A project's start can be years back, so entries are fetched a
+		// page of days at a time
+		burned := make(map[int]int)
+		tracked := make(map[int]int)
+		if len(projects) > 0 {
+			err := client.searchPages(ctx, from, today.AddDate(0, 0, 1), false, func(entries []TimeEntry) bool {
+				for _, entry := range entries {
+					if entry.WorkspaceID != workspaceID || entry.ProjectID == nil {
+						continue
+					}
+					seconds := int(entryEnd(entry, now).Sub(entry.Start).Seconds())
+					if seconds <= 0 {
+						continue
+					}
+					id := *entry.ProjectID
+					if !entry.Start.Before(burnFrom) && entry.Start.Before(today) {
+						burned[id] += seconds
+					}
+					if start, ok := since[id]; ok && !entry.Start.Before(start) {
+						tracked[id] += seconds
+					}
+				}
+				return true
+			})
+			if err != nil {
+				return apiErrorResult("get project status", err)
+			}
+		}
+
+		report := ProjectStatusReport{
+			BurnFrom:   burnFrom.Format("2006-01-02"),
+			BurnTo:     today.AddDate(0, 0, -1).Format("2006-01-02"),
+			BurnDays:   burnDays,
+			Thresholds: thresholds,
+			Projects:   []ProjectStatus{},
+		}
+		for _, project := range projects {
+			actual := tracked[project.ID]
+			if project.ActualSeconds != nil {
+				actual = *project.ActualSeconds
+			}
+			rate, _, ok := rates.rate(TimeEntry{ProjectID: &project.ID})
+			status := buildProjectStatus(project, actual, burned[project.ID], burnDays, rate, ok, thresholds, today)
+			if start, ok := since[project.ID]; ok {
+				status.ActualSince = start.Format("2006-01-02")
+			}
+			if status.FixedFee != nil && status.Currency == "" {
+				status.Currency = rates.workspace.DefaultCurrency
+			}
+			if project.ClientID != nil {
+				status.Client = clientNames[*project.ClientID]
+			}
+			report.Projects = append(report.Projects, status)
+		}
+		sort.Slice(report.Projects, func(i, j int) bool {
+			a, b := report.Projects[i], report.Projects[j]
+			if (a.PercentUsed == nil) != (b.PercentUsed == nil) {
+				return a.PercentUsed != nil
+			}
+			if a.PercentUsed != nil && *a.PercentUsed != *b.PercentUsed {
+				return *a.PercentUsed > *b.PercentUsed
+			}
+			return a.Project < b.Project
+		})
+
+		if wantsJSON(ctx) {
+			return jsonResult(report)
+		}
+		return mcp.NewToolResultText(formatProjectStatus(report)), nil
+	}
+}
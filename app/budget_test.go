@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestBuildProjectStatus(t *testing.T) {
+	today := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	eur := "EUR"
+	thresholds := []int{80, 100}
+
+	tests := []struct {
+		name           string
+		project        Project
+		actual, burned int
+		rate           float64
+		rated          bool
+		wantPercent    float64
+		wantRemaining  int
+		wantExhaustion string
+		wantExhausted  bool
+		wantThreshold  int
+		wantEffective  float64
+	}{
+		{
+			name:    "estimate",
+			project: Project{Name: "Website", EstimatedHours: intPtr(10)},
+			actual:  6 * 3600, burned: 7 * 3600,
+			wantPercent: 60, wantRemaining: 4 * 3600, wantExhaustion: "2024-03-23",
+		},
+		{
+			name:    "past a threshold",
+			project: Project{Name: "Website", EstimatedHours: intPtr(10)},
+			actual:  9 * 3600, burned: 14 * 3600,
+			wantPercent: 90, wantRemaining: 3600, wantExhaustion: "2024-03-16", wantThreshold: 80,
+		},
+		{
+			name:        "over the estimate",
+			project:     Project{Name: "Website", EstimatedHours: intPtr(10)},
+			actual:      11 * 3600,
+			wantPercent: 110, wantRemaining: -3600, wantExhausted: true, wantThreshold: 100,
+		},
+		{
+			name:    "fixed fee at a rate",
+			project: Project{Name: "Launch", FixedFee: floatPtr(1000), Currency: &eur},
+			actual:  5 * 3600, burned: 14 * 3600, rate: 100, rated: true,
+			wantPercent: 50, wantRemaining: 5 * 3600, wantExhaustion: "2024-03-20", wantEffective: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := buildProjectStatus(tt.project, tt.actual, tt.burned, 14, tt.rate, tt.rated, thresholds, today)
+			if status.PercentUsed == nil || *status.PercentUsed != tt.wantPercent {
+				t.Fatalf("unexpected percent: %+v", status)
+			}
+			if *status.RemainingSeconds != tt.wantRemaining || status.ExhaustionDate != tt.wantExhaustion ||
+				status.Exhausted != tt.wantExhausted || status.Threshold != tt.wantThreshold {
+				t.Errorf("unexpected status: %+v", status)
+			}
+			if tt.wantEffective != 0 && (status.EffectiveRate == nil || *status.EffectiveRate != tt.wantEffective) {
+				t.Errorf("unexpected effective rate: %+v", status)
+			}
+		})
+	}
+
+	if status := buildProjectStatus(Project{Name: "Open"}, 3600, 0, 14, 0, false, thresholds, today); status.PercentUsed != nil ||
+		status.BudgetSeconds != 0 {
+		t.Errorf("expected no budget, got %+v", status)
+	}
+}
+
+func TestHandleProjectStatus(t *testing.T) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	entry := func(id, project, daysAgo, hours int) TimeEntry {
+		start := today.AddDate(0, 0, -daysAgo).Add(9 * time.Hour)
+		stop := start.Add(time.Duration(hours) * time.Hour)
+		return TimeEntry{
+			BaseEntity: BaseEntity{ID: id, WorkspaceID: 456},
+			ProjectID:  intPtr(project),
+			Start:      start,
+			Stop:       &stop,
+			Duration:   hours * 3600,
+		}
+	}
+
+	website := Project{
+		BaseEntity: BaseEntity{ID: 111, WorkspaceID: 456}, Name: "Website", Active: true, ClientID: intPtr(7),
+		EstimatedHours: intPtr(100), ActualSeconds: intPtr(90 * 3600),
+	}
+	launch := Project{
+		BaseEntity: BaseEntity{ID: 222, WorkspaceID: 456}, Name: "Launch", Active: true,
+		FixedFee: floatPtr(1000), Rate: floatPtr(100), StartDate: today.AddDate(0, 0, -100).Format("2006-01-02"),
+	}
+	idle := Project{BaseEntity: BaseEntity{ID: 333, WorkspaceID: 456}, Name: "Idle", Active: true}
+
+	entries := []TimeEntry{
+		entry(1, 111, 1, 7),
+		entry(2, 111, 20, 8),
+		entry(3, 222, 2, 4),
+		entry(4, 222, 0, 1),
+		entry(5, 222, 90, 3),
+	}
+	var pages int
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/workspaces/456":
+			writeJSON(w, http.StatusOK, Workspace{ID: 456, DefaultCurrency: "EUR"})
+		case "/api/v9/workspaces/456/projects":
+			writeJSON(w, http.StatusOK, []Project{website, launch, idle})
+		case "/api/v9/workspaces/456/clients":
+			writeJSON(w, http.StatusOK, []Client{{BaseEntity: BaseEntity{ID: 7}, Name: "Acme"}})
+		case "/api/v9/me":
+			writeJSON(w, http.StatusOK, testUser)
+		case "/api/v9/workspaces/456/workspace_users":
+			writeError(w, http.StatusForbidden, "admin only")
+		case "/api/v9/me/time_entries":
+			start, _ := time.Parse(time.RFC3339, r.URL.Query().Get("start_date"))
+			end, _ := time.Parse(time.RFC3339, r.URL.Query().Get("end_date"))
+			if end.Sub(start) > searchPageDays*24*time.Hour {
+				t.Errorf("expected pages of at most %d days, got %s to %s", searchPageDays, start, end)
+			}
+			pages++
+			var page []TimeEntry
+			for _, e := range entries {
+				if !e.Start.Before(start) && e.Start.Before(end) {
+					page = append(page, e)
+				}
+			}
+			writeJSON(w, http.StatusOK, page)
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	})
+	client.location = time.UTC
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"workspace_id": float64(456), "burn_days": float64(7)}
+	result, err := handleProjectStatus([]int{80, 100})(context.Background(), client, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+
+	for _, want := range []string{
+		"Project status, burn rate of your own entries over",
+		"- Website (Acme) [reached 80%]: 90h 00m of 100h 00m estimated (90.0%), 10h 00m left; you burn 1h 00m/day, runs out on " +
+			today.AddDate(0, 0, 10).Format("2006-01-02") + " at your rate",
+		"- Launch [reached 80%]: 8h 00m of 10h 00m the fee pays for (80.0%) (own entries since " + today.AddDate(0, 0, -100).Format("2006-01-02") +
+			"), 2h 00m left; you burn 0h 34m/day",
+		"  Fixed fee 1000.00 EUR: 800.00 EUR at 100.00 EUR/h so far, effective rate 125.00 EUR/h",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Idle") || strings.Index(text, "Website") > strings.Index(text, "Launch") {
+		t.Errorf("expected projects with a budget, most used first:\n%s", text)
+	}
+	if pages < 4 {
+		t.Errorf("expected the entries since the project's start to be fetched in pages, got %d requests", pages)
+	}
+
+	req.GetArguments()["project"] = "Nope"
+	if result, err := handleProjectStatus(nil)(context.Background(), client, req); err != nil || !result.IsError {
+		t.Errorf("expected an error result for an unknown project, got %v", err)
+	}
+}
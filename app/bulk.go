@@ -0,0 +1,370 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxBulkEntries bounds how many time entries one bulk update may change
+const maxBulkEntries = 500
+
+// Outcomes of a bulk update, per entry
+const (
+	BulkMatched = "matched"
+	BulkUpdated = "updated"
+	BulkFailed  = "failed"
+)
+
+// BulkUpdateEntry is the outcome of a bulk update for one entry
+type BulkUpdateEntry struct {
+	ID          int       `json:"id"`
+	Description string    `json:"description"`
+	Start       time.Time `json:"start"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// BulkUpdateResult reports a bulk update, or its preview
+type BulkUpdateResult struct {
+	Preview bool              `json:"preview"`
+	Changes []PatchOperation  `json:"changes"`
+	Entries []BulkUpdateEntry `json:"entries"`
+}
+
+// entryFilter selects time entries; empty fields match everything
+type entryFilter struct {
+	// description is matched as a case-insensitive substring
+	description string
+	projectID   *int
+	// tag is matched ignoring case
+	tag string
+}
+
+func (f entryFilter) matches(entry TimeEntry) bool {
+	if f.description != "" && !strings.Contains(strings.ToLower(entry.Description), strings.ToLower(f.description)) {
+		return false
+	}
+	if f.projectID != nil && !sameID(entry.ProjectID, f.projectID) {
+		return false
+	}
+	if f.tag != "" {
+		for _, tag := range entry.Tags {
+			if strings.EqualFold(tag, f.tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// bulkChanges turns the change arguments into patch operations, looking up
+// project names and reusing the spelling of existing tags
+func bulkChanges(args map[string]interface{}, lookup *importLookup) ([]PatchOperation, error) {
+	var ops []PatchOperation
+
+	if description, ok := args["description"].(string); ok {
+		ops = append(ops, PatchOperation{Op: "replace", Path: "/description", Value: description})
+	}
+
+	projectID := getOptionalNumber(args, "project_id")
+	if name := getOptionalString(args, "project"); name != "" {
+		id, ok := lookup.projectIDs[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown project %q", name)
+		}
+		projectID = &id
+	}
+	switch {
+	case projectID != nil && getOptionalBool(args, "clear_project"):
+		return nil, errors.New("give a project or clear_project, not both")
+	case projectID != nil:
+		ops = append(ops, PatchOperation{Op: "replace", Path: "/project_id", Value: *projectID})
+	case getOptionalBool(args, "clear_project"):
+		ops = append(ops, PatchOperation{Op: "replace", Path: "/project_id", Value: nil})
+	}
+
+	if billable, ok := args["billable"].(bool); ok {
+		ops = append(ops, PatchOperation{Op: "replace", Path: "/billable", Value: billable})
+	}
+
+	if args["set_tags"] != nil && (args["add_tags"] != nil || args["remove_tags"] != nil) {
+		return nil, errors.New("give set_tags, or add_tags and remove_tags, not both")
+	}
+	for _, tagOp := range []struct{ key, op string }{
+		{"set_tags", "replace"}, {"add_tags", "add"}, {"remove_tags", "remove"},
+	} {
+		if args[tagOp.key] == nil {
+			continue
+		}
+		tags, err := getOptionalStringList(args, tagOp.key)
+		if err != nil {
+			return nil, err
+		}
+		for i, tag := range tags {
+			if name, ok := lookup.tagNames[strings.ToLower(tag)]; ok {
+				tags[i] = name
+			}
+		}
+		ops = append(ops, PatchOperation{Op: tagOp.op, Path: "/tags", Value: tags})
+	}
+
+	if len(ops) == 0 {
+		return nil, errors.New("no changes given")
+	}
+	return ops, nil
+}
+
+// describePatch describes an operation for people, naming projects
+func describePatch(op PatchOperation, projectNames map[int]string) string {
+	field := strings.TrimPrefix(op.Path, "/")
+	switch {
+	case field == "project_id" && op.Value == nil:
+		return "clear project"
+	case field == "project_id":
+		id, _ := op.Value.(int)
+		return fmt.Sprintf("set project to %s (ID: %d)", projectNames[id], id)
+	case field == "tags":
+		tags, _ := op.Value.([]string)
+		verb := map[string]string{"replace": "set tags to", "add": "add tags", "remove": "remove tags"}[op.Op]
+		if len(tags) == 0 {
+			return verb + " (none)"
+		}
+		return verb + " " + strings.Join(tags, ", ")
+	case field == "description":
+		return fmt.Sprintf("set description to %q", op.Value)
+	default:
+		return fmt.Sprintf("set %s to %v", field, op.Value)
+	}
+}
+
+// formatBulkUpdate lists the changes and the outcome for each entry
+func formatBulkUpdate(result BulkUpdateResult, projectNames map[int]string, loc *time.Location) string {
+	var text strings.Builder
+	if result.Preview {
+		matched := 0
+		for _, entry := range result.Entries {
+			if entry.Status == BulkMatched {
+				matched++
+			}
+		}
+		text.WriteString(fmt.Sprintf("Preview of changes to %d time entries (nothing has been changed; call again with confirm=true to apply):\n",
+			matched))
+	} else {
+		text.WriteString("Updated time entries:\n")
+	}
+
+	changes := make([]string, len(result.Changes))
+	for i, op := range result.Changes {
+		changes[i] = describePatch(op, projectNames)
+	}
+	text.WriteString("Changes: " + strings.Join(changes, "; ") + "\n")
+
+	counts := make(map[string]int)
+	for _, entry := range result.Entries {
+		counts[entry.Status]++
+		text.WriteString("- ")
+		if !entry.Start.IsZero() {
+			text.WriteString(fmt.Sprintf("%s %s ", entry.Start.In(loc).Format("2006-01-02 15:04"), entry.Description))
+		}
+		text.WriteString(fmt.Sprintf("(ID: %d) - %s", entry.ID, entry.Status))
+		if entry.Error != "" {
+			text.WriteString(": " + entry.Error)
+		}
+		text.WriteString("\n")
+	}
+
+	var summary []string
+	for _, status := range []string{BulkMatched, BulkUpdated, BulkFailed} {
+		if counts[status] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	if len(summary) == 0 {
+		summary = append(summary, "no entries")
+	}
+	text.WriteString("Summary: " + strings.Join(summary, ", ") + "\n")
+	return text.String()
+}
+
+// bulkTools returns the tool that updates many time entries at once
+func bulkTools(client *TogglClient) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"bulk_update_time_entries",
+				mcp.WithDescription("Change the description, project, billable flag or tags of many time entries at once, selected by entry_ids or by a date range with optional description, project and tag filters. Without confirm=true only a preview is returned; afterwards each entry is reported as updated or failed."),
+				mcp.WithArray("entry_ids", mcp.Description("Entries to update; give these or start_date and end_date"), mcp.Items(map[string]interface{}{"type": "number"})),
+				mcp.WithString("start_date", mcp.Description("First day, YYYY-MM-DD")),
+				mcp.WithString("end_date", mcp.Description("Day after the last day, YYYY-MM-DD (exclusive)")),
+				mcp.WithString("match_description", mcp.Description("Only entries whose description contains this, ignoring case")),
+				mcp.WithString("match_project", mcp.Description("Only entries on this project, by name")),
+				mcp.WithString("match_tag", mcp.Description("Only entries with this tag")),
+				mcp.WithString("description", mcp.Description("New description")),
+				mcp.WithString("project", mcp.Description("New project, by name")),
+				mcp.WithNumber("project_id", mcp.Description("New project, by ID")),
+				mcp.WithBoolean("clear_project", mcp.Description("Remove the project")),
+				mcp.WithBoolean("billable", mcp.Description("New billable flag")),
+				mcp.WithArray("set_tags", mcp.Description("Replace the tags"), mcp.Items(map[string]interface{}{"type": "string"})),
+				mcp.WithArray("add_tags", mcp.Description("Tags to add"), mcp.Items(map[string]interface{}{"type": "string"})),
+				mcp.WithArray("remove_tags", mcp.Description("Tags to remove"), mcp.Items(map[string]interface{}{"type": "string"})),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
+				mcp.WithBoolean("confirm", mcp.Description("Apply the changes; otherwise only preview them")),
+			),
+			handler: wrapHandler(client, handleBulkUpdateTimeEntries),
+		},
+	}
+}
+
+func handleBulkUpdateTimeEntries(
+	ctx context.Context,
+	client *TogglClient,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	workspaceID, err := getWorkspaceID(args, client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace_id: %w", err)
+	}
+
+	lookup, err := client.newImportLookup(ctx, workspaceID)
+	if err != nil {
+		return apiErrorResult("update time entries", err)
+	}
+	ops, err := bulkChanges(args, lookup)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid changes: %s", err)), nil
+	}
+
+	var result BulkUpdateResult
+	var entries []TimeEntry
+	if args["entry_ids"] != nil {
+		ids, err := getRequiredNumberList(args, "entry_ids")
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			entry, err := client.GetTimeEntry(ctx, id)
+			switch {
+			case err == nil && entry.WorkspaceID != workspaceID:
+				err = fmt.Errorf("in workspace %d, not %d", entry.WorkspaceID, workspaceID)
+			case err != nil && !errors.Is(err, ErrAPIRequest):
+				return nil, fmt.Errorf("getting time entry %d: %w", id, err)
+			}
+			if err != nil {
+				result.Entries = append(result.Entries, BulkUpdateEntry{ID: id, Status: BulkFailed, Error: err.Error()})
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	} else {
+		if entries, err = bulkMatches(ctx, client, workspaceID, args, lookup); err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				return apiErrorResult("update time entries", err)
+			}
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	if len(entries) > maxBulkEntries {
+		return mcp.NewToolResultError(fmt.Sprintf(
+			"%d entries match; narrow the selection to at most %d", len(entries), maxBulkEntries,
+		)), nil
+	}
+
+	result.Preview = !getOptionalBool(args, "confirm")
+	result.Changes = ops
+	status := make(map[int]*BulkUpdateEntry, len(entries))
+	for _, entry := range entries {
+		result.Entries = append(result.Entries, BulkUpdateEntry{
+			ID: entry.ID, Description: entry.Description, Start: entry.Start, Status: BulkMatched,
+		})
+	}
+	for i := range result.Entries {
+		status[result.Entries[i].ID] = &result.Entries[i]
+	}
+
+	if !result.Preview && len(entries) > 0 {
+		patched, err := client.PatchTimeEntries(ctx, workspaceID, entries, ops)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range patched.Success {
+			if entry, ok := status[id]; ok {
+				entry.Status = BulkUpdated
+			}
+		}
+		for _, failure := range patched.Failure {
+			if entry, ok := status[failure.ID]; ok {
+				entry.Status, entry.Error = BulkFailed, failure.Message
+			}
+		}
+		// Entries the API reported neither way were not changed
+		for _, entry := range status {
+			if entry.Status == BulkMatched {
+				entry.Status, entry.Error = BulkFailed, "not reported by the API"
+			}
+		}
+	}
+
+	if wantsJSON(ctx) {
+		return jsonResult(result)
+	}
+	loc := client.timeZone()
+	return mcp.NewToolResultText(formatBulkUpdate(result, lookup.projectNames, loc)), nil
+}
+
+// bulkMatches fetches the workspace's entries in the requested date range
+// that pass the filters, oldest first
+func bulkMatches(
+	ctx context.Context,
+	client *TogglClient,
+	workspaceID int,
+	args map[string]interface{},
+	lookup *importLookup,
+) ([]TimeEntry, error) {
+	if getOptionalString(args, "start_date") == "" || getOptionalString(args, "end_date") == "" {
+		return nil, errors.New("give entry_ids, or start_date and end_date")
+	}
+	start, err := getRequiredDate(args, "start_date")
+	if err != nil {
+		return nil, err
+	}
+	end, err := getRequiredDate(args, "end_date")
+	if err != nil {
+		return nil, err
+	}
+
+	filter := entryFilter{
+		description: getOptionalString(args, "match_description"),
+		tag:         getOptionalString(args, "match_tag"),
+	}
+	if name := getOptionalString(args, "match_project"); name != "" {
+		id, ok := lookup.projectIDs[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown project %q", name)
+		}
+		filter.projectID = &id
+	}
+
+	entries, err := client.GetTimeEntries(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var matched []TimeEntry
+	for _, entry := range entries {
+		if entry.WorkspaceID == workspaceID && filter.matches(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Start.Before(matched[j].Start)
+	})
+	return matched, nil
+}
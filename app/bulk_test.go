@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestEntryFilter(t *testing.T) {
+	entry := lintEntry(1, "Code review", "09:00", "10:00", true)
+	entry.Tags = []string{"Review"}
+
+	tests := []struct {
+		name   string
+		filter entryFilter
+		want   bool
+	}{
+		{name: "empty", filter: entryFilter{}, want: true},
+		{name: "description ignoring case", filter: entryFilter{description: "REVIEW"}, want: true},
+		{name: "other description", filter: entryFilter{description: "standup"}, want: false},
+		{name: "project", filter: entryFilter{projectID: intPtr(111)}, want: true},
+		{name: "other project", filter: entryFilter{projectID: intPtr(222)}, want: false},
+		{name: "tag ignoring case", filter: entryFilter{tag: "review"}, want: true},
+		{name: "other tag", filter: entryFilter{tag: "meeting"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(entry); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBulkChanges(t *testing.T) {
+	lookup := &importLookup{
+		projectIDs: map[string]int{"test project": 111},
+		tagNames:   map[string]string{"meeting": "Meeting"},
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		want    string
+		wantErr string
+	}{
+		{
+			name: "fields",
+			args: map[string]interface{}{"description": "Review", "project": "Test project", "billable": false},
+			want: `[{"op":"replace","path":"/description","value":"Review"},{"op":"replace","path":"/project_id","value":111},{"op":"replace","path":"/billable","value":false}]`,
+		},
+		{
+			name: "clear project",
+			args: map[string]interface{}{"clear_project": true},
+			want: `[{"op":"replace","path":"/project_id","value":null}]`,
+		},
+		{
+			name: "tags",
+			args: map[string]interface{}{"add_tags": []interface{}{"meeting"}, "remove_tags": []interface{}{"old"}},
+			want: `[{"op":"add","path":"/tags","value":["Meeting"]},{"op":"remove","path":"/tags","value":["old"]}]`,
+		},
+		{name: "unknown project", args: map[string]interface{}{"project": "Nope"}, wantErr: "unknown project"},
+		{name: "project and clear", args: map[string]interface{}{"project_id": float64(5), "clear_project": true}, wantErr: "not both"},
+		{name: "set and add tags", args: map[string]interface{}{"set_tags": []interface{}{}, "add_tags": []interface{}{"a"}}, wantErr: "not both"},
+		{name: "nothing", args: map[string]interface{}{}, wantErr: "no changes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := bulkChanges(tt.args, lookup)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, _ := json.Marshal(ops)
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleBulkUpdateTimeEntries(t *testing.T) {
+	entries := []TimeEntry{
+		lintEntry(1, "Standup", "09:00", "09:15", false),
+		lintEntry(2, "Code review", "10:00", "11:00", true),
+		lintEntry(3, "Standup", "13:00", "13:15", true),
+	}
+
+	var mu sync.Mutex
+	var patches []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/v9")
+		switch {
+		case path == "/workspaces/456/projects":
+			writeJSON(w, http.StatusOK, []Project{testProject})
+		case path == "/workspaces/456/tags":
+			writeJSON(w, http.StatusOK, []Tag{})
+		case path == "/me/time_entries":
+			if r.URL.Query().Get("start_date") != "2024-03-15T00:00:00Z" {
+				t.Errorf("unexpected start_date %q", r.URL.Query().Get("start_date"))
+			}
+			writeJSON(w, http.StatusOK, entries)
+		case path == "/me/time_entries/2":
+			writeJSON(w, http.StatusOK, entries[1])
+		case r.Method == http.MethodPatch && strings.HasPrefix(path, "/workspaces/456/time_entries/"):
+			var ops []PatchOperation
+			json.NewDecoder(r.Body).Decode(&ops)
+			body, _ := json.Marshal(ops)
+			mu.Lock()
+			patches = append(patches, path+" "+string(body))
+			mu.Unlock()
+			writeJSON(w, http.StatusOK, PatchResult{
+				Success: []int{1},
+				Failure: []PatchFailure{{ID: 3, Message: "entry is locked"}},
+			})
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	}
+	_, client := testServer(t, handler)
+	client.location = time.UTC
+
+	call := func(args map[string]interface{}) string {
+		t.Helper()
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		args["workspace_id"] = float64(456)
+		result, err := handleBulkUpdateTimeEntries(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	args := map[string]interface{}{
+		"start_date":        "2024-03-15",
+		"end_date":          "2024-03-16",
+		"match_description": "standup",
+		"project":           "Test Project",
+	}
+	text := call(args)
+	if !strings.Contains(text, "Preview of changes to 2 time entries") ||
+		!strings.Contains(text, "set project to Test Project (ID: 111)") ||
+		!strings.Contains(text, "Summary: 2 matched") {
+		t.Errorf("unexpected preview: %s", text)
+	}
+	if len(patches) != 0 {
+		t.Fatalf("expected no updates in a preview, got %v", patches)
+	}
+
+	args["confirm"] = true
+	text = call(args)
+	if !strings.Contains(text, "(ID: 1) - updated") ||
+		!strings.Contains(text, "(ID: 3) - failed: entry is locked") ||
+		!strings.Contains(text, "Summary: 1 updated, 1 failed") {
+		t.Errorf("unexpected result: %s", text)
+	}
+	want := `/workspaces/456/time_entries/1,3 [{"op":"replace","path":"/project_id","value":111}]`
+	if len(patches) != 1 || patches[0] != want {
+		t.Errorf("unexpected requests %v, want %s", patches, want)
+	}
+
+	text = call(map[string]interface{}{"entry_ids": []interface{}{float64(2), float64(9)}, "billable": true})
+	if !strings.Contains(text, "Preview of changes to 1 time entries") ||
+		!strings.Contains(text, "Code review (ID: 2) - matched") ||
+		!strings.Contains(text, "(ID: 9) - failed") {
+		t.Errorf("unexpected result for entry_ids: %s", text)
+	}
+
+	if text := call(map[string]interface{}{"billable": true}); !strings.Contains(text, "give entry_ids") {
+		t.Errorf("unexpected result without a selection: %s", text)
+	}
+}
@@ -0,0 +1,335 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// calendarRule assigns a project, a task and tags to events whose summary,
+// description or location matches a pattern
+type calendarRule struct {
+	match   *regexp.Regexp
+	project string
+	task    string
+	tags    []string
+}
+
+// parseCalendarRules reads the rules argument: a list of objects with a
+// case-insensitive regular expression in match, and a project, task and/or
+// tags. A task is looked up in the rule's project, or else the default one.
+func parseCalendarRules(raw interface{}) ([]calendarRule, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, errors.New("rules must be an array of objects")
+	}
+
+	rules := make([]calendarRule, 0, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("rule %d must be an object", i+1)
+		}
+
+		pattern, err := getRequiredString(obj, "match")
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		match, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: invalid match: %w", i+1, err)
+		}
+
+		tags, err := getOptionalStringList(obj, "tags")
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+
+		rule := calendarRule{match: match, project: getOptionalString(obj, "project"), tags: tags}
+		switch v := obj["task"].(type) {
+		case nil:
+		case string:
+			rule.task = strings.TrimSpace(v)
+		case float64:
+			rule.task = strconv.Itoa(int(v))
+		default:
+			return nil, fmt.Errorf("rule %d: task must be a name or an ID", i+1)
+		}
+		if rule.project == "" && rule.task == "" && len(rule.tags) == 0 {
+			return nil, fmt.Errorf("rule %d needs a project, task or tags", i+1)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// calendarFilter selects which events become time entries
+type calendarFilter struct {
+	attendee      string
+	keyword       string
+	includeAllDay bool
+}
+
+// matches reports whether event passes the filter. Cancelled events never
+// do, and with an attendee set, neither do events the attendee declined.
+func (f calendarFilter) matches(event calendarEvent) bool {
+	if event.Status == "CANCELLED" || (event.AllDay && !f.includeAllDay) {
+		return false
+	}
+
+	if f.keyword != "" {
+		keyword := strings.ToLower(f.keyword)
+		if !strings.Contains(strings.ToLower(event.Summary), keyword) &&
+			!strings.Contains(strings.ToLower(event.Description), keyword) &&
+			!strings.Contains(strings.ToLower(event.Location), keyword) {
+			return false
+		}
+	}
+
+	if f.attendee != "" {
+		attendee := strings.ToLower(f.attendee)
+		for _, a := range event.Attendees {
+			if (strings.Contains(strings.ToLower(a.Email), attendee) ||
+				strings.Contains(strings.ToLower(a.Name), attendee)) && a.PartStat != "DECLINED" {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// calendarEntry turns an event into a time entry request, the name of its
+// project, that of the first matching rule, else defaultProject, and the
+// rule's task
+func calendarEntry(event calendarEvent, rules []calendarRule, defaultProject string) (TimeEntryRequest, string, string, error) {
+	entry := TimeEntryRequest{
+		Description: event.Summary,
+		Start:       event.Start,
+		Duration:    int(event.End.Sub(event.Start).Seconds()),
+		CreatedWith: "toggl-mcp",
+	}
+	if entry.Duration <= 0 {
+		return entry, "", "", errors.New("event has no duration")
+	}
+
+	project, task := defaultProject, ""
+	text := strings.Join([]string{event.Summary, event.Description, event.Location}, "\n")
+	for _, rule := range rules {
+		if rule.match.MatchString(text) {
+			if rule.project != "" {
+				project = rule.project
+			}
+			task = rule.task
+			entry.Tags = append([]string(nil), rule.tags...)
+			break
+		}
+	}
+
+	return entry, project, task, nil
+}
+
+// calendarTools returns the calendar import and export tools, which may read
+// and write files in allowedDirs
+func calendarTools(client *TogglClient, allowedDirs []string) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"import_calendar_events",
+				mcp.WithDescription("Turn the events of an iCalendar (.ics) file into time entries. Recurring events are expanded, and those with an unsupported RRULE are reported as invalid; cancelled, all-day and declined events are skipped, as are events already logged. Without confirm=true only a preview is returned."),
+				mcp.WithString("ics", mcp.Description("iCalendar text; give either ics or path")),
+				mcp.WithString("path", mcp.Description("iCalendar file to read; must be inside an allowed directory")),
+				mcp.WithString("start_date", mcp.Required(), mcp.Description("First day, YYYY-MM-DD")),
+				mcp.WithString("end_date", mcp.Required(), mcp.Description("Day after the last day, YYYY-MM-DD (exclusive)")),
+				mcp.WithString("attendee", mcp.Description("Only events with an attendee or organizer whose email or name contains this, and who has not declined")),
+				mcp.WithString("keyword", mcp.Description("Only events whose summary, description or location contains this")),
+				mcp.WithString("project", mcp.Description("Project name for events no rule assigns one to")),
+				mcp.WithArray("rules",
+					mcp.Description("Assign projects, tasks and tags by pattern; the first rule whose match (a case-insensitive regular expression) finds the summary, description or location wins. A task, by name or ID, is looked up in the rule's project, or else in project"),
+					mcp.Items(map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"match":   map[string]interface{}{"type": "string"},
+							"project": map[string]interface{}{"type": "string"},
+							"task":    map[string]interface{}{"type": []string{"string", "number"}},
+							"tags":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+						},
+						"required": []string{"match"},
+					}),
+				),
+				mcp.WithBoolean("include_all_day", mcp.Description("Also import all-day events")),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
+				mcp.WithBoolean("round", mcp.Description(roundDescription)),
+				mcp.WithBoolean("confirm", mcp.Description("Create the entries; otherwise only preview them")),
+			),
+			handler: wrapHandler(client, handleImportCalendarEvents(allowedDirs)),
+		},
+		{
+			tool: mcp.NewTool(
+				"export_ics",
+				mcp.WithDescription("Export time entries in a date range as an iCalendar (.ics) file with one event per entry, titled with its description and categorized by project and tags. Event UIDs derive from entry IDs, so re-importing an updated export replaces events. Running entries end at the time of export. Returns the calendar as an embedded resource, or writes it to path if that lies in an allowed directory."),
+				mcp.WithString("start_date", mcp.Required(), mcp.Description("First day, YYYY-MM-DD")),
+				mcp.WithString("end_date", mcp.Required(), mcp.Description("Day after the last day, YYYY-MM-DD (exclusive)")),
+				mcp.WithString("path", mcp.Description("File to write the calendar to instead of returning it; must be inside an allowed directory")),
+			),
+			handler: wrapHandler(client, handleExportICS(allowedDirs)),
+		},
+	}
+}
+
+func handleImportCalendarEvents(allowedDirs []string) func(
+	context.Context,
+	*TogglClient,
+	mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := req.GetArguments()
+
+		workspaceID, err := getWorkspaceID(args, client)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace_id: %w", err)
+		}
+		startDate, err := getRequiredDate(args, "start_date")
+		if err != nil {
+			return nil, err
+		}
+		endDate, err := getRequiredDate(args, "end_date")
+		if err != nil {
+			return nil, err
+		}
+		rules, err := parseCalendarRules(args["rules"])
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid rules: %s", err)), nil
+		}
+		rounding, err := requestedRounding(ctx, args, "round")
+		if err != nil {
+			return nil, err
+		}
+
+		text := getOptionalString(args, "ics")
+		path := getOptionalString(args, "path")
+		switch {
+		case text != "" && path != "":
+			return mcp.NewToolResultError("Give either ics or path, not both"), nil
+		case path != "":
+			if text, err = readImportFile(allowedDirs, path); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Cannot read calendar file: %s", err)), nil
+			}
+		case text == "":
+			return mcp.NewToolResultError("Give the calendar as ics text or as a path"), nil
+		}
+
+		loc := client.timeZone()
+		from := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+		to := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc)
+
+		events, err := parseICS(strings.NewReader(text), loc)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid calendar: %s", err)), nil
+		}
+		events = expandEvents(events, from, to)
+
+		filter := calendarFilter{
+			attendee:      getOptionalString(args, "attendee"),
+			keyword:       getOptionalString(args, "keyword"),
+			includeAllDay: getOptionalBool(args, "include_all_day"),
+		}
+		var selected []calendarEvent
+		for _, event := range events {
+			if filter.matches(event) {
+				selected = append(selected, event)
+			}
+		}
+		if len(selected) > maxImportRows {
+			return mcp.NewToolResultError(fmt.Sprintf(
+				"%d events match; narrow the date range or filters to at most %d", len(selected), maxImportRows,
+			)), nil
+		}
+
+		lookup, err := client.newImportLookup(ctx, workspaceID)
+		if err != nil {
+			return apiErrorResult("prepare calendar import", err)
+		}
+
+		rows := make([]ImportRow, len(selected))
+		defaultProject := getOptionalString(args, "project")
+		for i, event := range selected {
+			rows[i].Line = event.Line
+			if event.rruleErr != nil {
+				rows[i].Status, rows[i].Error = ImportInvalid, event.rruleErr.Error()
+				continue
+			}
+			entry, project, task, err := calendarEntry(event, rules, defaultProject)
+			if err != nil {
+				rows[i].Status, rows[i].Error = ImportInvalid, err.Error()
+				continue
+			}
+			lookup.resolve(&rows[i], entry, project)
+			lookup.resolveTask(ctx, &rows[i], task)
+		}
+
+		roundImportRows(rows, rounding)
+		if err := client.markDuplicates(ctx, rows, loc); err != nil {
+			return apiErrorResult("prepare calendar import", err)
+		}
+
+		result := ImportResult{Preview: !getOptionalBool(args, "confirm"), Rows: rows}
+		if !result.Preview {
+			client.createImportRows(ctx, workspaceID, result.Rows)
+		}
+
+		if wantsJSON(ctx) {
+			return jsonResult(result)
+		}
+		return mcp.NewToolResultText(formatImportResult(result, loc)), nil
+	}
+}
+
+func handleExportICS(allowedDirs []string) func(
+	context.Context,
+	*TogglClient,
+	mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start, err := getRequiredDate(req.GetArguments(), "start_date")
+		if err != nil {
+			return nil, err
+		}
+		end, err := getRequiredDate(req.GetArguments(), "end_date")
+		if err != nil {
+			return nil, err
+		}
+
+		var path string
+		if p := getOptionalString(req.GetArguments(), "path"); p != "" {
+			if path, err = allowedPath(allowedDirs, p); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Cannot write export: %s", err)), nil
+			}
+		}
+
+		var buf bytes.Buffer
+		count, err := client.exportICS(ctx, &buf, start, end, time.Now())
+		if err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to export time entries: %s", apiErr.Error())), nil
+			}
+			return nil, fmt.Errorf("exporting time entries: %w", err)
+		}
+
+		return deliverExport(buf.Bytes(), path, fmt.Sprintf("%d time entries", count), start, end,
+			"time-entries", "ics", "text/calendar"), nil
+	}
+}
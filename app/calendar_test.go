@@ -0,0 +1,216 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const calendarICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Standup
+DTSTART:20240315T090000Z
+DTEND:20240315T091500Z
+ATTENDEE;PARTSTAT=ACCEPTED:mailto:me@example.com
+END:VEVENT
+BEGIN:VEVENT
+UID:review
+SUMMARY:Review
+DESCRIPTION:Client ACME quarterly review
+DTSTART:20240315T100000Z
+DTEND:20240315T113000Z
+ATTENDEE;PARTSTAT=ACCEPTED:mailto:me@example.com
+END:VEVENT
+BEGIN:VEVENT
+UID:declined
+SUMMARY:Skipped meeting
+DTSTART:20240315T120000Z
+DTEND:20240315T130000Z
+ATTENDEE;PARTSTAT=DECLINED:mailto:me@example.com
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled
+SUMMARY:Cancelled meeting
+STATUS:CANCELLED
+DTSTART:20240315T140000Z
+DTEND:20240315T150000Z
+ATTENDEE;PARTSTAT=ACCEPTED:mailto:me@example.com
+END:VEVENT
+BEGIN:VEVENT
+UID:other-day
+SUMMARY:Next week
+DTSTART:20240322T090000Z
+DTEND:20240322T100000Z
+ATTENDEE;PARTSTAT=ACCEPTED:mailto:me@example.com
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestHandleImportCalendarEvents(t *testing.T) {
+	var created int32
+	_, client := testServer(t, importHandler(&created))
+	client.location = time.UTC
+
+	call := func(extra map[string]interface{}) *mcp.CallToolResult {
+		t.Helper()
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{
+			"ics":          calendarICS,
+			"start_date":   "2024-03-15",
+			"end_date":     "2024-03-16",
+			"attendee":     "me@example.com",
+			"workspace_id": float64(456),
+			"rules": []interface{}{
+				map[string]interface{}{"match": "acme", "project": "Test Project", "tags": []interface{}{"meeting"}},
+			},
+		}
+		for k, v := range extra {
+			req.GetArguments()[k] = v
+		}
+		result, err := handleImportCalendarEvents(nil)(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	preview := call(nil).Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"Preview of 2 rows",
+		"Line 2: 2024-03-15 09:00-09:15 Standup - duplicate of entry 555, skipped",
+		"Line 9: 2024-03-15 10:00-11:30 Review [Test Project] - ready",
+	} {
+		if !strings.Contains(preview, want) {
+			t.Errorf("expected preview to contain %q, got:\n%s", want, preview)
+		}
+	}
+	for _, skipped := range []string{"Skipped meeting", "Cancelled meeting", "Next week"} {
+		if strings.Contains(preview, skipped) {
+			t.Errorf("expected %q to be filtered out:\n%s", skipped, preview)
+		}
+	}
+	if created != 0 {
+		t.Fatalf("preview created %d entries", created)
+	}
+
+	result := call(map[string]interface{}{"confirm": true, "keyword": "review"}).Content[0].(mcp.TextContent).Text
+	if !strings.Contains(result, "Summary: 1 created") || created != 1 {
+		t.Errorf("expected the review to be created, got:\n%s", result)
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{
+		"ics": calendarICS, "start_date": "2024-03-15", "end_date": "2024-03-16", "keyword": "review", "workspace_id": float64(456),
+		"rules": []interface{}{map[string]interface{}{"match": "acme", "project": "Test Project", "task": "review"}},
+	}
+	tasked, err := handleImportCalendarEvents(nil)(context.WithValue(context.Background(), outputFormatKey{}, OutputJSON), client, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rows ImportResult
+	if err := json.Unmarshal([]byte(tasked.Content[0].(mcp.TextContent).Text), &rows); err != nil {
+		t.Fatalf("decoding result: %v", err)
+	}
+	if len(rows.Rows) != 1 || rows.Rows[0].Entry == nil || rows.Rows[0].Entry.TaskID == nil || *rows.Rows[0].Entry.TaskID != 12 {
+		t.Errorf("expected the rule's task to be resolved, got %+v", rows.Rows)
+	}
+
+	recurring := strings.Replace(calendarICS, "UID:other-day", "UID:other-day\nRRULE:FREQ=WEEKLY;BYSETPOS=1", 1)
+	recurring = strings.Replace(recurring, "DTSTART:20240322T090000Z\nDTEND:20240322T100000Z", "DTSTART:20240308T090000Z\nDTEND:20240308T100000Z", 1)
+	unsupported := call(map[string]interface{}{"ics": recurring}).Content[0].(mcp.TextContent).Text
+	if !strings.Contains(unsupported, `Line 32: invalid: RRULE: unsupported RRULE part "BYSETPOS=1"`) {
+		t.Errorf("expected the unsupported recurrence to be reported, got:\n%s", unsupported)
+	}
+
+	invalid := call(map[string]interface{}{"rules": []interface{}{map[string]interface{}{"match": "("}}})
+	if !invalid.IsError || !strings.Contains(invalid.Content[0].(mcp.TextContent).Text, "invalid match") {
+		t.Errorf("expected rule error, got %+v", invalid.Content)
+	}
+}
+
+func TestCalendarFilter(t *testing.T) {
+	event := calendarEvent{
+		Summary:   "Planning",
+		Location:  "Room 4",
+		Attendees: []calendarAttendee{{Email: "jane@example.com", Name: "Jane Doe", PartStat: "TENTATIVE"}},
+	}
+
+	tests := []struct {
+		name   string
+		filter calendarFilter
+		event  calendarEvent
+		want   bool
+	}{
+		{name: "no filter", event: event, want: true},
+		{name: "keyword in location", filter: calendarFilter{keyword: "room"}, event: event, want: true},
+		{name: "keyword missing", filter: calendarFilter{keyword: "retro"}, event: event, want: false},
+		{name: "attendee by name", filter: calendarFilter{attendee: "jane doe"}, event: event, want: true},
+		{name: "other attendee", filter: calendarFilter{attendee: "bob"}, event: event, want: false},
+		{name: "all day skipped", event: calendarEvent{AllDay: true}, want: false},
+		{name: "all day included", filter: calendarFilter{includeAllDay: true}, event: calendarEvent{AllDay: true}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(tt.event); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleExportICS(t *testing.T) {
+	dir := t.TempDir()
+	_, client := testServer(t, exportHandler)
+	client.location = time.UTC
+
+	call := func(allowedDirs []string, args map[string]interface{}) *mcp.CallToolResult {
+		t.Helper()
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{"start_date": "2024-03-15", "end_date": "2024-03-16"}
+		for k, v := range args {
+			req.GetArguments()[k] = v
+		}
+		result, err := handleExportICS(allowedDirs)(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	result := call(nil, nil)
+	if len(result.Content) != 2 {
+		t.Fatalf("expected text and resource content, got %+v", result.Content)
+	}
+	contents := result.Content[1].(mcp.EmbeddedResource).Resource.(mcp.TextResourceContents)
+	if contents.MIMEType != "text/calendar" || contents.URI != "toggl://exports/time-entries-2024-03-15-2024-03-16.ics" {
+		t.Errorf("unexpected resource: %+v", contents)
+	}
+	for _, want := range []string{
+		"DESCRIPTION:Project: Test Project\\nClient: Acme\\nBillable\r\n",
+		"SUMMARY:Standup\\, daily\r\n",
+	} {
+		if !strings.Contains(contents.Text, want) {
+			t.Errorf("expected calendar to contain %q, got:\n%s", want, contents.Text)
+		}
+	}
+	if strings.Index(contents.Text, "time-entry-1@") > strings.Index(contents.Text, "time-entry-2@") {
+		t.Errorf("expected entries oldest first:\n%s", contents.Text)
+	}
+
+	written := call([]string{dir}, map[string]interface{}{"path": filepath.Join(dir, "march.ics")})
+	if written.IsError || !strings.Contains(written.Content[0].(mcp.TextContent).Text, "Exported 2 time entries to") {
+		t.Errorf("unexpected result: %+v", written.Content)
+	}
+
+	denied := call(nil, map[string]interface{}{"path": filepath.Join(dir, "march.ics")})
+	if !denied.IsError {
+		t.Errorf("expected an error without allowed directories, got %+v", denied.Content)
+	}
+}
@@ -0,0 +1,482 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Exit codes returned by RunCommand
+const (
+	ExitOK             = 0
+	ExitFailure        = 1
+	ExitUsage          = 2
+	ExitNoRunningEntry = 3
+	ExitAuth           = 4
+	ExitAPI            = 5
+)
+
+// CommandEnv is what the CLI commands need from the binary running them
+type CommandEnv struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	// Client returns the client for the named profile, or the default
+	// client when profile is empty
+	Client func(profile string) (*TogglClient, error)
+	// Now defaults to time.Now
+	Now func() time.Time
+	// Rounding is applied by the --round and --rounded flags
+	Rounding Rounding
+}
+
+// cliCommand is a subcommand of the binary besides serve
+type cliCommand struct {
+	usage string
+	run   func(ctx context.Context, cmd *commandContext) error
+	flags func(fs *flag.FlagSet)
+}
+
+// commandContext carries a parsed command line to a command
+type commandContext struct {
+	env    CommandEnv
+	fs     *flag.FlagSet
+	client *TogglClient
+	json   bool
+}
+
+// usageError marks errors in how a command was invoked
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+
+var cliCommands = map[string]cliCommand{
+	"start": {
+		usage: "start [--project ID] [--workspace ID] <description>",
+		flags: func(fs *flag.FlagSet) {
+			fs.Int("project", 0, "project ID")
+			fs.Int("workspace", 0, "workspace ID (default: the configured or account default workspace)")
+		},
+		run: runStart,
+	},
+	"stop": {
+		usage: "stop [--round]",
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("round", false, "round the stopped entry's duration with the configured rounding")
+		},
+		run: runStop,
+	},
+	"current": {
+		usage: "current",
+		run:   runCurrent,
+	},
+	"entries": {
+		usage: "entries [--from YYYY-MM-DD] [--to YYYY-MM-DD] [--rounded]",
+		flags: func(fs *flag.FlagSet) {
+			dateRangeFlags(fs)
+			roundedFlag(fs)
+		},
+		run: runEntries,
+	},
+	"projects": {
+		usage: "projects [--workspace ID] [--active]",
+		flags: func(fs *flag.FlagSet) {
+			fs.Int("workspace", 0, "workspace ID (default: the configured or account default workspace)")
+			fs.Bool("active", false, "list active projects only")
+		},
+		run: runProjects,
+	},
+	"export": {
+		usage: "export [--from YYYY-MM-DD] [--to YYYY-MM-DD] [--format csv|jsonl] [--columns LIST] [--output FILE]",
+		flags: func(fs *flag.FlagSet) {
+			dateRangeFlags(fs)
+			fs.String("format", "", "csv or jsonl (default: csv, or jsonl with --json)")
+			fs.String("columns", "", "comma-separated columns (default: "+strings.Join(exportColumns, ",")+")")
+			fs.String("output", "", "file to write instead of standard output")
+		},
+		run: runExport,
+	},
+	"export-ics": {
+		usage: "export-ics [--from YYYY-MM-DD] [--to YYYY-MM-DD] [--output FILE]",
+		flags: func(fs *flag.FlagSet) {
+			dateRangeFlags(fs)
+			fs.String("output", "", "file to write instead of standard output")
+		},
+		run: runExportICS,
+	},
+	"report": {
+		usage: "report [--from YYYY-MM-DD] [--to YYYY-MM-DD] [--rounded]",
+		flags: func(fs *flag.FlagSet) {
+			dateRangeFlags(fs)
+			roundedFlag(fs)
+		},
+		run: runReport,
+	},
+}
+
+// IsCommand reports whether name is a CLI subcommand handled by RunCommand
+func IsCommand(name string) bool {
+	_, ok := cliCommands[name]
+	return ok
+}
+
+// RunCommand runs the CLI subcommand name with its arguments and returns the
+// process exit code
+func RunCommand(ctx context.Context, name string, args []string, env CommandEnv) int {
+	if env.Now == nil {
+		env.Now = time.Now
+	}
+
+	command, ok := cliCommands[name]
+	if !ok {
+		fmt.Fprintf(env.Stderr, "error: unknown command %q\n", name)
+		return ExitUsage
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.Stderr, "usage: toggl-mcp %s\n", command.usage)
+		fs.PrintDefaults()
+	}
+	jsonOutput := fs.Bool("json", false, "print JSON instead of text")
+	profile := fs.String("profile", "", "profile to use (default: the default profile)")
+	if command.flags != nil {
+		command.flags(fs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	client, err := env.Client(*profile)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "error:", err)
+		return ExitFailure
+	}
+
+	err = command.run(ctx, &commandContext{env: env, fs: fs, client: client, json: *jsonOutput})
+	if err != nil {
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintln(env.Stderr, "error:", err)
+			fs.Usage()
+			return ExitUsage
+		}
+		fmt.Fprintln(env.Stderr, "error:", err)
+		return exitCode(err)
+	}
+
+	return ExitOK
+}
+
+// exitCode maps a command's error to the process exit code
+func exitCode(err error) int {
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrNoRunningEntry):
+		return ExitNoRunningEntry
+	case errors.As(err, &apiErr):
+		if apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden {
+			return ExitAuth
+		}
+		return ExitAPI
+	default:
+		return ExitFailure
+	}
+}
+
+// output prints v as JSON, or text otherwise
+func (cmd *commandContext) output(v interface{}, text string) error {
+	if cmd.json {
+		enc := json.NewEncoder(cmd.env.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	_, err := fmt.Fprintln(cmd.env.Stdout, strings.TrimRight(text, "\n"))
+	return err
+}
+
+// intFlag returns the value of an int flag registered by the command
+func (cmd *commandContext) intFlag(name string) int {
+	return cmd.fs.Lookup(name).Value.(flag.Getter).Get().(int)
+}
+
+// boolFlag returns the value of a bool flag registered by the command
+func (cmd *commandContext) boolFlag(name string) bool {
+	return cmd.fs.Lookup(name).Value.(flag.Getter).Get().(bool)
+}
+
+// rounding returns the configured rounding when the named flag is set, and
+// no rounding otherwise
+func (cmd *commandContext) rounding(flagName string) (Rounding, error) {
+	if !cmd.boolFlag(flagName) {
+		return Rounding{}, nil
+	}
+	if !cmd.env.Rounding.Enabled() {
+		return Rounding{}, usageError{errNoRounding}
+	}
+	return cmd.env.Rounding, nil
+}
+
+// roundedFlag registers --rounded
+func roundedFlag(fs *flag.FlagSet) {
+	fs.Bool("rounded", false, "also show durations rounded with the configured rounding")
+}
+
+// workspaceID resolves the --workspace flag, falling back to the client's
+// default workspace and then to the account's
+func (cmd *commandContext) workspaceID(ctx context.Context) (int, error) {
+	if id := cmd.intFlag("workspace"); id != 0 {
+		return id, nil
+	}
+	if cmd.client.defaultWorkspaceID != 0 {
+		return cmd.client.defaultWorkspaceID, nil
+	}
+
+	user, err := cmd.client.GetMe(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("looking up default workspace: %w", err)
+	}
+	return user.DefaultWorkspaceID, nil
+}
+
+// dateRangeFlags registers --from and --to
+func dateRangeFlags(fs *flag.FlagSet) {
+	fs.String("from", "", "first day, YYYY-MM-DD (default: today)")
+	fs.String("to", "", "last day, inclusive, YYYY-MM-DD (default: the --from day)")
+}
+
+// dateRange resolves --from and --to into a half-open range of whole days in
+// the client's timezone
+func (cmd *commandContext) dateRange() (from, to time.Time, err error) {
+	loc := cmd.client.timeZone()
+
+	now := cmd.env.Now().In(loc)
+	from = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if value := cmd.fs.Lookup("from").Value.String(); value != "" {
+		if from, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			return from, to, usageError{fmt.Errorf("invalid --from date (use YYYY-MM-DD): %w", err)}
+		}
+	}
+
+	to = from
+	if value := cmd.fs.Lookup("to").Value.String(); value != "" {
+		if to, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			return from, to, usageError{fmt.Errorf("invalid --to date (use YYYY-MM-DD): %w", err)}
+		}
+	}
+	if to.Before(from) {
+		return from, to, usageError{errors.New("--to must not be before --from")}
+	}
+
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+func runStart(ctx context.Context, cmd *commandContext) error {
+	description := strings.Join(cmd.fs.Args(), " ")
+	if description == "" {
+		return usageError{errors.New("a description is required")}
+	}
+
+	workspaceID, err := cmd.workspaceID(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry := TimeEntryRequest{
+		Description: description,
+		Start:       cmd.env.Now(),
+		Duration:    -1, // Running timer
+		CreatedWith: "toggl-mcp",
+	}
+	if id := cmd.intFlag("project"); id != 0 {
+		entry.ProjectID = &id
+	}
+
+	started, err := cmd.client.CreateTimeEntry(ctx, workspaceID, entry)
+	if err != nil {
+		return fmt.Errorf("starting time entry: %w", err)
+	}
+
+	return cmd.output(started, fmt.Sprintf("Started time entry: %s (ID: %d)", started.Description, started.ID))
+}
+
+func runStop(ctx context.Context, cmd *commandContext) error {
+	rounding, err := cmd.rounding("round")
+	if err != nil {
+		return err
+	}
+
+	stopped, err := cmd.client.StopCurrentTimeEntry(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	duration := formatDuration(stopped.Duration, Rounding{})
+	if rounding.Enabled() {
+		// Show the raw duration next to the rounded one it was changed to
+		duration = formatDuration(stopped.Duration, rounding)
+		if stopped, err = cmd.client.roundTimeEntry(ctx, stopped.WorkspaceID, stopped, rounding); err != nil {
+			return fmt.Errorf("rounding time entry: %w", err)
+		}
+	}
+
+	return cmd.output(stopped, fmt.Sprintf("Stopped time entry: %s (ID: %d, %s)",
+		stopped.Description, stopped.ID, duration))
+}
+
+func runCurrent(ctx context.Context, cmd *commandContext) error {
+	current, err := cmd.client.GetCurrentTimeEntry(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cmd.output(current, formatCurrentEntry(current, cmd.env.Now()))
+}
+
+func runEntries(ctx context.Context, cmd *commandContext) error {
+	from, to, err := cmd.dateRange()
+	if err != nil {
+		return err
+	}
+	rounding, err := cmd.rounding("rounded")
+	if err != nil {
+		return err
+	}
+
+	entries, err := cmd.client.GetTimeEntries(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("getting time entries: %w", err)
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Found %d time entries:\n", len(entries)))
+	for _, entry := range entries {
+		text.WriteString(formatTimeEntryLine(entry, rounding) + "\n")
+	}
+
+	return cmd.output(entries, text.String())
+}
+
+func runProjects(ctx context.Context, cmd *commandContext) error {
+	workspaceID, err := cmd.workspaceID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var active *bool
+	if cmd.fs.Lookup("active").Value.String() == "true" {
+		active = new(bool)
+		*active = true
+	}
+
+	projects, err := cmd.client.GetProjects(ctx, workspaceID, active)
+	if err != nil {
+		return fmt.Errorf("getting projects: %w", err)
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Found %d projects:\n", len(projects)))
+	for _, project := range projects {
+		text.WriteString(formatProjectLine(project) + "\n")
+	}
+
+	return cmd.output(projects, text.String())
+}
+
+func runReport(ctx context.Context, cmd *commandContext) error {
+	from, to, err := cmd.dateRange()
+	if err != nil {
+		return err
+	}
+	rounding, err := cmd.rounding("rounded")
+	if err != nil {
+		return err
+	}
+
+	entries, err := cmd.client.GetTimeEntries(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("getting time entries: %w", err)
+	}
+
+	names, err := cmd.client.projectNames(ctx, entries)
+	if err != nil {
+		return fmt.Errorf("getting project names: %w", err)
+	}
+
+	report := buildReport(entries, names, rounding, cmd.env.Now())
+	report.From = from.Format("2006-01-02")
+	report.To = to.AddDate(0, 0, -1).Format("2006-01-02")
+
+	return cmd.output(report, formatReport(report))
+}
+
+func runExport(ctx context.Context, cmd *commandContext) error {
+	from, to, err := cmd.dateRange()
+	if err != nil {
+		return err
+	}
+
+	opts := ExportOptions{
+		Format:  cmd.fs.Lookup("format").Value.String(),
+		Columns: splitList(cmd.fs.Lookup("columns").Value.String()),
+	}
+	if opts.Format == "" && cmd.json {
+		opts.Format = ExportJSONL
+	}
+	if opts, err = opts.withDefaults(); err != nil {
+		return usageError{err}
+	}
+
+	return cmd.writeExport(func(w io.Writer) (int, error) {
+		return cmd.client.exportTimeEntries(ctx, w, from, to, opts, cmd.env.Now())
+	})
+}
+
+func runExportICS(ctx context.Context, cmd *commandContext) error {
+	from, to, err := cmd.dateRange()
+	if err != nil {
+		return err
+	}
+
+	return cmd.writeExport(func(w io.Writer) (int, error) {
+		return cmd.client.exportICS(ctx, w, from, to, cmd.env.Now())
+	})
+}
+
+// writeExport runs export into standard output, or into the file named by
+// --output followed by a note on standard output
+func (cmd *commandContext) writeExport(export func(io.Writer) (int, error)) error {
+	output := cmd.fs.Lookup("output").Value.String()
+	if output == "" {
+		_, err := export(cmd.env.Stdout)
+		return err
+	}
+
+	var buf bytes.Buffer
+	count, err := export(&buf)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("writing export: %w", err)
+	}
+
+	_, err = fmt.Fprintf(cmd.env.Stdout, "Exported %d time entries to %s\n", count, output)
+	return err
+}
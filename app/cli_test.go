@@ -0,0 +1,275 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCommand(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	entry := testTimeEntry
+	entry.Start = now.Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		command    string
+		args       []string
+		rounding   Rounding
+		handler    func(w http.ResponseWriter, r *http.Request)
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:    "start",
+			command: "start",
+			args:    []string{"--project", "111", "Write", "docs"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v9/me":
+					writeJSON(w, http.StatusOK, testUser)
+				case "/api/v9/workspaces/456/time_entries":
+					var req TimeEntryRequest
+					json.NewDecoder(r.Body).Decode(&req)
+					if req.Description != "Write docs" || req.ProjectID == nil || *req.ProjectID != 111 {
+						writeError(w, http.StatusBadRequest, "unexpected request")
+						return
+					}
+					started := entry
+					started.Description = req.Description
+					writeJSON(w, http.StatusOK, started)
+				}
+			},
+			wantStdout: "Started time entry: Write docs (ID: 789)",
+		},
+		{
+			name:       "start without description",
+			command:    "start",
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			wantCode:   ExitUsage,
+			wantStderr: "a description is required",
+		},
+		{
+			name:    "stop with nothing running",
+			command: "stop",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, nil)
+			},
+			wantCode:   ExitNoRunningEntry,
+			wantStderr: "no running time entry",
+		},
+		{
+			name:    "current",
+			command: "current",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, entry)
+			},
+			wantStdout: "Running for: 1h0m0s",
+		},
+		{
+			name:    "current as json",
+			command: "current",
+			args:    []string{"--json"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, entry)
+			},
+			wantStdout: `"description": "Test Entry"`,
+		},
+		{
+			name:    "entries defaults to today",
+			command: "entries",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				if q.Get("start_date") != "2024-03-15T00:00:00Z" || q.Get("end_date") != "2024-03-16T00:00:00Z" {
+					writeError(w, http.StatusBadRequest, "unexpected range "+r.URL.RawQuery)
+					return
+				}
+				writeJSON(w, http.StatusOK, []TimeEntry{entry})
+			},
+			wantStdout: "Found 1 time entries:\n- Test Entry (ID: 789)",
+		},
+		{
+			name:       "entries with bad date",
+			command:    "entries",
+			args:       []string{"--from", "15/03/2024"},
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			wantCode:   ExitUsage,
+			wantStderr: "invalid --from date",
+		},
+		{
+			name:    "projects",
+			command: "projects",
+			args:    []string{"--workspace", "456", "--active"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("active") != "true" {
+					writeError(w, http.StatusBadRequest, "expected active filter")
+					return
+				}
+				writeJSON(w, http.StatusOK, []Project{testProject})
+			},
+			wantStdout: "- Test Project (ID: 111, active)",
+		},
+		{
+			name:    "report",
+			command: "report",
+			args:    []string{"--from", "2024-03-11", "--to", "2024-03-15"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v9/me/time_entries":
+					writeJSON(w, http.StatusOK, []TimeEntry{entry, entry})
+				case "/api/v9/workspaces/456/projects":
+					writeJSON(w, http.StatusOK, []Project{testProject})
+				}
+			},
+			wantStdout: "Report 2024-03-11 to 2024-03-15:\nTest Project    2h 00m  (2 entries)",
+		},
+		{
+			name:     "report rounded",
+			command:  "report",
+			args:     []string{"--rounded"},
+			rounding: Rounding{Mode: RoundUp, Increment: 15 * time.Minute, Minimum: 90 * time.Minute},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v9/me/time_entries":
+					writeJSON(w, http.StatusOK, []TimeEntry{entry, entry})
+				case "/api/v9/workspaces/456/projects":
+					writeJSON(w, http.StatusOK, []Project{testProject})
+				}
+			},
+			wantStdout: "Test Project    2h 00m    3h 00m  (2 entries)\nTotal           2h 00m    3h 00m",
+		},
+		{
+			name:       "entries rounded without rounding configured",
+			command:    "entries",
+			args:       []string{"--rounded"},
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			wantCode:   ExitUsage,
+			wantStderr: "no rounding is configured",
+		},
+		{
+			name:       "export",
+			command:    "export",
+			args:       []string{"--columns", "date,description,duration_hours"},
+			handler:    exportHandler,
+			wantStdout: "date,description,duration_hours\n2024-03-15,Review,1.50\n",
+		},
+		{
+			name:       "export ics",
+			command:    "export-ics",
+			handler:    exportHandler,
+			wantStdout: "UID:time-entry-1@toggl-mcp\r\n",
+		},
+		{
+			name:       "export with unknown column",
+			command:    "export",
+			args:       []string{"--columns", "rate"},
+			handler:    exportHandler,
+			wantCode:   ExitUsage,
+			wantStderr: `unknown column "rate"`,
+		},
+		{
+			name:    "unauthorized",
+			command: "current",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeError(w, http.StatusForbidden, "Incorrect username and/or password")
+			},
+			wantCode:   ExitAuth,
+			wantStderr: "status 403",
+		},
+		{
+			name:    "api error",
+			command: "projects",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v9/me":
+					writeJSON(w, http.StatusOK, testUser)
+				default:
+					writeError(w, http.StatusInternalServerError, "boom")
+				}
+			},
+			wantCode:   ExitAPI,
+			wantStderr: "status 500",
+		},
+		{
+			name:       "unknown flag",
+			command:    "stop",
+			args:       []string{"--force"},
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			wantCode:   ExitUsage,
+			wantStderr: "usage: toggl-mcp stop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := testServer(t, tt.handler)
+			client.location = time.UTC
+
+			var stdout, stderr bytes.Buffer
+			code := RunCommand(context.Background(), tt.command, tt.args, CommandEnv{
+				Stdout:   &stdout,
+				Stderr:   &stderr,
+				Client:   func(string) (*TogglClient, error) { return client, nil },
+				Now:      func() time.Time { return now },
+				Rounding: tt.rounding,
+			})
+
+			if code != tt.wantCode {
+				t.Errorf("expected exit code %d, got %d (stderr: %s)", tt.wantCode, code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("expected stdout to contain %q, got %q", tt.wantStdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("expected stderr to contain %q, got %q", tt.wantStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunCommandClientError(t *testing.T) {
+	var stderr bytes.Buffer
+	code := RunCommand(context.Background(), "current", []string{"--profile", "home"}, CommandEnv{
+		Stdout: &bytes.Buffer{},
+		Stderr: &stderr,
+		Client: func(profile string) (*TogglClient, error) {
+			return nil, fmt.Errorf("unknown profile %q", profile)
+		},
+	})
+
+	if code != ExitFailure {
+		t.Errorf("expected exit code %d, got %d", ExitFailure, code)
+	}
+	if !strings.Contains(stderr.String(), `unknown profile "home"`) {
+		t.Errorf("unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil, want: ExitOK},
+		{name: "no running entry", err: fmt.Errorf("stopping: %w", ErrNoRunningEntry), want: ExitNoRunningEntry},
+		{name: "unauthorized", err: &APIError{StatusCode: http.StatusUnauthorized}, want: ExitAuth},
+		{name: "not found", err: fmt.Errorf("getting: %w", &APIError{StatusCode: http.StatusNotFound}), want: ExitAPI},
+		{name: "other", err: errors.New("network down"), want: ExitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,18 +1,25 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	togglAPIBase   = "https://api.track.toggl.com/api/v9"
 	defaultTimeout = 30 * time.Second
+
+	// tokenRereadInterval is the least time between two re-reads of the
+	// token after a 403
+	tokenRereadInterval = time.Minute
 )
 
 // ClientOption is a functional option for configuring the client
@@ -39,17 +46,63 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
+// WithJournal records every mutation made through the client in journal
+func WithJournal(journal *Journal) ClientOption {
+	return func(c *TogglClient) {
+		c.journal = journal
+	}
+}
+
+// WithBaseURL sets the Toggl API base URL, e.g. for a proxy or a test server
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *TogglClient) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithDefaultWorkspace sets the workspace used by tools called without a workspace_id
+func WithDefaultWorkspace(workspaceID int) ClientOption {
+	return func(c *TogglClient) {
+		c.defaultWorkspaceID = workspaceID
+	}
+}
+
+// WithLocation sets the time zone in which tools interpret calendar dates
+func WithLocation(loc *time.Location) ClientOption {
+	return func(c *TogglClient) {
+		c.location = loc
+	}
+}
+
+// WithTokenSource re-reads the API token from source whenever the API
+// rejects the current one with 401, or with 403 at most once every
+// tokenRereadInterval, so that rotated tokens are picked up
+func WithTokenSource(source TokenSource) ClientOption {
+	return func(c *TogglClient) {
+		c.tokenSource = source
+	}
+}
+
 // TogglClient represents a client for the Toggl API
 type TogglClient struct {
-	APIToken string
-	client   *http.Client
-	logger   *slog.Logger
+	APIToken           string
+	tokenMu            sync.RWMutex
+	tokenSource        TokenSource
+	refreshing         *tokenRefresh
+	rereadAt           time.Time
+	baseURL            string
+	client             *http.Client
+	logger             *slog.Logger
+	journal            *Journal
+	defaultWorkspaceID int
+	location           *time.Location
 }
 
 // NewTogglClient creates a new Toggl client with options
 func NewTogglClient(apiToken string, opts ...ClientOption) *TogglClient {
 	c := &TogglClient{
 		APIToken: apiToken,
+		baseURL:  togglAPIBase,
 		client:   &http.Client{Timeout: defaultTimeout},
 		logger:   slog.Default(),
 	}
@@ -61,15 +114,69 @@ func NewTogglClient(apiToken string, opts ...ClientOption) *TogglClient {
 	return c
 }
 
-// makeRequest is a generic method for making API requests
+// timeZone is the zone tools interpret and show calendar dates in: the
+// configured location, or else the local zone
+func (c *TogglClient) timeZone() *time.Location {
+	if c.location == nil {
+		return time.Local
+	}
+	return c.location
+}
+
+// apiDate formats a calendar date for the API's start_date and end_date
+// parameters. With a location set, the date starts at midnight in that zone
+// rather than in UTC.
+func (c *TogglClient) apiDate(date time.Time) string {
+	if c.location == nil {
+		return date.Format("2006-01-02")
+	}
+	y, m, d := date.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, c.location).Format(time.RFC3339)
+}
+
+// makeRequest is a generic method for making API requests. If the API
+// rejects the token with 401 or 403 and the client has a token source, the
+// token is re-read and the request retried once with the new token. Since
+// some endpoints answer 403 to members without the rights they need, a 403
+// only re-reads the token once every tokenRereadInterval.
 func (c *TogglClient) makeRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, method, togglAPIBase+endpoint, body)
+	if c.tokenSource == nil {
+		return c.send(ctx, method, endpoint, body, c.token())
+	}
+
+	// Keep the body so the request can be replayed
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+	}
+
+	used := c.token()
+	resp, err := c.send(ctx, method, endpoint, bytes.NewReader(payload), used)
+	if err != nil || (resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden) {
+		return resp, err
+	}
+
+	token, changed := c.refreshToken(ctx, used, resp.StatusCode == http.StatusForbidden)
+	if !changed {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	return c.send(ctx, method, endpoint, bytes.NewReader(payload), token)
+}
+
+// send makes a single API request authenticated with token
+func (c *TogglClient) send(ctx context.Context, method, endpoint string, body io.Reader, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, body)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(c.APIToken, "api_token")
+	req.SetBasicAuth(token, "api_token")
 
 	c.logger.Debug("making API request",
 		slog.String("method", method),
@@ -81,9 +188,76 @@ func (c *TogglClient) makeRequest(ctx context.Context, method, endpoint string,
 		return nil, fmt.Errorf("executing request: %w", err)
 	}
 
+	if rec := auditFromContext(ctx); rec != nil {
+		rec.addStatus(resp.StatusCode)
+	}
+
 	return resp, nil
 }
 
+// token returns the current API token
+func (c *TogglClient) token() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+	return c.APIToken
+}
+
+// tokenRefresh is a re-read of the token that concurrent requests share
+type tokenRefresh struct {
+	done    chan struct{}
+	token   string
+	changed bool
+}
+
+// refreshToken re-reads the token from the token source after rejected was
+// refused, returning the token to retry with and whether it differs from
+// rejected. Requests failing at the same time share one re-read, so a token
+// command runs once, and a token already replaced is used without reading.
+// With throttled set, as for a 403, the token is not re-read again within
+// tokenRereadInterval of the last read.
+func (c *TogglClient) refreshToken(ctx context.Context, rejected string, throttled bool) (string, bool) {
+	c.tokenMu.Lock()
+	if c.APIToken != rejected {
+		token := c.APIToken
+		c.tokenMu.Unlock()
+		return token, true
+	}
+	if r := c.refreshing; r != nil {
+		c.tokenMu.Unlock()
+		select {
+		case <-r.done:
+			return r.token, r.changed
+		case <-ctx.Done():
+			return "", false
+		}
+	}
+	if throttled && time.Since(c.rereadAt) < tokenRereadInterval {
+		c.tokenMu.Unlock()
+		return rejected, false
+	}
+	r := &tokenRefresh{done: make(chan struct{})}
+	c.refreshing = r
+	c.tokenMu.Unlock()
+
+	token, err := c.tokenSource(ctx)
+
+	c.tokenMu.Lock()
+	c.rereadAt = time.Now()
+	switch {
+	case err != nil:
+		c.logger.Warn("failed to re-read API token", slog.Any("error", err))
+	case token != c.APIToken:
+		c.APIToken = token
+		r.token, r.changed = token, true
+		c.logger.Info("API token was rejected; retrying with the re-read token")
+	}
+	c.refreshing = nil
+	c.tokenMu.Unlock()
+	close(r.done)
+
+	return r.token, r.changed
+}
+
 // decodeResponse is a generic function to decode JSON responses
 func decodeResponse[T any](resp *http.Response) (T, error) {
 	var result T
@@ -107,3 +281,23 @@ func decodeResponse[T any](resp *http.Response) (T, error) {
 
 	return result, nil
 }
+
+// checkResponse closes a response whose body is not needed, returning an
+// APIError for non-2xx statuses
+func checkResponse(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("reading response body: %w", err)
+		}
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+		}
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
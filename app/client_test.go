@@ -122,6 +122,18 @@ func TestClientOptions(t *testing.T) {
 				timeout, client.client.Timeout)
 		}
 	})
+
+	t.Run("WithLocation", func(t *testing.T) {
+		loc := time.FixedZone("UTC+2", 2*60*60)
+		date := time.Date(2025, 7, 9, 0, 0, 0, 0, time.UTC)
+
+		if got := NewTogglClient("token").apiDate(date); got != "2025-07-09" {
+			t.Errorf("expected plain date without location, got %q", got)
+		}
+		if got := NewTogglClient("token", WithLocation(loc)).apiDate(date); got != "2025-07-09T00:00:00+02:00" {
+			t.Errorf("expected midnight in location, got %q", got)
+		}
+	})
 }
 
 func TestTogglClient_makeRequest(t *testing.T) {
@@ -505,6 +517,20 @@ func TestDecodeResponse_DifferentTypes(t *testing.T) {
 	}
 }
 
+func TestCheckResponse(t *testing.T) {
+	ok := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}
+	if err := checkResponse(ok); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	notFound := &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("missing"))}
+	err := checkResponse(notFound)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Body != "missing" {
+		t.Errorf("expected APIError 404, got %v", err)
+	}
+}
+
 // errorReader implements io.ReadCloser and always returns an error
 type errorReader struct{}
 
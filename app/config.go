@@ -0,0 +1,500 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileNames are the file names searched for in the config directory, in order
+var configFileNames = []string{"config.toml", "config.yaml", "config.yml", "config.json"}
+
+// Duration is a time.Duration written as a string such as "30s" or "5m" in config files
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Config is the server configuration. It is assembled from defaults, a config
+// file, environment variables and flags, in increasing order of precedence.
+type Config struct {
+	APIToken           string             `json:"api_token,omitempty" yaml:"api_token,omitempty" toml:"api_token,omitempty"`
+	APITokenFile       string             `json:"api_token_file,omitempty" yaml:"api_token_file,omitempty" toml:"api_token_file,omitempty"`
+	APITokenCmd        string             `json:"api_token_cmd,omitempty" yaml:"api_token_cmd,omitempty" toml:"api_token_cmd,omitempty"`
+	APIBaseURL         string             `json:"api_base_url" yaml:"api_base_url" toml:"api_base_url"`
+	Timeout            Duration           `json:"timeout" yaml:"timeout" toml:"timeout"`
+	DefaultWorkspaceID int                `json:"default_workspace_id,omitempty" yaml:"default_workspace_id,omitempty" toml:"default_workspace_id,omitzero"`
+	Timezone           string             `json:"timezone,omitempty" yaml:"timezone,omitempty" toml:"timezone,omitempty"`
+	OutputFormat       string             `json:"output_format" yaml:"output_format" toml:"output_format"`
+	JournalPath        string             `json:"journal_path,omitempty" yaml:"journal_path,omitempty" toml:"journal_path,omitempty"`
+	ProfilesFile       string             `json:"profiles_file,omitempty" yaml:"profiles_file,omitempty" toml:"profiles_file,omitempty"`
+	DefaultProfile     string             `json:"default_profile,omitempty" yaml:"default_profile,omitempty" toml:"default_profile,omitempty"`
+	Log                LogConfig          `json:"log" yaml:"log" toml:"log"`
+	Server             ServerConfig       `json:"server" yaml:"server" toml:"server"`
+	Tools              ToolsConfig        `json:"tools" yaml:"tools" toml:"tools"`
+	AuditLog           AuditLogConfig     `json:"audit_log" yaml:"audit_log" toml:"audit_log"`
+	Rounding           RoundingConfig     `json:"rounding" yaml:"rounding" toml:"rounding"`
+	WorkHours          WorkHoursConfig    `json:"work_hours" yaml:"work_hours" toml:"work_hours"`
+	Invoice            InvoiceConfig      `json:"invoice" yaml:"invoice" toml:"invoice"`
+	Budget             BudgetConfig       `json:"budget" yaml:"budget" toml:"budget"`
+	Timesheet          TimesheetConfig    `json:"timesheet" yaml:"timesheet" toml:"timesheet"`
+	Schedule           ScheduleConfig     `json:"schedule" yaml:"schedule" toml:"schedule"`
+	Profiles           map[string]Profile `json:"profiles,omitempty" yaml:"profiles,omitempty" toml:"profiles,omitempty"`
+}
+
+// LogConfig controls the server's own log output on stderr
+type LogConfig struct {
+	Level  string `json:"level" yaml:"level" toml:"level"`
+	Format string `json:"format" yaml:"format" toml:"format"`
+}
+
+// ServerConfig selects the MCP transport
+type ServerConfig struct {
+	Transport string `json:"transport" yaml:"transport" toml:"transport"`
+	Listen    string `json:"listen" yaml:"listen" toml:"listen"`
+	BaseURL   string `json:"base_url,omitempty" yaml:"base_url,omitempty" toml:"base_url,omitempty"`
+	MultiUser bool   `json:"multi_user" yaml:"multi_user" toml:"multi_user"`
+}
+
+// ToolsConfig selects which tools are registered
+type ToolsConfig struct {
+	Enabled         []string `json:"enabled,omitempty" yaml:"enabled,omitempty" toml:"enabled,omitempty"`
+	EnableDelete    bool     `json:"enable_delete" yaml:"enable_delete" toml:"enable_delete"`
+	ConfirmationTTL Duration `json:"confirmation_ttl" yaml:"confirmation_ttl" toml:"confirmation_ttl"`
+	AllowedDirs     []string `json:"allowed_dirs,omitempty" yaml:"allowed_dirs,omitempty" toml:"allowed_dirs,omitempty"`
+}
+
+// AuditLogConfig configures the audit log; it is disabled without a path
+type AuditLogConfig struct {
+	Path       string `json:"path,omitempty" yaml:"path,omitempty" toml:"path,omitempty"`
+	MaxSizeMB  int    `json:"max_size_mb" yaml:"max_size_mb" toml:"max_size_mb"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" toml:"max_backups"`
+}
+
+// RoundingConfig describes how tracked durations are rounded; it is
+// disabled without a mode
+type RoundingConfig struct {
+	Mode      string   `json:"mode,omitempty" yaml:"mode,omitempty" toml:"mode,omitempty"`
+	Increment Duration `json:"increment,omitempty" yaml:"increment,omitempty" toml:"increment,omitzero"`
+	Minimum   Duration `json:"minimum,omitempty" yaml:"minimum,omitempty" toml:"minimum,omitzero"`
+}
+
+// WorkHoursConfig is the working day, in the configured timezone, inside
+// which gaps between time entries are looked for
+type WorkHoursConfig struct {
+	Start string   `json:"start" yaml:"start" toml:"start"`
+	End   string   `json:"end" yaml:"end" toml:"end"`
+	Days  []string `json:"days" yaml:"days" toml:"days"`
+}
+
+// InvoiceConfig holds the sender details and templates of generated
+// invoices; built-in templates are used for the ones not set
+type InvoiceConfig struct {
+	Sender           InvoiceSender `json:"sender" yaml:"sender" toml:"sender"`
+	DueDays          int           `json:"due_days" yaml:"due_days" toml:"due_days"`
+	Notes            string        `json:"notes,omitempty" yaml:"notes,omitempty" toml:"notes,omitempty"`
+	MarkdownTemplate string        `json:"markdown_template,omitempty" yaml:"markdown_template,omitempty" toml:"markdown_template,omitempty"`
+	HTMLTemplate     string        `json:"html_template,omitempty" yaml:"html_template,omitempty" toml:"html_template,omitempty"`
+}
+
+// InvoiceSender is who an invoice is from
+type InvoiceSender struct {
+	Name    string `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+	Address string `json:"address,omitempty" yaml:"address,omitempty" toml:"address,omitempty"`
+	Email   string `json:"email,omitempty" yaml:"email,omitempty" toml:"email,omitempty"`
+	TaxID   string `json:"tax_id,omitempty" yaml:"tax_id,omitempty" toml:"tax_id,omitempty"`
+}
+
+// BudgetConfig sets when projects are flagged as running over budget
+type BudgetConfig struct {
+	// Thresholds are percentages of a project's estimate or fixed fee
+	Thresholds []int `json:"thresholds" yaml:"thresholds" toml:"thresholds"`
+}
+
+// TimesheetConfig lays out the weekly timesheet
+type TimesheetConfig struct {
+	// WeekStart is the first day of a week, as in work_hours.days
+	WeekStart string `json:"week_start" yaml:"week_start" toml:"week_start"`
+}
+
+// ScheduleConfig is the contracted working time that work_balance and
+// weekly_timesheet compare tracked time with
+type ScheduleConfig struct {
+	// Hours are the hours due per weekday, keyed by day name as in
+	// work_hours.days; set 0 for days off
+	Hours map[string]float64 `json:"hours" yaml:"hours" toml:"hours"`
+	// Holidays are public holidays, YYYY-MM-DD, on which no hours are due
+	Holidays []string `json:"holidays,omitempty" yaml:"holidays,omitempty" toml:"holidays,omitempty"`
+	// Vacation are vacation days, YYYY-MM-DD, or ranges such as
+	// 2024-08-05..2024-08-16 including both ends
+	Vacation []string `json:"vacation,omitempty" yaml:"vacation,omitempty" toml:"vacation,omitempty"`
+}
+
+// DefaultConfig returns the configuration used when nothing else is set
+func DefaultConfig() Config {
+	return Config{
+		APIBaseURL:   togglAPIBase,
+		Timeout:      Duration(defaultTimeout),
+		OutputFormat: OutputText,
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Server: ServerConfig{
+			Transport: "stdio",
+			Listen:    "localhost:8080",
+		},
+		Tools: ToolsConfig{
+			ConfirmationTTL: Duration(defaultConfirmationTTL),
+		},
+		AuditLog: AuditLogConfig{
+			MaxSizeMB:  defaultAuditMaxSize >> 20,
+			MaxBackups: defaultAuditMaxBackups,
+		},
+		WorkHours: WorkHoursConfig{
+			Start: "09:00",
+			End:   "17:00",
+			Days:  []string{"mon", "tue", "wed", "thu", "fri"},
+		},
+		Invoice: InvoiceConfig{
+			DueDays: defaultInvoiceDueDays,
+		},
+		Budget: BudgetConfig{
+			Thresholds: []int{80, 100},
+		},
+		Timesheet: TimesheetConfig{
+			WeekStart: "mon",
+		},
+		Schedule: ScheduleConfig{
+			Hours: map[string]float64{"mon": 8, "tue": 8, "wed": 8, "thu": 8, "fri": 8, "sat": 0, "sun": 0},
+		},
+	}
+}
+
+// FindConfigFile returns the first config file present in dir, or "" if there is none
+func FindConfigFile(dir string) string {
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// LoadConfigFile reads the TOML, YAML or JSON file at path, chosen by its
+// extension, on top of cfg. Unknown keys are rejected so typos do not go unnoticed.
+func LoadConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("parsing config %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("parsing config %s: unknown key %q", path, undecoded[0].String())
+		}
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config format %q (use .toml, .yaml or .json)", ext)
+	}
+
+	return nil
+}
+
+// envBinding maps an environment variable onto a config field
+type envBinding struct {
+	name string
+	set  func(cfg *Config, value string) error
+}
+
+// The token variables each replace whichever token source the config file
+// set; when several are set, TOGGL_API_TOKEN wins, then the file, then the command.
+var envBindings = []envBinding{
+	{"TOGGL_API_TOKEN_CMD", func(c *Config, v string) error { c.setTokenSource("", "", v); return nil }},
+	{"TOGGL_API_TOKEN_FILE", func(c *Config, v string) error { c.setTokenSource("", v, ""); return nil }},
+	{"TOGGL_API_TOKEN", func(c *Config, v string) error { c.setTokenSource(v, "", ""); return nil }},
+	{"TOGGL_API_BASE_URL", func(c *Config, v string) error { c.APIBaseURL = v; return nil }},
+	{"TOGGL_TIMEOUT", func(c *Config, v string) error { return c.Timeout.UnmarshalText([]byte(v)) }},
+	{"TOGGL_DEFAULT_WORKSPACE_ID", func(c *Config, v string) error { return setInt(&c.DefaultWorkspaceID, v) }},
+	{"TOGGL_TIMEZONE", func(c *Config, v string) error { c.Timezone = v; return nil }},
+	{"TOGGL_OUTPUT_FORMAT", func(c *Config, v string) error { c.OutputFormat = v; return nil }},
+	{"TOGGL_JOURNAL_PATH", func(c *Config, v string) error { c.JournalPath = v; return nil }},
+	{"TOGGL_PROFILES", func(c *Config, v string) error { c.ProfilesFile = v; return nil }},
+	{"TOGGL_LOG_LEVEL", func(c *Config, v string) error { c.Log.Level = v; return nil }},
+	{"TOGGL_LOG_FORMAT", func(c *Config, v string) error { c.Log.Format = v; return nil }},
+	{"TOGGL_TRANSPORT", func(c *Config, v string) error { c.Server.Transport = v; return nil }},
+	{"TOGGL_LISTEN", func(c *Config, v string) error { c.Server.Listen = v; return nil }},
+	{"TOGGL_MULTI_USER", func(c *Config, v string) error { return setBool(&c.Server.MultiUser, v) }},
+	{"TOGGL_ENABLED_TOOLS", func(c *Config, v string) error { c.Tools.Enabled = splitList(v); return nil }},
+	{"TOGGL_ENABLE_DELETE_TOOLS", func(c *Config, v string) error { return setBool(&c.Tools.EnableDelete, v) }},
+	{"TOGGL_CONFIRMATION_TTL", func(c *Config, v string) error { return c.Tools.ConfirmationTTL.UnmarshalText([]byte(v)) }},
+	{"TOGGL_ALLOWED_DIRS", func(c *Config, v string) error { c.Tools.AllowedDirs = splitList(v); return nil }},
+	{"TOGGL_AUDIT_LOG", func(c *Config, v string) error { c.AuditLog.Path = v; return nil }},
+	{"TOGGL_AUDIT_LOG_MAX_SIZE_MB", func(c *Config, v string) error { return setInt(&c.AuditLog.MaxSizeMB, v) }},
+	{"TOGGL_AUDIT_LOG_MAX_BACKUPS", func(c *Config, v string) error { return setInt(&c.AuditLog.MaxBackups, v) }},
+	{"TOGGL_ROUNDING_MODE", func(c *Config, v string) error { c.Rounding.Mode = v; return nil }},
+	{"TOGGL_ROUNDING_INCREMENT", func(c *Config, v string) error { return c.Rounding.Increment.UnmarshalText([]byte(v)) }},
+	{"TOGGL_ROUNDING_MINIMUM", func(c *Config, v string) error { return c.Rounding.Minimum.UnmarshalText([]byte(v)) }},
+	{"TOGGL_WORK_START", func(c *Config, v string) error { c.WorkHours.Start = v; return nil }},
+	{"TOGGL_WORK_END", func(c *Config, v string) error { c.WorkHours.End = v; return nil }},
+	{"TOGGL_WORK_DAYS", func(c *Config, v string) error { c.WorkHours.Days = splitList(v); return nil }},
+	{"TOGGL_BUDGET_THRESHOLDS", func(c *Config, v string) error { return setIntList(&c.Budget.Thresholds, v) }},
+	{"TOGGL_WEEK_START", func(c *Config, v string) error { c.Timesheet.WeekStart = v; return nil }},
+	{"TOGGL_SCHEDULE_HOURS", func(c *Config, v string) error { return setHours(&c.Schedule.Hours, v) }},
+	{"TOGGL_HOLIDAYS", func(c *Config, v string) error { c.Schedule.Holidays = splitList(v); return nil }},
+	{"TOGGL_VACATION", func(c *Config, v string) error { c.Schedule.Vacation = splitList(v); return nil }},
+}
+
+// ApplyEnv overrides cfg with the TOGGL_* environment variables that are set
+// and non-empty, as reported by lookup (usually os.LookupEnv)
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	for _, b := range envBindings {
+		value, ok := lookup(b.name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := b.set(c, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", b.name, err)
+		}
+	}
+	return nil
+}
+
+// setTokenSource sets exactly one of the token settings
+func (c *Config) setTokenSource(token, file, cmd string) {
+	c.APIToken, c.APITokenFile, c.APITokenCmd = token, file, cmd
+}
+
+func setInt(dst *int, value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*dst = n
+	return nil
+}
+
+// setIntList parses a comma-separated list of integers
+func setIntList(dst *[]int, value string) error {
+	var list []int
+	for _, item := range splitList(value) {
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			return err
+		}
+		list = append(list, n)
+	}
+	*dst = list
+	return nil
+}
+
+// setHours parses a comma-separated list of day=hours pairs, e.g. mon=8,fri=6,
+// into hours
+func setHours(hours *map[string]float64, value string) error {
+	if *hours == nil {
+		*hours = make(map[string]float64)
+	}
+	for _, item := range splitList(value) {
+		day, h, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("invalid hours %q (use day=hours)", item)
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(h), 64)
+		if err != nil {
+			return err
+		}
+		(*hours)[strings.TrimSpace(day)] = n
+	}
+	return nil
+}
+
+func setBool(dst *bool, value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*dst = b
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping blank items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Validate checks that every setting has a usable value
+func (c Config) Validate() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		return fmt.Errorf("log.level: %w", err)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		return fmt.Errorf("log.format must be json or text, got %q", c.Log.Format)
+	}
+	if c.OutputFormat != OutputText && c.OutputFormat != OutputJSON {
+		return fmt.Errorf("output_format must be %s or %s, got %q", OutputText, OutputJSON, c.OutputFormat)
+	}
+	switch c.Server.Transport {
+	case "stdio", "sse", "http":
+	default:
+		return fmt.Errorf("server.transport must be stdio, sse or http, got %q", c.Server.Transport)
+	}
+	if c.Timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+	if countSet(c.APIToken, c.APITokenFile, c.APITokenCmd) > 1 {
+		return errors.New("only one of api_token, api_token_file and api_token_cmd may be set")
+	}
+	if _, err := c.Location(); err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+
+	switch c.Rounding.Mode {
+	case "":
+	case "up", "down", "nearest":
+		if c.Rounding.Increment <= 0 {
+			return errors.New("rounding.increment must be positive when rounding is enabled")
+		}
+	default:
+		return fmt.Errorf("rounding.mode must be up, down or nearest, got %q", c.Rounding.Mode)
+	}
+	if c.Rounding.Minimum < 0 {
+		return errors.New("rounding.minimum must not be negative")
+	}
+	if _, err := c.WorkHours.Parse(); err != nil {
+		return fmt.Errorf("work_hours: %w", err)
+	}
+	if _, err := c.Invoice.Parse(); err != nil {
+		return fmt.Errorf("invoice: %w", err)
+	}
+	for _, threshold := range c.Budget.Thresholds {
+		if threshold <= 0 {
+			return fmt.Errorf("budget.thresholds must be positive percentages, got %d", threshold)
+		}
+	}
+	if _, err := c.Timesheet.Parse(); err != nil {
+		return fmt.Errorf("timesheet: %w", err)
+	}
+	if _, err := c.Schedule.Parse(); err != nil {
+		return fmt.Errorf("schedule: %w", err)
+	}
+
+	return nil
+}
+
+// TokenSource returns the source the API token is re-read from, or nil when
+// the token is given directly
+func (c Config) TokenSource() TokenSource {
+	return tokenSource(c.APITokenFile, c.APITokenCmd)
+}
+
+// tokenSource returns a file or command token source, or nil if neither is set
+func tokenSource(file, cmd string) TokenSource {
+	switch {
+	case file != "":
+		return FileTokenSource(file)
+	case cmd != "":
+		return CommandTokenSource(cmd)
+	}
+	return nil
+}
+
+// countSet counts the non-empty values
+func countSet(values ...string) int {
+	n := 0
+	for _, v := range values {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Location returns the configured time zone, or nil when none is set
+func (c Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return nil, nil
+	}
+	return time.LoadLocation(c.Timezone)
+}
+
+// NewLogger creates a logger writing to w at the configured level and format
+func (c LogConfig) NewLogger(w io.Writer) *slog.Logger {
+	var level slog.Level
+	level.UnmarshalText([]byte(c.Level))
+
+	opts := &slog.HandlerOptions{Level: level}
+	if c.Format == "text" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+// Redacted returns a copy of c with every API token replaced
+func (c Config) Redacted() Config {
+	if c.APIToken != "" {
+		c.APIToken = "[redacted]"
+	}
+	if len(c.Profiles) > 0 {
+		profiles := make(map[string]Profile, len(c.Profiles))
+		for name, p := range c.Profiles {
+			if p.APIToken != "" {
+				p.APIToken = "[redacted]"
+			}
+			profiles[name] = p
+		}
+		c.Profiles = profiles
+	}
+	return c
+}
+
+// WriteTOML writes c to w in the TOML config file format
+func (c Config) WriteTOML(w io.Writer) error {
+	return toml.NewEncoder(w).Encode(c)
+}
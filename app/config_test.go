@@ -0,0 +1,316 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "toml",
+			file: "config.toml",
+			content: `timeout = "10s"
+default_workspace_id = 456
+
+[log]
+level = "debug"
+
+[tools]
+enabled = ["get_projects"]
+
+[profiles.work]
+api_token = "w-token"
+`,
+		},
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `timeout: 10s
+default_workspace_id: 456
+log:
+  level: debug
+tools:
+  enabled: [get_projects]
+profiles:
+  work:
+    api_token: w-token
+`,
+		},
+		{
+			name: "json",
+			file: "config.json",
+			content: `{"timeout": "10s", "default_workspace_id": 456, "log": {"level": "debug"},
+"tools": {"enabled": ["get_projects"]}, "profiles": {"work": {"api_token": "w-token"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("writing config: %v", err)
+			}
+
+			cfg := DefaultConfig()
+			if err := LoadConfigFile(path, &cfg); err != nil {
+				t.Fatalf("LoadConfigFile failed: %v", err)
+			}
+
+			if time.Duration(cfg.Timeout) != 10*time.Second {
+				t.Errorf("expected timeout 10s, got %v", time.Duration(cfg.Timeout))
+			}
+			if cfg.DefaultWorkspaceID != 456 {
+				t.Errorf("expected default workspace 456, got %d", cfg.DefaultWorkspaceID)
+			}
+			if cfg.Log.Level != "debug" || cfg.Log.Format != "json" {
+				t.Errorf("expected file level and default format, got %+v", cfg.Log)
+			}
+			if len(cfg.Tools.Enabled) != 1 || cfg.Tools.Enabled[0] != "get_projects" {
+				t.Errorf("unexpected enabled tools: %v", cfg.Tools.Enabled)
+			}
+			if cfg.Profiles["work"].APIToken != "w-token" {
+				t.Errorf("unexpected profiles: %+v", cfg.Profiles)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown toml key", file: "config.toml", content: "timeot = \"10s\"\n", wantErr: "unknown key"},
+		{name: "unknown yaml key", file: "config.yaml", content: "timeot: 10s\n", wantErr: "not found"},
+		{name: "unknown json key", file: "config.json", content: `{"timeot": "10s"}`, wantErr: "unknown field"},
+		{name: "invalid duration", file: "config.toml", content: "timeout = \"soon\"\n", wantErr: "invalid duration"},
+		{name: "unsupported format", file: "config.ini", content: "", wantErr: "unsupported config format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("writing config: %v", err)
+			}
+
+			cfg := DefaultConfig()
+			err := LoadConfigFile(path, &cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	if got := FindConfigFile(dir); got != "" {
+		t.Errorf("expected no config file, got %q", got)
+	}
+
+	for _, name := range []string{"config.json", "config.toml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o600); err != nil {
+			t.Fatalf("writing config: %v", err)
+		}
+	}
+	if got := FindConfigFile(dir); got != filepath.Join(dir, "config.toml") {
+		t.Errorf("expected config.toml to win, got %q", got)
+	}
+}
+
+func TestConfigApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"TOGGL_API_TOKEN":           "env-token",
+		"TOGGL_TIMEOUT":             "5s",
+		"TOGGL_ENABLE_DELETE_TOOLS": "true",
+		"TOGGL_ENABLED_TOOLS":       "get_projects, start_time_entry,",
+		"TOGGL_LOG_LEVEL":           "",
+		"TOGGL_ALLOWED_DIRS":        "/srv/exports,/srv/imports",
+		"TOGGL_WORK_DAYS":           "mon,tue,wed",
+		"TOGGL_SCHEDULE_HOURS":      "fri=6, sat=0",
+		"TOGGL_HOLIDAYS":            "2024-12-25,2024-12-26",
+	}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	cfg := DefaultConfig()
+	cfg.Log.Level = "debug"
+	if err := cfg.ApplyEnv(lookup); err != nil {
+		t.Fatalf("ApplyEnv failed: %v", err)
+	}
+
+	if cfg.APIToken != "env-token" {
+		t.Errorf("expected env token, got %q", cfg.APIToken)
+	}
+	if time.Duration(cfg.Timeout) != 5*time.Second {
+		t.Errorf("expected timeout 5s, got %v", time.Duration(cfg.Timeout))
+	}
+	if !cfg.Tools.EnableDelete {
+		t.Error("expected delete tools to be enabled")
+	}
+	if strings.Join(cfg.Tools.Enabled, ",") != "get_projects,start_time_entry" {
+		t.Errorf("unexpected enabled tools: %v", cfg.Tools.Enabled)
+	}
+	if strings.Join(cfg.Tools.AllowedDirs, ",") != "/srv/exports,/srv/imports" {
+		t.Errorf("unexpected allowed dirs: %v", cfg.Tools.AllowedDirs)
+	}
+	if strings.Join(cfg.WorkHours.Days, ",") != "mon,tue,wed" || cfg.WorkHours.Start != "09:00" {
+		t.Errorf("unexpected work hours: %+v", cfg.WorkHours)
+	}
+	if cfg.Timesheet.WeekStart != "mon" {
+		t.Errorf("unexpected timesheet: %+v", cfg.Timesheet)
+	}
+	if cfg.Schedule.Hours["fri"] != 6 || cfg.Schedule.Hours["mon"] != 8 || len(cfg.Schedule.Holidays) != 2 {
+		t.Errorf("unexpected schedule: %+v", cfg.Schedule)
+	}
+	if cfg.Log.Level != "debug" {
+		t.Errorf("expected empty variable to leave level alone, got %q", cfg.Log.Level)
+	}
+
+	env["TOGGL_AUDIT_LOG_MAX_BACKUPS"] = "many"
+	if err := cfg.ApplyEnv(lookup); err == nil || !strings.Contains(err.Error(), "TOGGL_AUDIT_LOG_MAX_BACKUPS") {
+		t.Errorf("expected error naming the variable, got %v", err)
+	}
+}
+
+func TestConfigApplyEnvTokenSources(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantToken string
+		wantFile  string
+		wantCmd   string
+	}{
+		{
+			name:     "file replaces token from config file",
+			env:      map[string]string{"TOGGL_API_TOKEN_FILE": "/run/secrets/toggl"},
+			wantFile: "/run/secrets/toggl",
+		},
+		{
+			name:    "command replaces token from config file",
+			env:     map[string]string{"TOGGL_API_TOKEN_CMD": "pass show toggl"},
+			wantCmd: "pass show toggl",
+		},
+		{
+			name: "token wins over file and command",
+			env: map[string]string{
+				"TOGGL_API_TOKEN":      "env-token",
+				"TOGGL_API_TOKEN_FILE": "/run/secrets/toggl",
+				"TOGGL_API_TOKEN_CMD":  "pass show toggl",
+			},
+			wantToken: "env-token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.APIToken = "file-config-token"
+			err := cfg.ApplyEnv(func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			})
+			if err != nil {
+				t.Fatalf("ApplyEnv failed: %v", err)
+			}
+			if cfg.APIToken != tt.wantToken || cfg.APITokenFile != tt.wantFile || cfg.APITokenCmd != tt.wantCmd {
+				t.Errorf("unexpected token settings: %q %q %q", cfg.APIToken, cfg.APITokenFile, cfg.APITokenCmd)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{name: "defaults", modify: func(*Config) {}},
+		{name: "bad log level", modify: func(c *Config) { c.Log.Level = "loud" }, wantErr: "log.level"},
+		{name: "bad log format", modify: func(c *Config) { c.Log.Format = "xml" }, wantErr: "log.format"},
+		{name: "bad output format", modify: func(c *Config) { c.OutputFormat = "csv" }, wantErr: "output_format"},
+		{name: "bad transport", modify: func(c *Config) { c.Server.Transport = "ws" }, wantErr: "server.transport"},
+		{name: "bad timezone", modify: func(c *Config) { c.Timezone = "Mars/Olympus" }, wantErr: "timezone"},
+		{
+			name:    "conflicting token sources",
+			modify:  func(c *Config) { c.APIToken = "token"; c.APITokenFile = "/run/secrets/toggl" },
+			wantErr: "only one of",
+		},
+		{name: "zero timeout", modify: func(c *Config) { c.Timeout = 0 }, wantErr: "timeout"},
+		{name: "bad rounding mode", modify: func(c *Config) { c.Rounding.Mode = "sideways" }, wantErr: "rounding.mode"},
+		{
+			name:    "rounding without increment",
+			modify:  func(c *Config) { c.Rounding.Mode = "up" },
+			wantErr: "rounding.increment",
+		},
+		{name: "bad work start", modify: func(c *Config) { c.WorkHours.Start = "9am" }, wantErr: "work_hours"},
+		{name: "bad work day", modify: func(c *Config) { c.WorkHours.Days = []string{"someday"} }, wantErr: "work_hours"},
+		{name: "missing invoice template", modify: func(c *Config) { c.Invoice.MarkdownTemplate = "/nonexistent/invoice.md" }, wantErr: "invoice"},
+		{name: "bad budget threshold", modify: func(c *Config) { c.Budget.Thresholds = []int{80, 0} }, wantErr: "budget.thresholds"},
+		{name: "bad week start", modify: func(c *Config) { c.Timesheet.WeekStart = "someday" }, wantErr: "timesheet: week_start"},
+		{name: "bad holiday", modify: func(c *Config) { c.Schedule.Holidays = []string{"christmas"} }, wantErr: "schedule: holidays"},
+		{
+			name: "valid rounding",
+			modify: func(c *Config) {
+				c.Rounding = RoundingConfig{Mode: "nearest", Increment: Duration(15 * time.Minute)}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfigRedacted(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.APIToken = "secret-token"
+	cfg.Profiles = map[string]Profile{"work": {APIToken: "work-secret", DefaultWorkspaceID: 1}}
+
+	var buf bytes.Buffer
+	if err := cfg.Redacted().WriteTOML(&buf); err != nil {
+		t.Fatalf("WriteTOML failed: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "secret") {
+		t.Errorf("expected secrets to be redacted:\n%s", out)
+	}
+	if !strings.Contains(out, `api_token = "[redacted]"`) {
+		t.Errorf("expected redacted token placeholder:\n%s", out)
+	}
+	if cfg.Profiles["work"].APIToken != "work-secret" {
+		t.Error("Redacted must not modify the original config")
+	}
+}
@@ -0,0 +1,271 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const defaultConfirmationTTL = 5 * time.Minute
+
+// pendingConfirmation is an issued token waiting to be redeemed
+type pendingConfirmation struct {
+	key     string
+	expires time.Time
+}
+
+// confirmationStore issues short-lived, single-use tokens that authorise a
+// destructive operation on an exact set of IDs
+type confirmationStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	pending map[string]pendingConfirmation
+}
+
+// newConfirmationStore creates a store whose tokens expire after ttl
+func newConfirmationStore(ttl time.Duration) *confirmationStore {
+	return &confirmationStore{
+		ttl:     ttl,
+		now:     time.Now,
+		pending: make(map[string]pendingConfirmation),
+	}
+}
+
+// confirmationKey binds an operation to a workspace and an order-independent set of IDs
+func confirmationKey(operation string, workspaceID int, ids []int) string {
+	sorted := append([]int(nil), ids...)
+	sort.Ints(sorted)
+
+	parts := make([]string, len(sorted))
+	for i, id := range sorted {
+		parts[i] = strconv.Itoa(id)
+	}
+	return fmt.Sprintf("%s:%d:%s", operation, workspaceID, strings.Join(parts, ","))
+}
+
+// issue creates a new token for key
+func (s *confirmationStore) issue(key string) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating confirmation token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for t, p := range s.pending {
+		if now.After(p.expires) {
+			delete(s.pending, t)
+		}
+	}
+	s.pending[token] = pendingConfirmation{key: key, expires: now.Add(s.ttl)}
+
+	return token, nil
+}
+
+// redeem consumes token if it was issued for key and has not expired
+func (s *confirmationStore) redeem(token, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pending[token]
+	if !ok || p.key != key {
+		return ErrInvalidToken
+	}
+
+	delete(s.pending, token)
+	if s.now().After(p.expires) {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+// deleteTools returns the guarded delete tools sharing a single confirmation store
+func deleteTools(client *TogglClient, store *confirmationStore) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"delete_time_entries",
+				mcp.WithDescription("Delete time entries in two steps. Call without confirmation_token to preview the entries and receive a short-lived token, then call again with the same IDs and the token to delete them."),
+				mcp.WithNumber("workspace_id", mcp.Required()),
+				mcp.WithArray("time_entry_ids", mcp.Required(), mcp.Items(map[string]interface{}{"type": "number"})),
+				mcp.WithString("confirmation_token"),
+			),
+			handler: wrapHandler(client, handleDeleteTimeEntries(store)),
+		},
+		{
+			tool: mcp.NewTool(
+				"delete_project",
+				mcp.WithDescription("Delete a project in two steps. Call without confirmation_token to preview the project and receive a short-lived token, then call again with the same ID and the token to delete it."),
+				mcp.WithNumber("workspace_id", mcp.Required()),
+				mcp.WithNumber("project_id", mcp.Required()),
+				mcp.WithString("confirmation_token"),
+			),
+			handler: wrapHandler(client, handleDeleteProject(store)),
+		},
+	}
+}
+
+// confirmationError converts a failed redemption into a tool error result
+func confirmationError(err error) (*mcp.CallToolResult, error) {
+	if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenExpired) {
+		return mcp.NewToolResultError(
+			fmt.Sprintf("Nothing was deleted: %s. Call again without confirmation_token to get a new preview.", err),
+		), nil
+	}
+	return nil, err
+}
+
+func handleDeleteTimeEntries(store *confirmationStore) func(
+	context.Context,
+	*TogglClient,
+	mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		workspaceID, err := getRequiredNumber(req.GetArguments(), "workspace_id")
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace_id: %w", err)
+		}
+
+		ids, err := getRequiredNumberList(req.GetArguments(), "time_entry_ids")
+		if err != nil {
+			return nil, fmt.Errorf("invalid time_entry_ids: %w", err)
+		}
+
+		key := confirmationKey("delete_time_entries", workspaceID, ids)
+		token := getOptionalString(req.GetArguments(), "confirmation_token")
+
+		if token == "" {
+			var result strings.Builder
+			result.WriteString(fmt.Sprintf("The following %d time entries will be deleted:\n", len(ids)))
+
+			for _, id := range ids {
+				entry, err := client.GetTimeEntry(ctx, id)
+				if err != nil {
+					var apiErr *APIError
+					if errors.As(err, &apiErr) {
+						return mcp.NewToolResultError(
+							fmt.Sprintf("Failed to preview time entry %d: %s", id, apiErr.Error()),
+						), nil
+					}
+					return nil, fmt.Errorf("previewing time entry: %w", err)
+				}
+				if entry.WorkspaceID != workspaceID {
+					return mcp.NewToolResultError(
+						fmt.Sprintf("Time entry %d belongs to workspace %d, not %d", id, entry.WorkspaceID, workspaceID),
+					), nil
+				}
+				result.WriteString(fmt.Sprintf("- %s (ID: %d, started %s) %s\n",
+					entry.Description, entry.ID, entry.Start.Format(time.RFC3339), formatDuration(entry.Duration, Rounding{})))
+			}
+
+			issued, err := store.issue(key)
+			if err != nil {
+				return nil, err
+			}
+
+			result.WriteString(fmt.Sprintf("\nNothing has been deleted yet. To confirm, call delete_time_entries again with the same IDs and confirmation_token: %s (expires in %s)",
+				issued, store.ttl))
+			return mcp.NewToolResultText(result.String()), nil
+		}
+
+		if err := store.redeem(token, key); err != nil {
+			return confirmationError(err)
+		}
+
+		var result strings.Builder
+		failed := 0
+		for _, id := range ids {
+			if err := client.DeleteTimeEntry(ctx, workspaceID, id); err != nil {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) {
+					return nil, fmt.Errorf("deleting time entries: %w", err)
+				}
+				failed++
+				result.WriteString(fmt.Sprintf("- ID %d: failed: %s\n", id, apiErr.Error()))
+				continue
+			}
+			result.WriteString(fmt.Sprintf("- ID %d: deleted\n", id))
+		}
+
+		summary := fmt.Sprintf("Deleted %d of %d time entries:\n", len(ids)-failed, len(ids))
+		if failed > 0 {
+			return mcp.NewToolResultError(summary + result.String()), nil
+		}
+		return mcp.NewToolResultText(summary + result.String()), nil
+	}
+}
+
+func handleDeleteProject(store *confirmationStore) func(
+	context.Context,
+	*TogglClient,
+	mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		workspaceID, err := getRequiredNumber(req.GetArguments(), "workspace_id")
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace_id: %w", err)
+		}
+
+		projectID, err := getRequiredNumber(req.GetArguments(), "project_id")
+		if err != nil {
+			return nil, fmt.Errorf("invalid project_id: %w", err)
+		}
+
+		key := confirmationKey("delete_project", workspaceID, []int{projectID})
+		token := getOptionalString(req.GetArguments(), "confirmation_token")
+
+		if token == "" {
+			project, err := client.GetProject(ctx, workspaceID, projectID)
+			if err != nil {
+				var apiErr *APIError
+				if errors.As(err, &apiErr) {
+					return mcp.NewToolResultError(
+						fmt.Sprintf("Failed to preview project %d: %s", projectID, apiErr.Error()),
+					), nil
+				}
+				return nil, fmt.Errorf("previewing project: %w", err)
+			}
+
+			issued, err := store.issue(key)
+			if err != nil {
+				return nil, err
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf(`The following project will be deleted:
+- %s (ID: %d)
+Its time entries will be kept but no longer assigned to a project.
+
+Nothing has been deleted yet. To confirm, call delete_project again with the same ID and confirmation_token: %s (expires in %s)`,
+				project.Name, project.ID, issued, store.ttl)), nil
+		}
+
+		if err := store.redeem(token, key); err != nil {
+			return confirmationError(err)
+		}
+
+		if err := client.DeleteProject(ctx, workspaceID, projectID); err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				return mcp.NewToolResultError(
+					fmt.Sprintf("Failed to delete project: %s", apiErr.Error()),
+				), nil
+			}
+			return nil, fmt.Errorf("deleting project: %w", err)
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Deleted project (ID: %d)", projectID)), nil
+	}
+}
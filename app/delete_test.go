@@ -0,0 +1,219 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var tokenPattern = regexp.MustCompile(`confirmation_token: ([0-9a-f]+)`)
+
+// extractToken pulls the confirmation token out of a preview result
+func extractToken(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+	content := result.Content[0].(mcp.TextContent).Text
+	match := tokenPattern.FindStringSubmatch(content)
+	if match == nil {
+		t.Fatalf("no confirmation token in result: %s", content)
+	}
+	return match[1]
+}
+
+func TestConfirmationKey(t *testing.T) {
+	a := confirmationKey("delete_time_entries", 456, []int{3, 1, 2})
+	b := confirmationKey("delete_time_entries", 456, []int{1, 2, 3})
+	if a != b {
+		t.Errorf("expected order-independent keys, got %q and %q", a, b)
+	}
+
+	if a == confirmationKey("delete_time_entries", 456, []int{1, 2}) {
+		t.Error("expected different ID sets to produce different keys")
+	}
+	if a == confirmationKey("delete_project", 456, []int{1, 2, 3}) {
+		t.Error("expected different operations to produce different keys")
+	}
+	if a == confirmationKey("delete_time_entries", 789, []int{1, 2, 3}) {
+		t.Error("expected different workspaces to produce different keys")
+	}
+}
+
+func TestConfirmationStore(t *testing.T) {
+	t.Run("redeem once", func(t *testing.T) {
+		store := newConfirmationStore(time.Minute)
+		token, err := store.issue("key")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := store.redeem(token, "key"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := store.redeem(token, "key"); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken on reuse, got %v", err)
+		}
+	})
+
+	t.Run("mismatched key", func(t *testing.T) {
+		store := newConfirmationStore(time.Minute)
+		token, _ := store.issue("key")
+
+		if err := store.redeem(token, "other"); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+		if err := store.redeem(token, "key"); err != nil {
+			t.Errorf("expected token to survive a mismatched attempt, got %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		store := newConfirmationStore(time.Minute)
+		now := time.Now()
+		store.now = func() time.Time { return now }
+		token, _ := store.issue("key")
+
+		store.now = func() time.Time { return now.Add(2 * time.Minute) }
+		if err := store.redeem(token, "key"); !errors.Is(err, ErrTokenExpired) {
+			t.Errorf("expected ErrTokenExpired, got %v", err)
+		}
+	})
+}
+
+func TestHandleDeleteTimeEntries(t *testing.T) {
+	deleted := map[string]bool{}
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v9/me/time_entries/"):
+			writeJSON(w, http.StatusOK, testTimeEntry)
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v9/workspaces/456/time_entries/404":
+			writeError(w, http.StatusNotFound, `"not found"`)
+		case r.Method == http.MethodDelete:
+			deleted[r.URL.Path] = true
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	handler := handleDeleteTimeEntries(newConfirmationStore(time.Minute))
+	call := func(args map[string]interface{}) (*mcp.CallToolResult, error) {
+		return handler(context.Background(), client, mcp.CallToolRequest{
+			Params: testCallToolParams{Arguments: args},
+		})
+	}
+
+	preview, err := call(map[string]interface{}{
+		"workspace_id":   float64(456),
+		"time_entry_ids": []interface{}{float64(789), float64(404)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Fatal("preview must not delete anything")
+	}
+	token := extractToken(t, preview)
+
+	t.Run("token bound to IDs", func(t *testing.T) {
+		result, err := call(map[string]interface{}{
+			"workspace_id":       float64(456),
+			"time_entry_ids":     []interface{}{float64(789)},
+			"confirmation_token": token,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.IsError || len(deleted) != 0 {
+			t.Error("expected mismatched IDs to be rejected without deleting")
+		}
+	})
+
+	t.Run("confirmed delete reports per ID", func(t *testing.T) {
+		result, err := call(map[string]interface{}{
+			"workspace_id":       float64(456),
+			"time_entry_ids":     []interface{}{float64(404), float64(789)},
+			"confirmation_token": token,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		content := result.Content[0].(mcp.TextContent).Text
+		if !result.IsError {
+			t.Error("expected partial failure to be reported as error")
+		}
+		if !strings.Contains(content, "Deleted 1 of 2") || !strings.Contains(content, "ID 404: failed") {
+			t.Errorf("unexpected result: %s", content)
+		}
+		if !deleted["/api/v9/workspaces/456/time_entries/789"] {
+			t.Error("expected entry 789 to be deleted")
+		}
+	})
+
+	t.Run("token is single use", func(t *testing.T) {
+		result, _ := call(map[string]interface{}{
+			"workspace_id":       float64(456),
+			"time_entry_ids":     []interface{}{float64(789), float64(404)},
+			"confirmation_token": token,
+		})
+		if !result.IsError {
+			t.Error("expected reused token to be rejected")
+		}
+	})
+
+	t.Run("missing IDs", func(t *testing.T) {
+		if _, err := call(map[string]interface{}{"workspace_id": float64(456)}); err == nil {
+			t.Error("expected error for missing time_entry_ids")
+		}
+	})
+}
+
+func TestHandleDeleteProject(t *testing.T) {
+	deleteCalls := 0
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v9/workspaces/456/projects/111" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, testProject)
+		case http.MethodDelete:
+			deleteCalls++
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	handler := handleDeleteProject(newConfirmationStore(time.Minute))
+	args := map[string]interface{}{
+		"workspace_id": float64(456),
+		"project_id":   float64(111),
+	}
+
+	preview, err := handler(context.Background(), client, mcp.CallToolRequest{
+		Params: testCallToolParams{Arguments: args},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(preview.Content[0].(mcp.TextContent).Text, "Test Project") {
+		t.Error("expected project name in preview")
+	}
+	if deleteCalls != 0 {
+		t.Fatal("preview must not delete anything")
+	}
+
+	args["confirmation_token"] = extractToken(t, preview)
+	result, err := handler(context.Background(), client, mcp.CallToolRequest{
+		Params: testCallToolParams{Arguments: args},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError || deleteCalls != 1 {
+		t.Errorf("expected one delete, got %d (%v)", deleteCalls, result.Content)
+	}
+}
@@ -0,0 +1,488 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultMergeGap is the largest gap between entries merge_time_entries
+// absorbs unless told otherwise
+const defaultMergeGap = 5 * time.Minute
+
+// entryEdit applies a sequence of changes to time entries, remembering each
+// one so that the sequence can be rolled back if a later step fails
+type entryEdit struct {
+	client  *TogglClient
+	applied []Change
+}
+
+func (e *entryEdit) create(ctx context.Context, workspaceID int, entry TimeEntryRequest) (TimeEntry, error) {
+	created, err := e.client.CreateTimeEntry(ctx, workspaceID, entry)
+	if err != nil {
+		return created, fmt.Errorf("creating time entry: %w", err)
+	}
+	e.applied = append(e.applied, Change{
+		Operation: changeCreate, EntityType: entityTimeEntry, WorkspaceID: workspaceID, EntityID: created.ID,
+	})
+	return created, nil
+}
+
+func (e *entryEdit) update(ctx context.Context, before TimeEntry, fields map[string]interface{}) (TimeEntry, error) {
+	updated, err := e.client.UpdateTimeEntry(ctx, before.WorkspaceID, before.ID, fields)
+	if err != nil {
+		return updated, fmt.Errorf("updating time entry %d: %w", before.ID, err)
+	}
+	e.record(changeUpdate, before)
+	return updated, nil
+}
+
+func (e *entryEdit) delete(ctx context.Context, before TimeEntry) error {
+	if err := e.client.DeleteTimeEntry(ctx, before.WorkspaceID, before.ID); err != nil {
+		return fmt.Errorf("deleting time entry %d: %w", before.ID, err)
+	}
+	e.record(changeDelete, before)
+	return nil
+}
+
+func (e *entryEdit) record(operation string, before TimeEntry) {
+	data, _ := json.Marshal(before)
+	e.applied = append(e.applied, Change{
+		Operation: operation, EntityType: entityTimeEntry, WorkspaceID: before.WorkspaceID, EntityID: before.ID, Before: data,
+	})
+}
+
+// rollback reverts the applied changes, newest first, and describes the
+// outcome for a tool error
+func (e *entryEdit) rollback(ctx context.Context) string {
+	if len(e.applied) == 0 {
+		return "nothing was changed"
+	}
+
+	var failed []string
+	for i := len(e.applied) - 1; i >= 0; i-- {
+		if _, err := undo(ctx, e.client, e.applied[i]); err != nil {
+			failed = append(failed, fmt.Sprintf("%s of entry %d: %s", e.applied[i].Operation, e.applied[i].EntityID, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Sprintf("rolling back failed for %s; check list_recent_changes", strings.Join(failed, "; "))
+	}
+	return fmt.Sprintf("rolled back %d changes", len(e.applied))
+}
+
+// entryPiece is one part of a split entry. Empty fields are inherited from
+// the entry being split.
+type entryPiece struct {
+	start, stop time.Time
+	description string
+	project     string
+	tags        []string
+}
+
+// parseSplitTime reads a split point given as RFC 3339 or as HH:MM on the
+// day the entry starts, in loc
+func parseSplitTime(value string, entryStart time.Time, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (use HH:MM or RFC 3339)", value)
+	}
+	y, m, d := entryStart.In(loc).Date()
+	return time.Date(y, m, d, clock.Hour(), clock.Minute(), 0, 0, loc), nil
+}
+
+// splitBounds cuts the span from start to stop at the given times, or into
+// pieces of the given durations followed by the remainder
+func splitBounds(start, stop time.Time, at []time.Time, durations []time.Duration) ([][2]time.Time, error) {
+	if len(at) > 0 && len(durations) > 0 {
+		return nil, errors.New("give either at or durations_minutes, not both")
+	}
+
+	cuts := append([]time.Time(nil), at...)
+	next := start
+	for _, d := range durations {
+		if d <= 0 {
+			return nil, errors.New("durations must be positive")
+		}
+		next = next.Add(d)
+		cuts = append(cuts, next)
+	}
+	if len(cuts) == 0 {
+		return nil, errors.New("give a split time in at, or durations_minutes")
+	}
+	if len(durations) > 0 && cuts[len(cuts)-1].Equal(stop) {
+		cuts = cuts[:len(cuts)-1]
+	}
+
+	bounds := make([][2]time.Time, 0, len(cuts)+1)
+	prev := start
+	for _, cut := range cuts {
+		if !cut.After(prev) || !cut.Before(stop) {
+			return nil, fmt.Errorf("split time %s is not inside the entry (%s to %s)",
+				cut.Format(time.RFC3339), start.Format(time.RFC3339), stop.Format(time.RFC3339))
+		}
+		bounds = append(bounds, [2]time.Time{prev, cut})
+		prev = cut
+	}
+	if len(bounds) == 0 {
+		return nil, errors.New("the durations cover the whole entry; nothing to split")
+	}
+	return append(bounds, [2]time.Time{prev, stop}), nil
+}
+
+// parseSplitPieces reads the optional pieces argument: an object per piece,
+// in order, with description, project and tags, or null to keep the entry's
+func parseSplitPieces(raw interface{}, bounds [][2]time.Time) ([]entryPiece, error) {
+	pieces := make([]entryPiece, len(bounds))
+	for i, b := range bounds {
+		pieces[i].start, pieces[i].stop = b[0], b[1]
+	}
+	if raw == nil {
+		return pieces, nil
+	}
+
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, errors.New("pieces must be an array of objects")
+	}
+	if len(items) > len(pieces) {
+		return nil, fmt.Errorf("%d pieces described but the entry splits into %d", len(items), len(pieces))
+	}
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("piece %d must be an object or null", i+1)
+		}
+		tags, err := getOptionalStringList(obj, "tags")
+		if err != nil {
+			return nil, fmt.Errorf("piece %d: %w", i+1, err)
+		}
+		pieces[i].description = getOptionalString(obj, "description")
+		pieces[i].project = getOptionalString(obj, "project")
+		pieces[i].tags = tags
+	}
+	return pieces, nil
+}
+
+// pieceRequest builds the entry for piece, inheriting from original
+func pieceRequest(original TimeEntry, piece entryPiece, lookup *importLookup) (TimeEntryRequest, error) {
+	entry := TimeEntryRequest{
+		Description: original.Description,
+		Start:       piece.start,
+		Duration:    int(piece.stop.Sub(piece.start).Seconds()),
+		ProjectID:   original.ProjectID,
+		TaskID:      original.TaskID,
+		Tags:        append([]string(nil), original.Tags...),
+		Billable:    original.Billable,
+		CreatedWith: "toggl-mcp",
+	}
+	if piece.description != "" {
+		entry.Description = piece.description
+	}
+	if piece.tags != nil {
+		entry.Tags = piece.tags
+	}
+
+	var project string
+	if piece.project != "" {
+		entry.ProjectID, entry.TaskID, project = nil, nil, piece.project
+	}
+	var row ImportRow
+	lookup.resolve(&row, entry, project)
+	if row.Status != ImportReady {
+		return entry, errors.New(row.Error)
+	}
+	return *row.Entry, nil
+}
+
+// requestFields turns an entry request into an update payload
+func requestFields(entry TimeEntryRequest) map[string]interface{} {
+	tags := entry.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return map[string]interface{}{
+		"description": entry.Description,
+		"start":       entry.Start,
+		"stop":        entry.Start.Add(time.Duration(entry.Duration) * time.Second),
+		"duration":    entry.Duration,
+		"project_id":  entry.ProjectID,
+		"task_id":     entry.TaskID,
+		"tags":        tags,
+	}
+}
+
+// formatEditedEntries lists entries with their times, in loc
+func formatEditedEntries(entries []TimeEntry, loc *time.Location) string {
+	var b strings.Builder
+	for _, entry := range entries {
+		start := entry.Start.In(loc)
+		stop := entryEnd(entry, start).In(loc)
+		fmt.Fprintf(&b, "\n- %s %s-%s %s (ID: %d)", start.Format("2006-01-02"),
+			start.Format("15:04"), stop.Format("15:04"), entry.Description, entry.ID)
+	}
+	return b.String()
+}
+
+// editTools returns the tools that split and merge time entries
+func editTools(client *TogglClient) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"split_time_entry",
+				mcp.WithDescription("Split a stopped time entry at a time, or into pieces of the given lengths followed by the remainder. The first piece keeps the entry's ID; each piece can get its own description, project and tags. If a step fails, the changes already made are rolled back."),
+				mcp.WithNumber("entry_id", mcp.Required()),
+				mcp.WithString("at", mcp.Description("Time to split at: HH:MM on the entry's day, or RFC 3339")),
+				mcp.WithArray("durations_minutes",
+					mcp.Description("Lengths of the leading pieces, in minutes; the rest of the entry becomes the last piece"),
+					mcp.Items(map[string]interface{}{"type": "number"}),
+				),
+				mcp.WithArray("pieces",
+					mcp.Description("Fields for each piece, in order; omitted fields, and pieces given as null, keep the entry's values"),
+					mcp.Items(map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"description": map[string]interface{}{"type": "string"},
+							"project":     map[string]interface{}{"type": "string"},
+							"tags":        map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+						},
+					}),
+				),
+			),
+			handler: wrapHandler(client, handleSplitTimeEntry),
+		},
+		{
+			tool: mcp.NewTool(
+				"merge_time_entries",
+				mcp.WithDescription("Merge consecutive stopped time entries on the same project and task with the same billable flag into one spanning them all. The earliest entry is kept with the combined tags and the others are deleted. If a step fails, the changes already made are rolled back."),
+				mcp.WithArray("entry_ids", mcp.Required(), mcp.Description("Entries to merge, at least two"), mcp.Items(map[string]interface{}{"type": "number"})),
+				mcp.WithString("description", mcp.Description("Description of the merged entry (default: the earliest entry's)")),
+				mcp.WithNumber("max_gap_minutes", mcp.Description("Largest gap between consecutive entries to merge across; gaps become part of the merged entry (default 5)")),
+			),
+			handler: wrapHandler(client, handleMergeTimeEntries),
+		},
+	}
+}
+
+func handleSplitTimeEntry(
+	ctx context.Context,
+	client *TogglClient,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	entryID, err := getRequiredNumber(args, "entry_id")
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry_id: %w", err)
+	}
+
+	original, err := client.GetTimeEntry(ctx, entryID)
+	if err != nil {
+		return apiErrorResult("split time entries", err)
+	}
+	if isRunning(original) {
+		return mcp.NewToolResultError(fmt.Sprintf("Time entry %d is running; stop it before splitting", entryID)), nil
+	}
+
+	loc := client.timeZone()
+
+	var at []time.Time
+	if value := getOptionalString(args, "at"); value != "" {
+		t, err := parseSplitTime(value, original.Start, loc)
+		if err != nil {
+			return nil, err
+		}
+		at = append(at, t)
+	}
+	var durations []time.Duration
+	if raw, ok := args["durations_minutes"].([]interface{}); ok {
+		for _, v := range raw {
+			minutes, ok := v.(float64)
+			if !ok {
+				return nil, errors.New("durations_minutes must be an array of numbers")
+			}
+			durations = append(durations, time.Duration(minutes*float64(time.Minute)))
+		}
+	}
+
+	bounds, err := splitBounds(original.Start, entryEnd(original, time.Now()), at, durations)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Cannot split time entry %d: %s", entryID, err)), nil
+	}
+	pieces, err := parseSplitPieces(args["pieces"], bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup, err := client.newImportLookup(ctx, original.WorkspaceID)
+	if err != nil {
+		return apiErrorResult("split time entries", err)
+	}
+	requests := make([]TimeEntryRequest, len(pieces))
+	for i, piece := range pieces {
+		if requests[i], err = pieceRequest(original, piece, lookup); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Cannot split time entry %d: piece %d: %s", entryID, i+1, err)), nil
+		}
+	}
+
+	edit := &entryEdit{client: client}
+	results := make([]TimeEntry, 0, len(requests))
+	updated, err := edit.update(ctx, original, requestFields(requests[0]))
+	if err == nil {
+		results = append(results, updated)
+		for _, request := range requests[1:] {
+			var created TimeEntry
+			if created, err = edit.create(ctx, original.WorkspaceID, request); err != nil {
+				break
+			}
+			results = append(results, created)
+		}
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to split time entry %d: %s; %s", entryID, err, edit.rollback(ctx))), nil
+	}
+
+	if wantsJSON(ctx) {
+		return jsonResult(results)
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Split time entry %d into %d entries:%s",
+		entryID, len(results), formatEditedEntries(results, loc))), nil
+}
+
+func handleMergeTimeEntries(
+	ctx context.Context,
+	client *TogglClient,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	ids, err := getRequiredNumberList(args, "entry_ids")
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) < 2 {
+		return nil, errors.New("entry_ids needs at least two entries")
+	}
+	maxGap := defaultMergeGap
+	if n := getOptionalNumber(args, "max_gap_minutes"); n != nil {
+		maxGap = time.Duration(*n) * time.Minute
+	}
+
+	entries := make([]TimeEntry, 0, len(ids))
+	for _, id := range ids {
+		entry, err := client.GetTimeEntry(ctx, id)
+		if err != nil {
+			return apiErrorResult("merge time entries", err)
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Start.Before(entries[j].Start)
+	})
+
+	if err := checkMergeable(entries, maxGap); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Cannot merge: %s", err)), nil
+	}
+
+	first, last := entries[0], entries[len(entries)-1]
+	stop := entryEnd(last, time.Now())
+	for _, entry := range entries {
+		if end := entryEnd(entry, time.Now()); end.After(stop) {
+			stop = end
+		}
+	}
+	merged := TimeEntryRequest{
+		Description: first.Description,
+		Start:       first.Start,
+		Duration:    int(stop.Sub(first.Start).Seconds()),
+		ProjectID:   first.ProjectID,
+		TaskID:      first.TaskID,
+	}
+	if description := getOptionalString(args, "description"); description != "" {
+		merged.Description = description
+	}
+	for _, entry := range entries {
+		for _, tag := range entry.Tags {
+			if !containsString(merged.Tags, tag) {
+				merged.Tags = append(merged.Tags, tag)
+			}
+		}
+	}
+
+	edit := &entryEdit{client: client}
+	updated, err := edit.update(ctx, first, requestFields(merged))
+	if err == nil {
+		for _, entry := range entries[1:] {
+			if err = edit.delete(ctx, entry); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to merge time entries: %s; %s", err, edit.rollback(ctx))), nil
+	}
+
+	if wantsJSON(ctx) {
+		return jsonResult(updated)
+	}
+	loc := client.timeZone()
+	return mcp.NewToolResultText(fmt.Sprintf("Merged %d time entries into entry %d:%s",
+		len(entries), updated.ID, formatEditedEntries([]TimeEntry{updated}, loc))), nil
+}
+
+// checkMergeable verifies that sorted entries are stopped, share a workspace,
+// project, task and billable flag, and follow each other with gaps of at
+// most maxGap
+func checkMergeable(sorted []TimeEntry, maxGap time.Duration) error {
+	first := sorted[0]
+	end := first.Start
+	for i, entry := range sorted {
+		switch {
+		case isRunning(entry):
+			return fmt.Errorf("time entry %d is running", entry.ID)
+		case entry.WorkspaceID != first.WorkspaceID:
+			return fmt.Errorf("time entry %d is in another workspace", entry.ID)
+		case !sameID(entry.ProjectID, first.ProjectID):
+			return fmt.Errorf("time entry %d is on a different project than entry %d", entry.ID, first.ID)
+		case !sameID(entry.TaskID, first.TaskID):
+			return fmt.Errorf("time entry %d is on a different task than entry %d", entry.ID, first.ID)
+		case entry.Billable != first.Billable:
+			return fmt.Errorf("time entry %d is %s, unlike entry %d", entry.ID, billableWord(entry.Billable), first.ID)
+		case i > 0 && entry.ID == sorted[i-1].ID:
+			return fmt.Errorf("time entry %d is listed twice", entry.ID)
+		case i > 0 && entry.Start.Sub(end) > maxGap:
+			return fmt.Errorf("time entries %d and %d are %s apart", sorted[i-1].ID, entry.ID,
+				entry.Start.Sub(end).Round(time.Second))
+		}
+		if stop := entryEnd(entry, entry.Start); stop.After(end) {
+			end = stop
+		}
+	}
+	return nil
+}
+
+// sameID reports whether two optional IDs, such as project IDs, are equal
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// billableWord describes an entry's billable flag
+func billableWord(billable bool) string {
+	if billable {
+		return "billable"
+	}
+	return "non-billable"
+}
@@ -0,0 +1,291 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSplitBounds(t *testing.T) {
+	start := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	stop := start.Add(2 * time.Hour)
+	at := func(clock string) time.Time {
+		t, _ := time.Parse(time.RFC3339, "2024-03-15T"+clock+":00Z")
+		return t
+	}
+
+	tests := []struct {
+		name      string
+		at        []time.Time
+		durations []time.Duration
+		want      string
+		wantErr   string
+	}{
+		{name: "at a time", at: []time.Time{at("10:15")}, want: "09:00-10:15 10:15-11:00"},
+		{name: "durations with remainder", durations: []time.Duration{30 * time.Minute, time.Hour}, want: "09:00-09:30 09:30-10:30 10:30-11:00"},
+		{name: "durations covering the entry", durations: []time.Duration{time.Hour, time.Hour}, want: "09:00-10:00 10:00-11:00"},
+		{name: "outside the entry", at: []time.Time{at("11:30")}, wantErr: "not inside"},
+		{name: "durations too long", durations: []time.Duration{3 * time.Hour}, wantErr: "not inside"},
+		{name: "whole entry", durations: []time.Duration{2 * time.Hour}, wantErr: "nothing to split"},
+		{name: "both", at: []time.Time{at("10:00")}, durations: []time.Duration{time.Hour}, wantErr: "not both"},
+		{name: "neither", wantErr: "give a split time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bounds, err := splitBounds(start, stop, tt.at, tt.durations)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, b := range bounds {
+				got = append(got, b[0].Format("15:04")+"-"+b[1].Format("15:04"))
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMergeable(t *testing.T) {
+	first := lintEntry(1, "Coding", "09:00", "10:00", true)
+	next := lintEntry(2, "Coding", "10:03", "11:00", true)
+	late := lintEntry(3, "Coding", "12:00", "13:00", true)
+	other := lintEntry(4, "Coding", "10:00", "11:00", false)
+	running := lintEntry(5, "Coding", "10:00", "11:00", true)
+	running.Duration = -1
+	billable := lintEntry(6, "Coding", "10:00", "11:00", true)
+	billable.Billable = true
+	tasked := lintEntry(7, "Coding", "10:00", "11:00", true)
+	tasked.TaskID = intPtr(12)
+
+	tests := []struct {
+		name    string
+		entries []TimeEntry
+		wantErr string
+	}{
+		{name: "consecutive", entries: []TimeEntry{first, next}},
+		{name: "gap too long", entries: []TimeEntry{first, next, late}, wantErr: "1h0m0s apart"},
+		{name: "different project", entries: []TimeEntry{first, other}, wantErr: "different project"},
+		{name: "running", entries: []TimeEntry{first, running}, wantErr: "running"},
+		{name: "different task", entries: []TimeEntry{first, tasked}, wantErr: "different task"},
+		{name: "mixed billable", entries: []TimeEntry{first, billable}, wantErr: "time entry 6 is billable, unlike entry 1"},
+		{name: "listed twice", entries: []TimeEntry{first, first}, wantErr: "listed twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMergeable(tt.entries, defaultMergeGap)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// editServer serves the entries in a map, applying updates, creates and
+// deletes to it and recording each request. Requests whose method and path
+// match fail are answered with an error.
+type editServer struct {
+	mu       sync.Mutex
+	entries  map[int]TimeEntry
+	nextID   int
+	requests []string
+	fail     string
+}
+
+func newEditServer(entries ...TimeEntry) *editServer {
+	s := &editServer{entries: make(map[int]TimeEntry), nextID: 1000}
+	for _, entry := range entries {
+		s.entries[entry.ID] = entry
+	}
+	return s
+}
+
+func (s *editServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	request := r.Method + " " + strings.TrimPrefix(r.URL.Path, "/api/v9")
+	if r.Method != http.MethodGet {
+		s.requests = append(s.requests, request)
+	}
+	if request == s.fail {
+		writeError(w, http.StatusBadRequest, "step failed")
+		return
+	}
+
+	var id int
+	switch {
+	case request == "GET /workspaces/456/projects":
+		writeJSON(w, http.StatusOK, []Project{testProject, {BaseEntity: BaseEntity{ID: 222}, Name: "Other"}})
+	case request == "GET /workspaces/456/tags":
+		writeJSON(w, http.StatusOK, []Tag{})
+	case request == "POST /workspaces/456/time_entries":
+		var req TimeEntryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.nextID++
+		stop := req.Start.Add(time.Duration(req.Duration) * time.Second)
+		entry := TimeEntry{
+			BaseEntity:  BaseEntity{ID: s.nextID, WorkspaceID: 456},
+			Description: req.Description, ProjectID: req.ProjectID, Tags: req.Tags,
+			Start: req.Start, Stop: &stop, Duration: req.Duration,
+		}
+		s.entries[entry.ID] = entry
+		writeJSON(w, http.StatusOK, entry)
+	case fmt.Sprint(sscan(r.URL.Path, "/api/v9/me/time_entries/%d", &id)) == "1":
+		writeJSON(w, http.StatusOK, s.entries[id])
+	case fmt.Sprint(sscan(r.URL.Path, "/api/v9/workspaces/456/time_entries/%d", &id)) == "1":
+		if r.Method == http.MethodDelete {
+			delete(s.entries, id)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		var fields struct {
+			Description string    `json:"description"`
+			Start       time.Time `json:"start"`
+			Stop        time.Time `json:"stop"`
+			Duration    int       `json:"duration"`
+			ProjectID   *int      `json:"project_id"`
+			Tags        []string  `json:"tags"`
+		}
+		json.NewDecoder(r.Body).Decode(&fields)
+		entry := s.entries[id]
+		entry.Description, entry.Start, entry.Stop = fields.Description, fields.Start, &fields.Stop
+		entry.Duration, entry.ProjectID, entry.Tags = fields.Duration, fields.ProjectID, fields.Tags
+		s.entries[id] = entry
+		writeJSON(w, http.StatusOK, entry)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func sscan(s, format string, id *int) int {
+	n, _ := fmt.Sscanf(s, format, id)
+	return n
+}
+
+// summary describes the entries held, in ID order
+func (s *editServer) summary() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var lines []string
+	for id := 0; id <= s.nextID; id++ {
+		if entry, ok := s.entries[id]; ok {
+			project := 0
+			if entry.ProjectID != nil {
+				project = *entry.ProjectID
+			}
+			lines = append(lines, fmt.Sprintf("%d %s-%s %s p%d %v", id, entry.Start.Format("15:04"),
+				entry.Stop.Format("15:04"), entry.Description, project, entry.Tags))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestHandleSplitTimeEntry(t *testing.T) {
+	call := func(server *editServer, args map[string]interface{}) string {
+		t.Helper()
+		_, client := testServer(t, server.handle)
+		client.location = time.UTC
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		result, err := handleSplitTimeEntry(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+	entry := lintEntry(789, "Workshop", "09:00", "11:00", true)
+	entry.Tags = []string{"client"}
+
+	server := newEditServer(entry)
+	text := call(server, map[string]interface{}{
+		"entry_id": float64(789),
+		"at":       "10:15",
+		"pieces":   []interface{}{nil, map[string]interface{}{"description": "Follow-up", "project": "other", "tags": []interface{}{}}},
+	})
+	if !strings.Contains(text, "Split time entry 789 into 2 entries") {
+		t.Errorf("unexpected result: %s", text)
+	}
+	want := "789 09:00-10:15 Workshop p111 [client]\n1001 10:15-11:00 Follow-up p222 []"
+	if got := server.summary(); got != want {
+		t.Errorf("unexpected entries:\n%s\nwant:\n%s", got, want)
+	}
+
+	failing := newEditServer(entry)
+	failing.fail = "POST /workspaces/456/time_entries"
+	text = call(failing, map[string]interface{}{"entry_id": float64(789), "durations_minutes": []interface{}{float64(30), float64(30)}})
+	if !strings.Contains(text, "Failed to split time entry 789") || !strings.Contains(text, "rolled back 1 changes") {
+		t.Errorf("unexpected result: %s", text)
+	}
+	if got := failing.summary(); got != "789 09:00-11:00 Workshop p111 [client]" {
+		t.Errorf("expected the entry to be restored, got:\n%s", got)
+	}
+
+	running := entry
+	running.Duration = -1
+	if text := call(newEditServer(running), map[string]interface{}{"entry_id": float64(789), "at": "10:00"}); !strings.Contains(text, "stop it before splitting") {
+		t.Errorf("unexpected result: %s", text)
+	}
+}
+
+func TestHandleMergeTimeEntries(t *testing.T) {
+	call := func(server *editServer, args map[string]interface{}) string {
+		t.Helper()
+		_, client := testServer(t, server.handle)
+		client.location = time.UTC
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		result, err := handleMergeTimeEntries(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+	first := lintEntry(1, "Coding", "09:00", "10:00", true)
+	first.Tags = []string{"go"}
+	second := lintEntry(2, "Coding more", "10:02", "10:30", true)
+	third := lintEntry(3, "Coding", "10:30", "11:00", true)
+	third.Tags = []string{"review", "go"}
+
+	server := newEditServer(first, second, third)
+	text := call(server, map[string]interface{}{"entry_ids": []interface{}{float64(3), float64(1), float64(2)}})
+	if !strings.Contains(text, "Merged 3 time entries into entry 1") {
+		t.Errorf("unexpected result: %s", text)
+	}
+	if got := server.summary(); got != "1 09:00-11:00 Coding p111 [go review]" {
+		t.Errorf("unexpected entries:\n%s", got)
+	}
+
+	failing := newEditServer(first, second, third)
+	failing.fail = "DELETE /workspaces/456/time_entries/3"
+	text = call(failing, map[string]interface{}{"entry_ids": []interface{}{float64(1), float64(2), float64(3)}})
+	if !strings.Contains(text, "rolled back 2 changes") {
+		t.Errorf("unexpected result: %s", text)
+	}
+	want := "1 09:00-10:00 Coding p111 [go]\n3 10:30-11:00 Coding p111 [review go]\n1001 10:02-10:30 Coding more p111 []"
+	if got := failing.summary(); got != want {
+		t.Errorf("expected the entries to be restored, got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,363 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Export formats
+const (
+	ExportCSV   = "csv"
+	ExportJSONL = "jsonl"
+)
+
+// exportColumns lists the columns an export can contain, in their default order
+var exportColumns = []string{
+	"date", "start", "stop", "duration_hours", "description", "project", "client", "tags", "billable",
+}
+
+// ExportOptions selects the format and columns of an export
+type ExportOptions struct {
+	Format  string
+	Columns []string
+}
+
+// withDefaults fills in the default format and columns and rejects unknown ones
+func (o ExportOptions) withDefaults() (ExportOptions, error) {
+	switch o.Format {
+	case "":
+		o.Format = ExportCSV
+	case ExportCSV, ExportJSONL:
+	default:
+		return o, fmt.Errorf("format must be %s or %s, got %q", ExportCSV, ExportJSONL, o.Format)
+	}
+
+	if len(o.Columns) == 0 {
+		o.Columns = exportColumns
+	}
+	for _, column := range o.Columns {
+		if !containsString(exportColumns, column) {
+			return o, fmt.Errorf("unknown column %q (available: %s)", column, strings.Join(exportColumns, ", "))
+		}
+	}
+
+	return o, nil
+}
+
+// mimeType is the media type of the export format
+func (o ExportOptions) mimeType() string {
+	if o.Format == ExportJSONL {
+		return "application/jsonl"
+	}
+	return "text/csv"
+}
+
+// exportNames holds the names time entry IDs are resolved to
+type exportNames struct {
+	projects map[int]Project
+	clients  map[int]string
+}
+
+// exportTimeEntries fetches the entries between start and end and writes
+// them to w, oldest first. It returns the number of entries written.
+func (c *TogglClient) exportTimeEntries(
+	ctx context.Context,
+	w io.Writer,
+	start, end time.Time,
+	opts ExportOptions,
+	now time.Time,
+) (int, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := c.GetTimeEntries(ctx, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("getting time entries: %w", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Start.Before(entries[j].Start)
+	})
+
+	withClients := containsString(opts.Columns, "client")
+	var names exportNames
+	if withClients || containsString(opts.Columns, "project") {
+		if names, err = c.exportNames(ctx, entries, withClients); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := writeExport(w, entries, names, opts, c.timeZone(), now); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// exportICS fetches the entries between start and end and writes them to w
+// as an iCalendar file, oldest first. It returns the number of entries written.
+func (c *TogglClient) exportICS(ctx context.Context, w io.Writer, start, end, now time.Time) (int, error) {
+	entries, err := c.GetTimeEntries(ctx, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("getting time entries: %w", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Start.Before(entries[j].Start)
+	})
+
+	names, err := c.exportNames(ctx, entries, true)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := writeICS(w, entries, names, c.timeZone(), now); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// exportNames looks up the projects of entries and, if withClients is set,
+// the clients of those projects
+func (c *TogglClient) exportNames(ctx context.Context, entries []TimeEntry, withClients bool) (exportNames, error) {
+	var names exportNames
+	var err error
+	if names.projects, err = c.entryProjects(ctx, entries); err != nil {
+		return names, fmt.Errorf("getting project names: %w", err)
+	}
+	if withClients {
+		if names.clients, err = c.projectClientNames(ctx, names.projects); err != nil {
+			return names, fmt.Errorf("getting client names: %w", err)
+		}
+	}
+	return names, nil
+}
+
+// writeExport renders entries as CSV with a header row, or as one JSON
+// object per line
+func writeExport(w io.Writer, entries []TimeEntry, names exportNames, opts ExportOptions, loc *time.Location, now time.Time) error {
+	if opts.Format == ExportJSONL {
+		enc := json.NewEncoder(w)
+		for _, entry := range entries {
+			row := make(map[string]interface{}, len(opts.Columns))
+			for _, column := range opts.Columns {
+				row[column] = exportValue(column, entry, names, loc, now)
+			}
+			if err := enc.Encode(row); err != nil {
+				return fmt.Errorf("writing export: %w", err)
+			}
+		}
+		return nil
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(opts.Columns); err != nil {
+		return fmt.Errorf("writing export: %w", err)
+	}
+	for _, entry := range entries {
+		record := make([]string, len(opts.Columns))
+		for i, column := range opts.Columns {
+			record[i] = csvValue(exportValue(column, entry, names, loc, now))
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("writing export: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing export: %w", err)
+	}
+	return nil
+}
+
+// exportValue is the value of one column for entry. Running entries have no
+// stop time and count up to now.
+func exportValue(column string, entry TimeEntry, names exportNames, loc *time.Location, now time.Time) interface{} {
+	switch column {
+	case "date":
+		return entry.Start.In(loc).Format("2006-01-02")
+	case "start":
+		return entry.Start.In(loc).Format(time.RFC3339)
+	case "stop":
+		if entry.Stop == nil || entry.Duration < 0 {
+			return nil
+		}
+		return entry.Stop.In(loc).Format(time.RFC3339)
+	case "duration_hours":
+		seconds := entry.Duration
+		if seconds < 0 {
+			seconds = int(now.Sub(entry.Start).Seconds())
+		}
+		return math.Round(float64(seconds)/36) / 100
+	case "description":
+		return entry.Description
+	case "project":
+		if entry.ProjectID == nil {
+			return nil
+		}
+		return names.projects[*entry.ProjectID].Name
+	case "client":
+		if entry.ProjectID == nil {
+			return nil
+		}
+		project := names.projects[*entry.ProjectID]
+		if project.ClientID == nil {
+			return nil
+		}
+		return names.clients[*project.ClientID]
+	case "tags":
+		if entry.Tags == nil {
+			return []string{}
+		}
+		return entry.Tags
+	case "billable":
+		return entry.Billable
+	default:
+		return nil
+	}
+}
+
+// csvFormulaPrefixes start cells that spreadsheets evaluate as formulas
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvText guards text against being run as a spreadsheet formula by quoting
+// it with a leading "'"; importCell removes the quote again
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune(csvFormulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// csvValue formats a column value for a CSV cell; tags are joined with ";"
+// and text that a spreadsheet would read as a formula is quoted
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return csvText(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		return csvText(strings.Join(v, ";"))
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// exportTools returns the export tool, which may write into allowedDirs
+func exportTools(client *TogglClient, allowedDirs []string) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"export_time_entries",
+				mcp.WithDescription("Export time entries in a date range as CSV or JSON Lines, with project and client names resolved. Returns the export as an embedded resource, or writes it to path if that lies in an allowed directory."),
+				mcp.WithString("start_date", mcp.Required(), mcp.Description("First day, YYYY-MM-DD")),
+				mcp.WithString("end_date", mcp.Required(), mcp.Description("Day after the last day, YYYY-MM-DD (exclusive)")),
+				mcp.WithString("format", mcp.Enum(ExportCSV, ExportJSONL), mcp.Description("Defaults to csv")),
+				mcp.WithArray("columns",
+					mcp.Description("Columns to include, in order; defaults to all: "+strings.Join(exportColumns, ", ")),
+					mcp.Items(map[string]interface{}{"type": "string", "enum": exportColumns}),
+				),
+				mcp.WithString("path", mcp.Description("File to write the export to instead of returning it; must be inside an allowed directory")),
+			),
+			handler: wrapHandler(client, handleExportTimeEntries(allowedDirs)),
+		},
+	}
+}
+
+func handleExportTimeEntries(allowedDirs []string) func(
+	context.Context,
+	*TogglClient,
+	mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start, err := getRequiredDate(req.GetArguments(), "start_date")
+		if err != nil {
+			return nil, err
+		}
+		end, err := getRequiredDate(req.GetArguments(), "end_date")
+		if err != nil {
+			return nil, err
+		}
+
+		columns, err := getOptionalStringList(req.GetArguments(), "columns")
+		if err != nil {
+			return nil, err
+		}
+		opts, err := ExportOptions{
+			Format:  getOptionalString(req.GetArguments(), "format"),
+			Columns: columns,
+		}.withDefaults()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var path string
+		if p := getOptionalString(req.GetArguments(), "path"); p != "" {
+			if path, err = allowedPath(allowedDirs, p); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Cannot write export: %s", err)), nil
+			}
+		}
+
+		var buf bytes.Buffer
+		count, err := client.exportTimeEntries(ctx, &buf, start, end, opts, time.Now())
+		if err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to export time entries: %s", apiErr.Error())), nil
+			}
+			return nil, fmt.Errorf("exporting time entries: %w", err)
+		}
+
+		return deliverExport(buf.Bytes(), path, fmt.Sprintf("%d time entries", count), start, end,
+			"time-entries", opts.Format, opts.mimeType()), nil
+	}
+}
+
+// deliverExport writes data to path if one was given, or else returns it as
+// an embedded resource named after the exported range. what describes the
+// contents, e.g. "3 time entries".
+func deliverExport(data []byte, path, what string, start, end time.Time, name, ext, mimeType string) *mcp.CallToolResult {
+	if path != "" {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Cannot write export: %s", err))
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Exported %s to %s", what, path))
+	}
+
+	return mcp.NewToolResultResource(
+		fmt.Sprintf("Exported %s from %s to %s", what, start.Format("2006-01-02"), end.Format("2006-01-02")),
+		mcp.TextResourceContents{
+			URI: fmt.Sprintf("toggl://exports/%s-%s-%s.%s",
+				name, start.Format("2006-01-02"), end.Format("2006-01-02"), ext),
+			MIMEType: mimeType,
+			Text:     string(data),
+		},
+	)
+}
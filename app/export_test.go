@@ -0,0 +1,216 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// exportHandler serves two entries, one of them running, with a project
+// that belongs to a client
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	stop := start.Add(90 * time.Minute)
+
+	switch r.URL.Path {
+	case "/api/v9/me/time_entries":
+		writeJSON(w, http.StatusOK, []TimeEntry{
+			{
+				BaseEntity:  BaseEntity{ID: 2, WorkspaceID: 456},
+				Description: "Standup, daily",
+				Start:       start.Add(2 * time.Hour),
+				Duration:    -1,
+			},
+			{
+				BaseEntity:  BaseEntity{ID: 1, WorkspaceID: 456},
+				Description: "Review",
+				ProjectID:   intPtr(111),
+				Start:       start,
+				Stop:        &stop,
+				Duration:    5400,
+				Tags:        []string{"code", "review"},
+				Billable:    true,
+			},
+		})
+	case "/api/v9/workspaces/456/projects":
+		project := testProject
+		project.ClientID = intPtr(222)
+		writeArchivedProjects(w, r, project)
+	case "/api/v9/workspaces/456/clients":
+		writeJSON(w, http.StatusOK, []Client{{BaseEntity: BaseEntity{ID: 222, WorkspaceID: 456}, Name: "Acme"}})
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func TestExportTimeEntries(t *testing.T) {
+	now := time.Date(2024, 3, 15, 11, 30, 0, 0, time.UTC)
+	start := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name    string
+		opts    ExportOptions
+		want    string
+		wantErr string
+	}{
+		{
+			name: "csv with all columns",
+			opts: ExportOptions{},
+			want: "date,start,stop,duration_hours,description,project,client,tags,billable\n" +
+				"2024-03-15,2024-03-15T09:00:00Z,2024-03-15T10:30:00Z,1.50,Review,Test Project,Acme,code;review,true\n" +
+				"2024-03-15,2024-03-15T11:00:00Z,,0.50,\"Standup, daily\",,,,false\n",
+		},
+		{
+			name: "jsonl with selected columns",
+			opts: ExportOptions{Format: ExportJSONL, Columns: []string{"description", "duration_hours", "tags"}},
+			want: `{"description":"Review","duration_hours":1.5,"tags":["code","review"]}` + "\n" +
+				`{"description":"Standup, daily","duration_hours":0.5,"tags":[]}` + "\n",
+		},
+		{
+			name:    "unknown column",
+			opts:    ExportOptions{Columns: []string{"rate"}},
+			wantErr: `unknown column "rate"`,
+		},
+		{
+			name:    "unknown format",
+			opts:    ExportOptions{Format: "xlsx"},
+			wantErr: "format must be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := testServer(t, exportHandler)
+			client.location = time.UTC
+
+			var buf bytes.Buffer
+			count, err := client.exportTimeEntries(context.Background(), &buf, start, end, tt.opts, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("export failed: %v", err)
+			}
+
+			if count != 2 {
+				t.Errorf("expected 2 entries, got %d", count)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("unexpected export:\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1 call", "'+1 call"},
+		{"-review", "'-review"},
+		{"@sum(A1)", "'@sum(A1)"},
+		{"\tindented", "'\tindented"},
+		{"Review = done", "Review = done"},
+		{[]string{"-x", "y"}, "'-x;y"},
+		{-1.5, "-1.50"},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := csvValue(tt.value); got != tt.want {
+			t.Errorf("csvValue(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestHandleExportTimeEntries(t *testing.T) {
+	dir := t.TempDir()
+	_, client := testServer(t, exportHandler)
+
+	t.Run("embedded resource", func(t *testing.T) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{
+			"start_date": "2024-03-15",
+			"end_date":   "2024-03-16",
+			"format":     "jsonl",
+		}
+
+		result, err := handleExportTimeEntries(nil)(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.Content) != 2 {
+			t.Fatalf("expected text and resource content, got %+v", result.Content)
+		}
+
+		resource, ok := result.Content[1].(mcp.EmbeddedResource)
+		if !ok {
+			t.Fatalf("expected embedded resource, got %T", result.Content[1])
+		}
+		contents := resource.Resource.(mcp.TextResourceContents)
+		if contents.MIMEType != "application/jsonl" || contents.URI != "toggl://exports/time-entries-2024-03-15-2024-03-16.jsonl" {
+			t.Errorf("unexpected resource: %+v", contents)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(contents.Text), "\n") {
+			if !json.Valid([]byte(line)) {
+				t.Errorf("invalid JSON line: %s", line)
+			}
+		}
+	})
+
+	t.Run("write to allowed path", func(t *testing.T) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{
+			"start_date": "2024-03-15",
+			"end_date":   "2024-03-16",
+			"path":       filepath.Join(dir, "march.csv"),
+		}
+
+		result, err := handleExportTimeEntries([]string{dir})(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.IsError {
+			t.Fatalf("unexpected tool error: %s", result.Content[0].(mcp.TextContent).Text)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "march.csv"))
+		if err != nil {
+			t.Fatalf("reading export: %v", err)
+		}
+		if !strings.HasPrefix(string(data), "date,start,stop") {
+			t.Errorf("unexpected file contents: %s", data)
+		}
+	})
+
+	t.Run("path outside allowed directories", func(t *testing.T) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{
+			"start_date": "2024-03-15",
+			"end_date":   "2024-03-16",
+			"path":       filepath.Join(dir, "..", "escape.csv"),
+		}
+
+		result, err := handleExportTimeEntries([]string{dir})(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "outside the allowed directories") {
+			t.Errorf("expected path error, got %q", result.Content[0].(mcp.TextContent).Text)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrPathNotAllowed is returned for file paths outside the allowed directories
+var ErrPathNotAllowed = errors.New("path is outside the allowed directories")
+
+// allowedPath resolves path and checks that it lies inside one of dirs.
+// Symbolic links are resolved first, so a link inside an allowed directory
+// cannot point outside it. The file itself need not exist yet.
+func allowedPath(dirs []string, path string) (string, error) {
+	if len(dirs) == 0 {
+		return "", errors.New("file access is disabled: no allowed directories are configured")
+	}
+	if path == "" {
+		return "", errors.New("path is empty")
+	}
+
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, dir := range dirs {
+		root, err := resolvePath(dir)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, resolved)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return resolved, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrPathNotAllowed, path)
+}
+
+// resolvePath makes path absolute and resolves symbolic links in it,
+// tolerating a final element that does not exist yet. A final element that
+// is a link to a missing file is refused, since writing to it would create
+// the file wherever the link points.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", path, err)
+	}
+
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("resolving %s: %w", path, err)
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", path, err)
+	}
+	if info, err := os.Lstat(abs); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return "", fmt.Errorf("resolving %s: symbolic link to a missing file", path)
+	}
+	return filepath.Join(dir, filepath.Base(abs)), nil
+}
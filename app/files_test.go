@@ -0,0 +1,54 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAllowedPath(t *testing.T) {
+	root := t.TempDir()
+	allowed := filepath.Join(root, "exports")
+	outside := filepath.Join(root, "exports-private")
+	for _, dir := range []string{allowed, outside} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatalf("creating %s: %v", dir, err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(allowed, "link")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "new.csv"), filepath.Join(allowed, "dangling.csv")); err != nil {
+		t.Fatalf("creating dangling link: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		dirs       []string
+		path       string
+		wantErr    bool
+		notAllowed bool
+	}{
+		{name: "new file in allowed dir", dirs: []string{allowed}, path: filepath.Join(allowed, "a.csv")},
+		{name: "allowed dir itself", dirs: []string{allowed}, path: allowed},
+		{name: "dot-dot escape", dirs: []string{allowed}, path: filepath.Join(allowed, "..", "exports-private", "a.csv"), wantErr: true, notAllowed: true},
+		{name: "symlink escape", dirs: []string{allowed}, path: filepath.Join(allowed, "link", "a.csv"), wantErr: true, notAllowed: true},
+		{name: "dangling symlink", dirs: []string{allowed}, path: filepath.Join(allowed, "dangling.csv"), wantErr: true},
+		{name: "sibling with common prefix", dirs: []string{allowed}, path: filepath.Join(outside, "a.csv"), wantErr: true, notAllowed: true},
+		{name: "missing parent directory", dirs: []string{allowed}, path: filepath.Join(allowed, "new", "a.csv"), wantErr: true},
+		{name: "no allowed dirs", path: filepath.Join(allowed, "a.csv"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := allowedPath(tt.dirs, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("allowedPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.notAllowed && !errors.Is(err, ErrPathNotAllowed) {
+				t.Errorf("expected ErrPathNotAllowed, got %v", err)
+			}
+		})
+	}
+}
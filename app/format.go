@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// formatTimeEntryLine formats a time entry as a list item, with its
+// rounded duration when rounding changes it
+func formatTimeEntryLine(entry TimeEntry, rounding Rounding) string {
+	projectInfo := ""
+	if entry.ProjectID != nil {
+		projectInfo = fmt.Sprintf(" (Project ID: %d)", *entry.ProjectID)
+	}
+	return fmt.Sprintf("- %s (ID: %d)%s %s",
+		entry.Description, entry.ID, projectInfo, formatDuration(entry.Duration, rounding))
+}
+
+// formatProjectLine formats a project as a list item
+func formatProjectLine(project Project) string {
+	status := "inactive"
+	if project.Active {
+		status = "active"
+	}
+	return fmt.Sprintf("- %s (ID: %d, %s)", project.Name, project.ID, status)
+}
+
+// formatCurrentEntry describes the running entry and how long it has been running
+func formatCurrentEntry(entry TimeEntry, now time.Time) string {
+	duration := now.Sub(entry.Start).Round(time.Second)
+	return fmt.Sprintf("Current time entry: %s (ID: %d, Running for: %s)",
+		entry.Description, entry.ID, duration)
+}
+
+// ReportRow is the time tracked on one project in a report
+type ReportRow struct {
+	ProjectID *int   `json:"project_id,omitempty"`
+	Project   string `json:"project"`
+	Seconds   int    `json:"seconds"`
+	// RoundedSeconds totals the rounded durations of the entries
+	RoundedSeconds int `json:"rounded_seconds,omitempty"`
+	Entries        int `json:"entries"`
+}
+
+// Report summarizes tracked time per project over a date range
+type Report struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	TotalSeconds int    `json:"total_seconds"`
+	// Rounding describes the rounding applied, if any
+	Rounding            string      `json:"rounding,omitempty"`
+	TotalRoundedSeconds int         `json:"total_rounded_seconds,omitempty"`
+	Rows                []ReportRow `json:"rows"`
+}
+
+// noProjectName labels time tracked without a project
+const noProjectName = "(no project)"
+
+// buildReport totals entries per project, longest first. Running entries
+// count up to now. When rounding is enabled, each entry is also rounded
+// before the rounded totals are added up.
+func buildReport(entries []TimeEntry, projectNames map[int]string, rounding Rounding, now time.Time) Report {
+	rows := make(map[int]*ReportRow)
+	var report Report
+	if rounding.Enabled() {
+		report.Rounding = rounding.String()
+	}
+
+	for _, entry := range entries {
+		seconds := entry.Duration
+		if seconds < 0 {
+			seconds = int(now.Sub(entry.Start).Seconds())
+		}
+
+		key := 0
+		if entry.ProjectID != nil {
+			key = *entry.ProjectID
+		}
+
+		row, ok := rows[key]
+		if !ok {
+			row = &ReportRow{Project: noProjectName}
+			if entry.ProjectID != nil {
+				row.ProjectID = entry.ProjectID
+				row.Project = projectNames[key]
+				if row.Project == "" {
+					row.Project = fmt.Sprintf("Project %d", key)
+				}
+			}
+			rows[key] = row
+		}
+
+		row.Seconds += seconds
+		row.Entries++
+		report.TotalSeconds += seconds
+		if rounding.Enabled() {
+			rounded := rounding.RoundSeconds(seconds)
+			row.RoundedSeconds += rounded
+			report.TotalRoundedSeconds += rounded
+		}
+	}
+
+	report.Rows = make([]ReportRow, 0, len(rows))
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Seconds != report.Rows[j].Seconds {
+			return report.Rows[i].Seconds > report.Rows[j].Seconds
+		}
+		return report.Rows[i].Project < report.Rows[j].Project
+	})
+
+	return report
+}
+
+// formatReport renders a report as a plain-text table
+func formatReport(report Report) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Report %s to %s:\n", report.From, report.To))
+
+	width := len("Total")
+	for _, row := range report.Rows {
+		width = max(width, len(row.Project))
+	}
+
+	if report.Rounding != "" {
+		result.WriteString(fmt.Sprintf("%-*s  %8s  %8s  (rounding %s)\n", width, "", "Tracked", "Rounded", report.Rounding))
+		for _, row := range report.Rows {
+			result.WriteString(fmt.Sprintf("%-*s  %8s  %8s  (%d entries)\n",
+				width, row.Project, formatHours(row.Seconds), formatHours(row.RoundedSeconds), row.Entries))
+		}
+		result.WriteString(fmt.Sprintf("%-*s  %8s  %8s\n", width, "Total",
+			formatHours(report.TotalSeconds), formatHours(report.TotalRoundedSeconds)))
+		return result.String()
+	}
+
+	for _, row := range report.Rows {
+		result.WriteString(fmt.Sprintf("%-*s  %8s  (%d entries)\n",
+			width, row.Project, formatHours(row.Seconds), row.Entries))
+	}
+	result.WriteString(fmt.Sprintf("%-*s  %8s\n", width, "Total", formatHours(report.TotalSeconds)))
+
+	return result.String()
+}
+
+// formatHours formats seconds as hours and minutes, e.g. "7h 05m"
+func formatHours(seconds int) string {
+	minutes := (seconds + 30) / 60
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
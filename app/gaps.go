@@ -0,0 +1,328 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Where a gap fill takes its description and project from
+const (
+	FillFromPrevious = "previous"
+	FillFromNext     = "next"
+	FillFromDefault  = "default"
+)
+
+// timeGap is an untracked stretch of working hours, in the location of the
+// range it was found in
+type timeGap struct {
+	start, end time.Time
+}
+
+// findGaps returns the untracked stretches of at least minGap inside the
+// working hours of each day from from (inclusive) to to (exclusive), cut
+// off at now. sorted must be in start order.
+func findGaps(sorted []TimeEntry, from, to time.Time, workHours WorkHours, minGap time.Duration, now time.Time) []timeGap {
+	var gaps []timeGap
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		windowStart, windowEnd, ok := workHours.window(day)
+		if !ok || !windowStart.Before(now) {
+			continue
+		}
+		if windowEnd.After(now) {
+			windowEnd = now.In(day.Location())
+		}
+
+		cursor := windowStart
+		add := func(end time.Time) {
+			if end.Sub(cursor) >= minGap {
+				gaps = append(gaps, timeGap{start: cursor, end: end})
+			}
+		}
+		for _, entry := range sorted {
+			start, end := entry.Start.In(day.Location()), entryEnd(entry, now).In(day.Location())
+			if !end.After(cursor) || !start.Before(windowEnd) || !end.After(start) {
+				continue
+			}
+			if start.After(cursor) {
+				add(start)
+			}
+			cursor = end
+			if !cursor.Before(windowEnd) {
+				break
+			}
+		}
+		if cursor.Before(windowEnd) {
+			add(windowEnd)
+		}
+	}
+	return gaps
+}
+
+// GapFill is the entry proposed for, or created in, one gap
+type GapFill struct {
+	ImportRow
+	// From is where the description and project came from: the previous
+	// or next entry, or the defaults given
+	From string `json:"from"`
+}
+
+// GapFillResult reports every gap filled, or proposed to be filled
+type GapFillResult struct {
+	Preview bool      `json:"preview"`
+	Fills   []GapFill `json:"fills"`
+}
+
+// gapDefaults are the fields of entries filling gaps that have no adjacent
+// entry to copy from, or all gaps when copying is off
+type gapDefaults struct {
+	description string
+	project     string
+	taskID      *int
+	tags        []string
+}
+
+// adjacentEntry returns the entry ending last before gap, or with from set
+// to FillFromNext the entry starting first after it, on the same day. It
+// falls back to the other side, and returns nil when the day has neither.
+func adjacentEntry(sorted []TimeEntry, gap timeGap, from string, now time.Time) (*TimeEntry, string) {
+	loc := gap.start.Location()
+	sameDay := func(t time.Time) bool {
+		y1, m1, d1 := t.In(loc).Date()
+		y2, m2, d2 := gap.start.Date()
+		return y1 == y2 && m1 == m2 && d1 == d2
+	}
+
+	var previous, next *TimeEntry
+	for i := range sorted {
+		entry := &sorted[i]
+		end := entryEnd(*entry, now)
+		if !end.After(gap.start) && sameDay(end) && (previous == nil || end.After(entryEnd(*previous, now))) {
+			previous = entry
+		}
+		if next == nil && !entry.Start.Before(gap.end) && sameDay(entry.Start) {
+			next = entry
+		}
+	}
+
+	first, second := previous, next
+	firstFrom, secondFrom := FillFromPrevious, FillFromNext
+	if from == FillFromNext {
+		first, second = next, previous
+		firstFrom, secondFrom = FillFromNext, FillFromPrevious
+	}
+	switch {
+	case first != nil:
+		return first, firstFrom
+	case second != nil:
+		return second, secondFrom
+	}
+	return nil, FillFromDefault
+}
+
+// gapFills proposes one entry per gap, copying the description and project
+// of the adjacent entry chosen by from, or using defaults. An empty from
+// means defaults only.
+func gapFills(sorted []TimeEntry, gaps []timeGap, from string, defaults gapDefaults, lookup *importLookup, now time.Time) []GapFill {
+	fills := make([]GapFill, len(gaps))
+	for i, gap := range gaps {
+		entry := TimeEntryRequest{
+			Start:       gap.start,
+			Duration:    int(gap.end.Sub(gap.start).Seconds()),
+			Tags:        append([]string(nil), defaults.tags...),
+			CreatedWith: "toggl-mcp",
+		}
+		project := defaults.project
+
+		fills[i].From = FillFromDefault
+		if from != "" {
+			var source *TimeEntry
+			if source, fills[i].From = adjacentEntry(sorted, gap, from, now); source != nil {
+				entry.Description = source.Description
+				entry.ProjectID, entry.TaskID = source.ProjectID, source.TaskID
+				project = ""
+			}
+		}
+		if fills[i].From == FillFromDefault {
+			entry.Description, entry.TaskID = defaults.description, defaults.taskID
+		}
+
+		lookup.resolve(&fills[i].ImportRow, entry, project)
+	}
+	return fills
+}
+
+// formatGapFills lists each gap with the entry proposed for it
+func formatGapFills(result GapFillResult, loc *time.Location) string {
+	var text strings.Builder
+	if result.Preview {
+		text.WriteString(fmt.Sprintf("Proposed entries for %d gaps (nothing has been created; call again with confirm=true to create them):\n",
+			len(result.Fills)))
+	} else {
+		text.WriteString(fmt.Sprintf("Filled %d gaps:\n", len(result.Fills)))
+	}
+
+	rows := make([]ImportRow, len(result.Fills))
+	for i, fill := range result.Fills {
+		rows[i] = fill.ImportRow
+		text.WriteString("- ")
+		if fill.Entry != nil {
+			start := fill.Entry.Start.In(loc)
+			stop := start.Add(time.Duration(fill.Entry.Duration) * time.Second)
+			text.WriteString(fmt.Sprintf("%s %s-%s %s", start.Format("2006-01-02"),
+				start.Format("15:04"), stop.Format("15:04"), fill.Entry.Description))
+			if fill.Project != "" {
+				text.WriteString(fmt.Sprintf(" [%s]", fill.Project))
+			}
+			if fill.From != FillFromDefault {
+				text.WriteString(fmt.Sprintf(" (from %s entry)", fill.From))
+			}
+			text.WriteString(" - ")
+		}
+		text.WriteString(importStatusText(fill.ImportRow) + "\n")
+	}
+
+	text.WriteString("Summary: " + importSummary(rows) + "\n")
+	return text.String()
+}
+
+// gapTools returns the gap filling tool, which looks for gaps inside workHours
+func gapTools(client *TogglClient, workHours WorkHours) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"fill_gaps",
+				mcp.WithDescription("Find untracked time between entries inside working hours and propose an entry for each gap, copying the description and project of the previous (or next) entry that day, or using the defaults given. Without confirm=true only a preview is returned."),
+				mcp.WithString("start_date", mcp.Required(), mcp.Description("First day, YYYY-MM-DD")),
+				mcp.WithString("end_date", mcp.Description("Day after the last day, YYYY-MM-DD (exclusive; default the day after start_date)")),
+				mcp.WithNumber("min_gap_minutes", mcp.Description("Shortest gap to fill (default 15)")),
+				mcp.WithString("copy_from",
+					mcp.Enum(FillFromPrevious, FillFromNext, "none"),
+					mcp.Description("Adjacent entry to copy the description and project from (default previous); none uses the defaults for every gap"),
+				),
+				mcp.WithString("description", mcp.Description("Description for gaps with no entry to copy from")),
+				mcp.WithString("project", mcp.Description("Project name for gaps with no entry to copy from")),
+				mcp.WithString("task", mcp.Description("Task for gaps with no entry to copy from, by name or ID; a name is looked up in project")),
+				mcp.WithArray("tags", mcp.Description("Tags for every new entry"), mcp.Items(map[string]interface{}{"type": "string"})),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
+				mcp.WithBoolean("confirm", mcp.Description("Create the entries; otherwise only preview them")),
+			),
+			handler: wrapHandler(client, handleFillGaps(workHours)),
+		},
+	}
+}
+
+func handleFillGaps(workHours WorkHours) func(
+	context.Context,
+	*TogglClient,
+	mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := req.GetArguments()
+
+		workspaceID, err := getWorkspaceID(args, client)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace_id: %w", err)
+		}
+		startDate, err := getRequiredDate(args, "start_date")
+		if err != nil {
+			return nil, err
+		}
+		endDate := startDate.AddDate(0, 0, 1)
+		if getOptionalString(args, "end_date") != "" {
+			if endDate, err = getRequiredDate(args, "end_date"); err != nil {
+				return nil, err
+			}
+		}
+		tags, err := getOptionalStringList(args, "tags")
+		if err != nil {
+			return nil, err
+		}
+
+		from := getOptionalString(args, "copy_from")
+		switch from {
+		case "":
+			from = FillFromPrevious
+		case "none":
+			from = ""
+		case FillFromPrevious, FillFromNext:
+		default:
+			return nil, fmt.Errorf("copy_from must be %s, %s or none, got %q", FillFromPrevious, FillFromNext, from)
+		}
+
+		minGap := defaultMinGap
+		if n := getOptionalNumber(args, "min_gap_minutes"); n != nil {
+			if *n <= 0 {
+				return nil, errors.New("min_gap_minutes must be positive")
+			}
+			minGap = time.Duration(*n) * time.Minute
+		}
+
+		loc := client.timeZone()
+		rangeStart := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+		rangeEnd := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc)
+		if !rangeEnd.After(rangeStart) {
+			return nil, errors.New("end_date must be after start_date")
+		}
+
+		now := time.Now()
+		entries, err := client.GetTimeEntries(ctx, rangeStart, rangeEnd)
+		if err != nil {
+			return apiErrorResult("fill gaps", err)
+		}
+		sortEntries(entries)
+
+		gaps := findGaps(entries, rangeStart, rangeEnd, workHours, minGap, now)
+		if len(gaps) > maxImportRows {
+			return mcp.NewToolResultError(fmt.Sprintf(
+				"%d gaps found; narrow the date range to at most %d", len(gaps), maxImportRows,
+			)), nil
+		}
+		if len(gaps) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No gaps of %s or more in working hours from %s to %s",
+				formatHours(int(minGap.Seconds())), rangeStart.Format("2006-01-02"),
+				rangeEnd.AddDate(0, 0, -1).Format("2006-01-02"))), nil
+		}
+
+		lookup, err := client.newImportLookup(ctx, workspaceID)
+		if err != nil {
+			return apiErrorResult("fill gaps", err)
+		}
+
+		defaults := gapDefaults{
+			description: getOptionalString(args, "description"),
+			project:     getOptionalString(args, "project"),
+			tags:        tags,
+		}
+		var projectID *int
+		if id, ok := lookup.projectIDs[strings.ToLower(defaults.project)]; ok {
+			projectID = &id
+		}
+		if defaults.taskID, err = client.resolveTask(ctx, workspaceID, projectID, args); err != nil {
+			return taskError("fill gaps", workspaceID, err)
+		}
+		result := GapFillResult{
+			Preview: !getOptionalBool(args, "confirm"),
+			Fills:   gapFills(entries, gaps, from, defaults, lookup, now),
+		}
+		if !result.Preview {
+			rows := make([]ImportRow, len(result.Fills))
+			for i := range result.Fills {
+				rows[i] = result.Fills[i].ImportRow
+			}
+			client.createImportRows(ctx, workspaceID, rows)
+			for i := range result.Fills {
+				result.Fills[i].ImportRow = rows[i]
+			}
+		}
+
+		if wantsJSON(ctx) {
+			return jsonResult(result)
+		}
+		return mcp.NewToolResultText(formatGapFills(result, loc)), nil
+	}
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestGapFills(t *testing.T) {
+	now := time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)
+	at := func(clock string) time.Time {
+		t, _ := time.Parse(time.RFC3339, "2024-03-15T"+clock+":00Z")
+		return t
+	}
+	entries := []TimeEntry{
+		lintEntry(1, "Design", "09:00", "10:00", true),
+		lintEntry(2, "Email", "11:00", "12:00", false),
+	}
+	gaps := []timeGap{
+		{start: at("08:00"), end: at("09:00")},
+		{start: at("10:00"), end: at("11:00")},
+		{start: at("12:00"), end: at("13:00")},
+	}
+	lookup := &importLookup{
+		workspaceID:  456,
+		projectIDs:   map[string]int{"test project": 111},
+		projectNames: map[int]string{111: "Test Project"},
+		tagNames:     map[string]string{"admin": "Admin"},
+	}
+	defaults := gapDefaults{description: "Admin work", project: "test project", tags: []string{"admin"}}
+
+	tests := []struct {
+		name string
+		from string
+		want []string
+	}{
+		{
+			name: "previous",
+			from: FillFromPrevious,
+			want: []string{"next:Design:Test Project", "previous:Design:Test Project", "previous:Email:"},
+		},
+		{
+			name: "next",
+			from: FillFromNext,
+			want: []string{"next:Design:Test Project", "next:Email:", "previous:Email:"},
+		},
+		{
+			name: "defaults only",
+			from: "",
+			want: []string{"default:Admin work:Test Project", "default:Admin work:Test Project", "default:Admin work:Test Project"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fills := gapFills(entries, gaps, tt.from, defaults, lookup, now)
+			var got []string
+			for _, fill := range fills {
+				if fill.Status != ImportReady {
+					t.Fatalf("unexpected fill: %+v", fill)
+				}
+				if fill.Entry.Duration != 3600 || fill.Entry.Tags[0] != "Admin" {
+					t.Errorf("unexpected entry: %+v", fill.Entry)
+				}
+				got = append(got, fill.From+":"+fill.Entry.Description+":"+fill.Project)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleFillGaps(t *testing.T) {
+	var created int32
+	_, client := testServer(t, importHandler(&created))
+	client.location = time.UTC
+	morning := WorkHours{Start: 9 * time.Hour, End: 12 * time.Hour, Days: [7]bool{true, true, true, true, true, true, true}}
+
+	call := func(extra map[string]interface{}) string {
+		t.Helper()
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{"start_date": "2024-03-15", "workspace_id": float64(456)}
+		for k, v := range extra {
+			req.GetArguments()[k] = v
+		}
+		result, err := handleFillGaps(morning)(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	preview := call(nil)
+	if !strings.Contains(preview, "- 2024-03-15 09:15-12:00 Standup (from previous entry) - ready") || created != 0 {
+		t.Errorf("unexpected preview:\n%s", preview)
+	}
+
+	unknown := call(map[string]interface{}{"copy_from": "none", "project": "Nope"})
+	if !strings.Contains(unknown, `invalid: unknown project "Nope"`) {
+		t.Errorf("expected unknown project, got:\n%s", unknown)
+	}
+
+	result := call(map[string]interface{}{"copy_from": "none", "description": "Admin", "project": "Test Project", "confirm": true})
+	if !strings.Contains(result, "Admin [Test Project] - created (ID: 1001)") || created != 1 {
+		t.Errorf("unexpected result:\n%s", result)
+	}
+
+	none := call(map[string]interface{}{"min_gap_minutes": float64(240)})
+	if !strings.HasPrefix(none, "No gaps of 4h 00m or more") {
+		t.Errorf("expected no gaps, got:\n%s", none)
+	}
+}
@@ -5,9 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
-	"net/url"
-	"strconv"
 	"strings"
 	"time"
 
@@ -15,12 +12,154 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// workspaceIDDescription documents the optional workspace_id argument
+const workspaceIDDescription = "Workspace ID; defaults to the profile's default workspace"
+
+// roundDescription documents the optional round argument of tools that
+// create or stop entries
+const roundDescription = "Round durations with the configured rounding"
+
+// roundedDescription documents the optional rounded argument of listings
+const roundedDescription = "Also show durations rounded with the configured rounding"
+
+// toolDefinition pairs a tool schema with its handler
+type toolDefinition struct {
+	tool    mcp.Tool
+	handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+}
+
+// setupConfig holds the optional settings applied by SetupTools
+type setupConfig struct {
+	deleteTools     bool
+	confirmationTTL time.Duration
+	auditLog        *AuditLog
+	profiles        *Profiles
+	enabledTools    []string
+	outputFormat    string
+	allowedDirs     []string
+	workHours       WorkHours
+	rounding        Rounding
+	invoicing       Invoicing
+	budget          []int
+	timesheet       TimesheetSettings
+	schedule        Schedule
+}
+
+// SetupOption is a functional option for configuring which tools are registered
+type SetupOption func(*setupConfig)
+
+// WithDeleteTools registers the destructive delete tools. Deletions always
+// require a confirmation token issued by a preview call; tokens expire after
+// ttl, or after defaultConfirmationTTL when ttl is zero.
+func WithDeleteTools(ttl time.Duration) SetupOption {
+	return func(c *setupConfig) {
+		c.deleteTools = true
+		if ttl > 0 {
+			c.confirmationTTL = ttl
+		}
+	}
+}
+
+// WithAuditLog writes a record of every tool call to auditLog
+func WithAuditLog(auditLog *AuditLog) SetupOption {
+	return func(c *setupConfig) {
+		c.auditLog = auditLog
+	}
+}
+
+// WithProfiles adds an optional profile argument to every tool, selecting
+// which profile's client the call uses, and registers list_profiles
+func WithProfiles(profiles *Profiles) SetupOption {
+	return func(c *setupConfig) {
+		c.profiles = profiles
+	}
+}
+
+// WithEnabledTools registers only the named tools instead of every available one
+func WithEnabledTools(names ...string) SetupOption {
+	return func(c *setupConfig) {
+		c.enabledTools = names
+	}
+}
+
+// WithAllowedDirs lets tools read and write files inside dirs. Without it,
+// tools never touch the filesystem.
+func WithAllowedDirs(dirs ...string) SetupOption {
+	return func(c *setupConfig) {
+		c.allowedDirs = dirs
+	}
+}
+
+// WithWorkHours sets the working hours inside which tools look for gaps
+// between time entries, instead of DefaultWorkHours
+func WithWorkHours(workHours WorkHours) SetupOption {
+	return func(c *setupConfig) {
+		c.workHours = workHours
+	}
+}
+
+// WithRounding sets the rounding tools apply when asked to round durations
+func WithRounding(rounding Rounding) SetupOption {
+	return func(c *setupConfig) {
+		c.rounding = rounding
+	}
+}
+
+// WithInvoicing sets the sender details and templates of generated invoices
+func WithInvoicing(invoicing Invoicing) SetupOption {
+	return func(c *setupConfig) {
+		c.invoicing = invoicing
+	}
+}
+
+// WithBudgetThresholds sets the percentages of a project's estimate or
+// fixed fee at which project_status flags it
+func WithBudgetThresholds(thresholds ...int) SetupOption {
+	return func(c *setupConfig) {
+		c.budget = thresholds
+	}
+}
+
+// WithTimesheet sets the week start of weekly_timesheet and work_balance,
+// instead of DefaultTimesheetSettings
+func WithTimesheet(settings TimesheetSettings) SetupOption {
+	return func(c *setupConfig) {
+		c.timesheet = settings
+	}
+}
+
+// WithSchedule sets the contracted working time that work_balance and
+// weekly_timesheet compare tracked time with, instead of DefaultSchedule
+func WithSchedule(schedule Schedule) SetupOption {
+	return func(c *setupConfig) {
+		c.schedule = schedule
+	}
+}
+
+// WithOutputFormat sets how listing tools format their results: OutputText
+// (the default) or OutputJSON
+func WithOutputFormat(format string) SetupOption {
+	return func(c *setupConfig) {
+		c.outputFormat = format
+	}
+}
+
 // SetupTools defines all tools with their configurations
-func SetupTools(s *server.MCPServer, togglClient *TogglClient) error {
-	tools := []struct {
-		tool    mcp.Tool
-		handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
-	}{
+func SetupTools(s *server.MCPServer, togglClient *TogglClient, opts ...SetupOption) error {
+	cfg := setupConfig{
+		confirmationTTL: defaultConfirmationTTL,
+		workHours:       DefaultWorkHours(),
+		invoicing:       DefaultInvoicing(),
+		budget:          DefaultConfig().Budget.Thresholds,
+		timesheet:       DefaultTimesheetSettings(),
+		schedule:        DefaultSchedule(),
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	tools := []toolDefinition{
 		{
 			tool: mcp.NewTool(
 				"test_connection",
@@ -33,8 +172,9 @@ func SetupTools(s *server.MCPServer, togglClient *TogglClient) error {
 				"start_time_entry",
 				mcp.WithDescription("Start a new time entry"),
 				mcp.WithString("description", mcp.Required()),
-				mcp.WithNumber("workspace_id", mcp.Required()),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
 				mcp.WithNumber("project_id"),
+				mcp.WithString("task", mcp.Description(taskDescription)),
 			),
 			handler: wrapHandler(togglClient, handleStartTimeEntry),
 		},
@@ -42,7 +182,8 @@ func SetupTools(s *server.MCPServer, togglClient *TogglClient) error {
 			tool: mcp.NewTool(
 				"stop_time_entry",
 				mcp.WithDescription("Stop the current running time entry"),
-				mcp.WithNumber("workspace_id", mcp.Required()),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
+				mcp.WithBoolean("round", mcp.Description("Round the entry's duration with the configured rounding, moving its stop time")),
 			),
 			handler: wrapHandler(togglClient, handleStopTimeEntry),
 		},
@@ -59,6 +200,7 @@ func SetupTools(s *server.MCPServer, togglClient *TogglClient) error {
 				mcp.WithDescription("Get time entries with optional date filtering. Note: To get entries for a single day (e.g., July 9th), use start_date=2025-07-09 and end_date=2025-07-10. The API uses inclusive start, exclusive end date logic."),
 				mcp.WithString("start_date"),
 				mcp.WithString("end_date"),
+				mcp.WithBoolean("rounded", mcp.Description(roundedDescription)),
 			),
 			handler: wrapHandler(togglClient, handleGetTimeEntries),
 		},
@@ -67,6 +209,7 @@ func SetupTools(s *server.MCPServer, togglClient *TogglClient) error {
 				"get_time_entries_for_day",
 				mcp.WithDescription("Get time entries for a specific day (automatically handles the date range correctly)"),
 				mcp.WithString("date", mcp.Required()),
+				mcp.WithBoolean("rounded", mcp.Description(roundedDescription)),
 			),
 			handler: wrapHandler(togglClient, handleGetTimeEntriesForDay),
 		},
@@ -75,7 +218,7 @@ func SetupTools(s *server.MCPServer, togglClient *TogglClient) error {
 				"create_project",
 				mcp.WithDescription("Create a new project"),
 				mcp.WithString("name", mcp.Required()),
-				mcp.WithNumber("workspace_id", mcp.Required()),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
 				mcp.WithString("color"),
 				mcp.WithNumber("client_id"),
 			),
@@ -85,22 +228,178 @@ func SetupTools(s *server.MCPServer, togglClient *TogglClient) error {
 			tool: mcp.NewTool(
 				"get_projects",
 				mcp.WithDescription("Get projects in a workspace"),
-				mcp.WithNumber("workspace_id", mcp.Required()),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
 				mcp.WithBoolean("active"),
 			),
 			handler: wrapHandler(togglClient, handleGetProjects),
 		},
 	}
 
+	tools = append(tools, taskTools(togglClient)...)
+	tools = append(tools, journalTools(togglClient)...)
+	tools = append(tools, exportTools(togglClient, cfg.allowedDirs)...)
+	tools = append(tools, importTools(togglClient, cfg.allowedDirs)...)
+	tools = append(tools, calendarTools(togglClient, cfg.allowedDirs)...)
+	tools = append(tools, lintTools(togglClient, cfg.workHours)...)
+	tools = append(tools, gapTools(togglClient, cfg.workHours)...)
+	tools = append(tools, editTools(togglClient)...)
+	tools = append(tools, bulkTools(togglClient)...)
+	tools = append(tools, searchTools(togglClient)...)
+	tools = append(tools, billingTools(togglClient)...)
+	tools = append(tools, invoiceTools(togglClient, cfg.invoicing)...)
+	tools = append(tools, budgetTools(togglClient, cfg.budget)...)
+	tools = append(tools, timesheetTools(togglClient, cfg.timesheet, cfg.schedule)...)
+	tools = append(tools, balanceTools(togglClient, cfg.schedule, cfg.timesheet.WeekStart)...)
+
+	if cfg.deleteTools {
+		tools = append(tools, deleteTools(togglClient, newConfirmationStore(cfg.confirmationTTL))...)
+	}
+
+	if cfg.profiles != nil {
+		tools = append(tools, profileTools(cfg.profiles)...)
+	}
+
+	if len(cfg.enabledTools) > 0 {
+		var err error
+		if tools, err = filterTools(tools, cfg.enabledTools); err != nil {
+			return err
+		}
+	}
+
 	// Register all tools
 	for _, t := range tools {
-		s.AddTool(t.tool, t.handler)
+		tool, handler := t.tool, t.handler
+		if cfg.profiles != nil {
+			tool = cfg.profiles.withProfileArgument(tool)
+			handler = cfg.profiles.middleware(handler)
+		}
+		handler = withToolName(tool.Name, handler)
+		if cfg.outputFormat != "" {
+			handler = withOutputFormat(cfg.outputFormat, handler)
+		}
+		if cfg.rounding.Enabled() {
+			handler = withRounding(cfg.rounding, handler)
+		}
+		if cfg.auditLog != nil {
+			handler = cfg.auditLog.middleware(tool.Name, handler)
+		}
+		s.AddTool(tool, handler)
 	}
 
 	return nil
 }
 
-// wrapHandler wraps a handler function to provide the client and proper error handling
+// filterTools keeps only the named tools, failing on names that are not available
+func filterTools(tools []toolDefinition, names []string) ([]toolDefinition, error) {
+	available := make(map[string]toolDefinition, len(tools))
+	for _, t := range tools {
+		available[t.tool.Name] = t
+	}
+
+	filtered := make([]toolDefinition, 0, len(names))
+	for _, name := range names {
+		t, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("enabled tool %q is not available", name)
+		}
+		filtered = append(filtered, t)
+	}
+	return filtered, nil
+}
+
+// toolNameKey is the context key holding the name of the tool being called
+type toolNameKey struct{}
+
+// withToolName makes the tool name available to everything the handler calls
+func withToolName(
+	name string,
+	handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error),
+) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handler(context.WithValue(ctx, toolNameKey{}, name), req)
+	}
+}
+
+// toolNameFromContext returns the name of the tool being called, if any
+func toolNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(toolNameKey{}).(string)
+	return name
+}
+
+// Output formats for listing tools
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// outputFormatKey is the context key holding the configured output format
+type outputFormatKey struct{}
+
+// withOutputFormat makes the output format available to the handler
+func withOutputFormat(
+	format string,
+	handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error),
+) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handler(context.WithValue(ctx, outputFormatKey{}, format), req)
+	}
+}
+
+// wantsJSON reports whether results should be returned as JSON
+func wantsJSON(ctx context.Context) bool {
+	format, _ := ctx.Value(outputFormatKey{}).(string)
+	return format == OutputJSON
+}
+
+// roundingKey is the context key holding the configured rounding
+type roundingKey struct{}
+
+// withRounding makes the configured rounding available to the handler
+func withRounding(
+	rounding Rounding,
+	handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error),
+) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handler(context.WithValue(ctx, roundingKey{}, rounding), req)
+	}
+}
+
+// requestedRounding returns the configured rounding when the boolean
+// argument key is set, and no rounding otherwise. Asking for rounding that
+// is not configured is an error.
+func requestedRounding(ctx context.Context, args map[string]interface{}, key string) (Rounding, error) {
+	if !getOptionalBool(args, key) {
+		return Rounding{}, nil
+	}
+	rounding, _ := ctx.Value(roundingKey{}).(Rounding)
+	if !rounding.Enabled() {
+		return Rounding{}, errNoRounding
+	}
+	return rounding, nil
+}
+
+// apiErrorResult turns an API error into a tool error result saying what
+// failed, e.g. "Failed to build timesheet: ..."; other errors are returned
+func apiErrorResult(action string, err error) (*mcp.CallToolResult, error) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to %s: %s", action, apiErr.Error())), nil
+	}
+	return nil, fmt.Errorf("failed to %s: %w", action, err)
+}
+
+// jsonResult returns v as indented JSON text
+func jsonResult(v interface{}) (*mcp.CallToolResult, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding result: %w", err)
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// wrapHandler wraps a handler function to provide the client and proper error handling.
+// A client attached to the request context (see WithRequestClient) takes
+// precedence over the default client, which may be nil in multi-user mode.
 func wrapHandler(
 	client *TogglClient,
 	handler func(
@@ -110,7 +409,16 @@ func wrapHandler(
 	) (*mcp.CallToolResult, error),
 ) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		return handler(ctx, client, req)
+		c := client
+		if rc := requestClientFromContext(ctx); rc != nil {
+			c = rc
+		}
+		if c == nil {
+			return mcp.NewToolResultError(
+				"No Toggl API token: send yours as a bearer token or in the " + TokenHeader + " header",
+			), nil
+		}
+		return handler(ctx, c, req)
 	}
 }
 
@@ -119,18 +427,13 @@ func handleTestConnection(
 	client *TogglClient,
 	_ mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
-	resp, err := client.makeRequest(ctx, http.MethodGet, "/me", nil)
-	if err != nil {
-		return nil, fmt.Errorf("connecting to Toggl API: %w", err)
-	}
-
-	user, err := decodeResponse[UserInfo](resp)
+	user, err := client.GetMe(ctx)
 	if err != nil {
 		var apiErr *APIError
 		if errors.As(err, &apiErr) {
 			return mcp.NewToolResultError(fmt.Sprintf("Authentication failed: %s", apiErr.Error())), nil
 		}
-		return nil, fmt.Errorf("parsing user info: %w", err)
+		return nil, fmt.Errorf("connecting to Toggl API: %w", err)
 	}
 
 	result := fmt.Sprintf(`✅ Authentication successful!
@@ -146,12 +449,12 @@ func handleStartTimeEntry(
 	client *TogglClient,
 	req mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
-	description, err := getRequiredString(req.Params.Arguments, "description")
+	description, err := getRequiredString(req.GetArguments(), "description")
 	if err != nil {
 		return nil, fmt.Errorf("invalid description: %w", err)
 	}
 
-	workspaceID, err := getRequiredNumber(req.Params.Arguments, "workspace_id")
+	workspaceID, err := getWorkspaceID(req.GetArguments(), client)
 	if err != nil {
 		return nil, fmt.Errorf("invalid workspace_id: %w", err)
 	}
@@ -161,25 +464,13 @@ func handleStartTimeEntry(
 		Start:       time.Now(),
 		Duration:    -1, // Running timer
 		CreatedWith: "toggl-mcp",
-		ProjectID:   getOptionalNumber(req.Params.Arguments, "project_id"),
+		ProjectID:   getOptionalNumber(req.GetArguments(), "project_id"),
 	}
-
-	payload, err := json.Marshal(entry)
-	if err != nil {
-		return nil, fmt.Errorf("marshaling request: %w", err)
-	}
-
-	resp, err := client.makeRequest(
-		ctx,
-		http.MethodPost,
-		fmt.Sprintf("/workspaces/%d/time_entries", workspaceID),
-		strings.NewReader(string(payload)),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("starting time entry: %w", err)
+	if entry.TaskID, err = client.resolveTask(ctx, workspaceID, entry.ProjectID, req.GetArguments()); err != nil {
+		return taskError("start time entry", workspaceID, err)
 	}
 
-	result, err := decodeResponse[TimeEntry](resp)
+	result, err := client.CreateTimeEntry(ctx, workspaceID, entry)
 	if err != nil {
 		var apiErr *APIError
 		if errors.As(err, &apiErr) {
@@ -187,7 +478,7 @@ func handleStartTimeEntry(
 				fmt.Sprintf("Failed to start time entry: %s", apiErr.Error()),
 			), nil
 		}
-		return nil, fmt.Errorf("decoding response: %w", err)
+		return nil, fmt.Errorf("starting time entry: %w", err)
 	}
 
 	return mcp.NewToolResultText(
@@ -200,52 +491,47 @@ func handleStopTimeEntry(
 	client *TogglClient,
 	req mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
-	workspaceID, err := getRequiredNumber(req.Params.Arguments, "workspace_id")
+	workspaceID, err := getWorkspaceID(req.GetArguments(), client)
 	if err != nil {
 		return nil, fmt.Errorf("invalid workspace_id: %w", err)
 	}
-
-	// Get current running entry
-	resp, err := client.makeRequest(ctx, http.MethodGet, "/me/time_entries/current", nil)
-	if err != nil {
-		return nil, fmt.Errorf("getting current time entry: %w", err)
-	}
-
-	// Check if there's a running entry
-	if resp.StatusCode == http.StatusNotFound {
-		resp.Body.Close()
-		return mcp.NewToolResultText("No running time entry found"), nil
-	}
-
-	current, err := decodeResponse[TimeEntry](resp)
+	rounding, err := requestedRounding(ctx, req.GetArguments(), "round")
 	if err != nil {
-		return nil, fmt.Errorf("parsing current entry: %w", err)
+		return nil, err
 	}
 
-	// Stop the entry
-	stopResp, err := client.makeRequest(
-		ctx,
-		http.MethodPatch,
-		fmt.Sprintf("/workspaces/%d/time_entries/%d/stop", workspaceID, current.ID),
-		nil,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("stopping time entry: %w", err)
-	}
-
-	_, err = decodeResponse[TimeEntry](stopResp)
+	stopped, err := client.StopCurrentTimeEntry(ctx, workspaceID)
 	if err != nil {
+		if errors.Is(err, ErrNoRunningEntry) {
+			return mcp.NewToolResultText("No running time entry found"), nil
+		}
 		var apiErr *APIError
 		if errors.As(err, &apiErr) {
 			return mcp.NewToolResultError(
 				fmt.Sprintf("Failed to stop time entry: %s", apiErr.Error()),
 			), nil
 		}
-		return nil, fmt.Errorf("decoding stop response: %w", err)
+		return nil, fmt.Errorf("stopping time entry: %w", err)
+	}
+
+	if rounding.Enabled() {
+		raw := stopped.Duration
+		rounded, err := client.roundTimeEntry(ctx, workspaceID, stopped, rounding)
+		if err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				return mcp.NewToolResultError(fmt.Sprintf(
+					"Stopped time entry %d, but failed to round it: %s", stopped.ID, apiErr.Error(),
+				)), nil
+			}
+			return nil, fmt.Errorf("rounding time entry: %w", err)
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Stopped time entry: %s (ID: %d) %s",
+			rounded.Description, rounded.ID, formatDuration(raw, rounding))), nil
 	}
 
 	return mcp.NewToolResultText(
-		fmt.Sprintf("Stopped time entry: %s (ID: %d)", current.Description, current.ID),
+		fmt.Sprintf("Stopped time entry: %s (ID: %d)", stopped.Description, stopped.ID),
 	), nil
 }
 
@@ -254,31 +540,25 @@ func handleGetCurrentTimeEntry(
 	client *TogglClient,
 	_ mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
-	resp, err := client.makeRequest(ctx, http.MethodGet, "/me/time_entries/current", nil)
-	if err != nil {
-		return nil, fmt.Errorf("getting current time entry: %w", err)
-	}
-
-	// Check if there's a running entry
-	if resp.StatusCode == http.StatusNotFound {
-		resp.Body.Close()
-		return mcp.NewToolResultText("No running time entry found"), nil
-	}
-
-	current, err := decodeResponse[TimeEntry](resp)
+	current, err := client.GetCurrentTimeEntry(ctx)
 	if err != nil {
+		if errors.Is(err, ErrNoRunningEntry) {
+			return mcp.NewToolResultText("No running time entry found"), nil
+		}
 		var apiErr *APIError
 		if errors.As(err, &apiErr) {
 			return mcp.NewToolResultError(
 				fmt.Sprintf("Failed to get current entry: %s", apiErr.Error()),
 			), nil
 		}
-		return nil, fmt.Errorf("parsing response: %w", err)
+		return nil, fmt.Errorf("getting current time entry: %w", err)
+	}
+
+	if wantsJSON(ctx) {
+		return jsonResult(current)
 	}
 
-	duration := time.Since(current.Start).Round(time.Second)
-	return mcp.NewToolResultText(fmt.Sprintf("Current time entry: %s (ID: %d, Running for: %s)",
-		current.Description, current.ID, duration)), nil
+	return mcp.NewToolResultText(formatCurrentEntry(current, time.Now())), nil
 }
 
 func handleGetTimeEntries(
@@ -286,35 +566,30 @@ func handleGetTimeEntries(
 	client *TogglClient,
 	req mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
-	endpoint := "/me/time_entries"
-	params := url.Values{}
-
-	startDate := getOptionalString(req.Params.Arguments, "start_date")
-	endDate := getOptionalString(req.Params.Arguments, "end_date")
+	startDate := getOptionalString(req.GetArguments(), "start_date")
+	endDate := getOptionalString(req.GetArguments(), "end_date")
+	rounding, err := requestedRounding(ctx, req.GetArguments(), "rounded")
+	if err != nil {
+		return nil, err
+	}
 
+	var start, end time.Time
 	if startDate != "" {
-		if _, err := time.Parse("2006-01-02", startDate); err != nil {
+		date, err := time.Parse("2006-01-02", startDate)
+		if err != nil {
 			return nil, fmt.Errorf("invalid start_date format (use YYYY-MM-DD): %w", err)
 		}
-		params.Set("start_date", startDate)
+		start = date
 	}
 	if endDate != "" {
-		if _, err := time.Parse("2006-01-02", endDate); err != nil {
+		date, err := time.Parse("2006-01-02", endDate)
+		if err != nil {
 			return nil, fmt.Errorf("invalid end_date format (use YYYY-MM-DD): %w", err)
 		}
-		params.Set("end_date", endDate)
-	}
-
-	if len(params) > 0 {
-		endpoint += "?" + params.Encode()
+		end = date
 	}
 
-	resp, err := client.makeRequest(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("getting time entries: %w", err)
-	}
-
-	entries, err := decodeResponse[[]TimeEntry](resp)
+	entries, err := client.GetTimeEntries(ctx, start, end)
 	if err != nil {
 		var apiErr *APIError
 		if errors.As(err, &apiErr) {
@@ -322,7 +597,11 @@ func handleGetTimeEntries(
 				fmt.Sprintf("Failed to get time entries: %s", apiErr.Error()),
 			), nil
 		}
-		return nil, fmt.Errorf("decoding response: %w", err)
+		return nil, fmt.Errorf("getting time entries: %w", err)
+	}
+
+	if wantsJSON(ctx) {
+		return jsonResult(entries)
 	}
 
 	var result strings.Builder
@@ -349,13 +628,7 @@ func handleGetTimeEntries(
 		}
 	} else {
 		for _, entry := range entries {
-			duration := formatDuration(entry.Duration)
-			projectInfo := ""
-			if entry.ProjectID != nil {
-				projectInfo = fmt.Sprintf(" (Project ID: %d)", *entry.ProjectID)
-			}
-			result.WriteString(fmt.Sprintf("- %s (ID: %d)%s %s\n",
-				entry.Description, entry.ID, projectInfo, duration))
+			result.WriteString(formatTimeEntryLine(entry, rounding) + "\n")
 		}
 	}
 
@@ -367,7 +640,7 @@ func handleGetTimeEntriesForDay(
 	client *TogglClient,
 	req mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
-	date, err := getRequiredString(req.Params.Arguments, "date")
+	date, err := getRequiredString(req.GetArguments(), "date")
 	if err != nil {
 		return nil, fmt.Errorf("invalid date: %w", err)
 	}
@@ -380,8 +653,8 @@ func handleGetTimeEntriesForDay(
 	endDate := startDate.AddDate(0, 0, 1) // Add one day
 	endDateStr := endDate.Format("2006-01-02")
 
-	req.Params.Arguments["start_date"] = date
-	req.Params.Arguments["end_date"] = endDateStr
+	req.GetArguments()["start_date"] = date
+	req.GetArguments()["end_date"] = endDateStr
 
 	return handleGetTimeEntries(ctx, client, req)
 }
@@ -391,12 +664,12 @@ func handleCreateProject(
 	client *TogglClient,
 	req mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
-	name, err := getRequiredString(req.Params.Arguments, "name")
+	name, err := getRequiredString(req.GetArguments(), "name")
 	if err != nil {
 		return nil, fmt.Errorf("invalid name: %w", err)
 	}
 
-	workspaceID, err := getRequiredNumber(req.Params.Arguments, "workspace_id")
+	workspaceID, err := getWorkspaceID(req.GetArguments(), client)
 	if err != nil {
 		return nil, fmt.Errorf("invalid workspace_id: %w", err)
 	}
@@ -406,29 +679,14 @@ func handleCreateProject(
 		"active": true,
 	}
 
-	if color := getOptionalString(req.Params.Arguments, "color"); color != "" {
+	if color := getOptionalString(req.GetArguments(), "color"); color != "" {
 		project["color"] = color
 	}
-	if clientID := getOptionalNumber(req.Params.Arguments, "client_id"); clientID != nil {
+	if clientID := getOptionalNumber(req.GetArguments(), "client_id"); clientID != nil {
 		project["client_id"] = *clientID
 	}
 
-	payload, err := json.Marshal(project)
-	if err != nil {
-		return nil, fmt.Errorf("marshaling request: %w", err)
-	}
-
-	resp, err := client.makeRequest(
-		ctx,
-		http.MethodPost,
-		fmt.Sprintf("/workspaces/%d/projects", workspaceID),
-		strings.NewReader(string(payload)),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("creating project: %w", err)
-	}
-
-	result, err := decodeResponse[Project](resp)
+	result, err := client.CreateProject(ctx, workspaceID, project)
 	if err != nil {
 		var apiErr *APIError
 		if errors.As(err, &apiErr) {
@@ -436,7 +694,7 @@ func handleCreateProject(
 				fmt.Sprintf("Failed to create project: %s", apiErr.Error()),
 			), nil
 		}
-		return nil, fmt.Errorf("decoding response: %w", err)
+		return nil, fmt.Errorf("creating project: %w", err)
 	}
 
 	return mcp.NewToolResultText(
@@ -449,28 +707,17 @@ func handleGetProjects(
 	client *TogglClient,
 	req mcp.CallToolRequest,
 ) (*mcp.CallToolResult, error) {
-	workspaceID, err := getRequiredNumber(req.Params.Arguments, "workspace_id")
+	workspaceID, err := getWorkspaceID(req.GetArguments(), client)
 	if err != nil {
 		return nil, fmt.Errorf("invalid workspace_id: %w", err)
 	}
 
-	endpoint := fmt.Sprintf("/workspaces/%d/projects", workspaceID)
-	params := url.Values{}
-
-	if active, ok := req.Params.Arguments["active"].(bool); ok {
-		params.Set("active", strconv.FormatBool(active))
-	}
-
-	if len(params) > 0 {
-		endpoint += "?" + params.Encode()
+	var active *bool
+	if a, ok := req.GetArguments()["active"].(bool); ok {
+		active = &a
 	}
 
-	resp, err := client.makeRequest(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("getting projects: %w", err)
-	}
-
-	projects, err := decodeResponse[[]Project](resp)
+	projects, err := client.GetProjects(ctx, workspaceID, active)
 	if err != nil {
 		var apiErr *APIError
 		if errors.As(err, &apiErr) {
@@ -478,20 +725,18 @@ func handleGetProjects(
 				fmt.Sprintf("Failed to get projects: %s", apiErr.Error()),
 			), nil
 		}
-		return nil, fmt.Errorf("decoding response: %w", err)
+		return nil, fmt.Errorf("getting projects: %w", err)
+	}
+
+	if wantsJSON(ctx) {
+		return jsonResult(projects)
 	}
 
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("Found %d projects:\n", len(projects)))
 
 	for _, project := range projects {
-		status := "inactive"
-		if project.Active {
-			status = "active"
-		}
-		result.WriteString(
-			fmt.Sprintf("- %s (ID: %d, %s)\n", project.Name, project.ID, status),
-		)
+		result.WriteString(formatProjectLine(project) + "\n")
 	}
 
 	return mcp.NewToolResultText(result.String()), nil
@@ -16,13 +16,7 @@ import (
 )
 
 // testCallToolParams is a helper type for test CallToolRequest params
-type testCallToolParams struct {
-	Name      string                 `json:"name"`
-	Arguments map[string]interface{} `json:"arguments,omitempty"`
-	Meta      *struct {
-		ProgressToken mcp.ProgressToken `json:"progressToken,omitempty"`
-	} `json:"_meta,omitempty"`
-}
+type testCallToolParams = mcp.CallToolParams
 
 // testServer creates a test HTTP server that handles API requests
 func testServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, *TogglClient) {
@@ -99,6 +93,19 @@ func writeError(w http.ResponseWriter, status int, message string) {
 	w.Write([]byte(message))
 }
 
+// writeArchivedProjects writes projects as archived ones, which the API only
+// lists with active=both
+func writeArchivedProjects(w http.ResponseWriter, r *http.Request, projects ...Project) {
+	if r.URL.Query().Get("active") != "both" {
+		writeJSON(w, http.StatusOK, []Project{})
+		return
+	}
+	for i := range projects {
+		projects[i].Active = false
+	}
+	writeJSON(w, http.StatusOK, projects)
+}
+
 func TestSetupTools(t *testing.T) {
 	s := server.NewMCPServer(
 		"test-server",
@@ -117,6 +124,60 @@ func TestSetupTools(t *testing.T) {
 
 	// SetupTools should complete without error
 	// Note: The server doesn't expose a way to verify registered tools
+
+	if err := SetupTools(s, client, WithDeleteTools(time.Minute)); err != nil {
+		t.Fatalf("SetupTools with delete tools failed: %v", err)
+	}
+
+	if err := SetupTools(s, client, WithEnabledTools("get_projects", "start_time_entry")); err != nil {
+		t.Fatalf("SetupTools with enabled tools failed: %v", err)
+	}
+
+	if err := SetupTools(s, client, WithEnabledTools("delete_project")); err == nil {
+		t.Error("expected error enabling a tool that is not available")
+	}
+}
+
+func TestFilterTools(t *testing.T) {
+	tools := []toolDefinition{
+		{tool: mcp.NewTool("a")},
+		{tool: mcp.NewTool("b")},
+		{tool: mcp.NewTool("c")},
+	}
+
+	filtered, err := filterTools(tools, []string{"c", "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(filtered) != 2 || filtered[0].tool.Name != "c" || filtered[1].tool.Name != "a" {
+		t.Errorf("unexpected tools: %+v", filtered)
+	}
+
+	if _, err := filterTools(tools, []string{"d"}); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+}
+
+func TestOutputFormatJSON(t *testing.T) {
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []Project{testProject})
+	})
+
+	handler := withOutputFormat(OutputJSON, wrapHandler(client, handleGetProjects))
+	result, err := handler(context.Background(), mcp.CallToolRequest{
+		Params: testCallToolParams{Arguments: map[string]interface{}{"workspace_id": float64(456)}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var projects []Project
+	if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &projects); err != nil {
+		t.Fatalf("expected JSON output: %v", err)
+	}
+	if len(projects) != 1 || projects[0].ID != testProject.ID {
+		t.Errorf("unexpected projects: %+v", projects)
+	}
 }
 
 func TestHandleTestConnection(t *testing.T) {
@@ -800,3 +861,17 @@ func TestAPIError(t *testing.T) {
 		t.Error("APIError should match ErrAPIRequest")
 	}
 }
+
+func TestAPIErrorResult(t *testing.T) {
+	wrapped := fmt.Errorf("getting projects: %w", &APIError{StatusCode: 403, Body: "admin only"})
+	result, err := apiErrorResult("build timesheet", wrapped)
+	if err != nil || !result.IsError ||
+		result.Content[0].(mcp.TextContent).Text != "Failed to build timesheet: API error (status 403): admin only" {
+		t.Errorf("unexpected result for an API error: %+v, %v", result, err)
+	}
+
+	result, err = apiErrorResult("build timesheet", context.DeadlineExceeded)
+	if result != nil || err == nil || err.Error() != "failed to build timesheet: context deadline exceeded" {
+		t.Errorf("expected other errors to be returned, got %+v, %v", result, err)
+	}
+}
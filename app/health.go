@@ -0,0 +1,55 @@
+package app
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthCacheTTL is how long a health check result is reused, so that
+// frequent probes do not each call the Toggl API
+const healthCacheTTL = 30 * time.Second
+
+// HealthHandler reports whether the Toggl API accepts the client's token,
+// using the same check as the test_connection tool. The result is cached for
+// healthCacheTTL, and failures are only logged, not returned. With a nil
+// client (multi-user mode, where there is no shared token) it only reports
+// that the server is up.
+func HealthHandler(client *TogglClient) http.Handler {
+	var (
+		mu        sync.Mutex
+		checkedAt time.Time
+		healthy   bool
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if client == nil {
+			json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+			return
+		}
+
+		mu.Lock()
+		if time.Since(checkedAt) > healthCacheTTL {
+			_, err := client.GetMe(r.Context())
+			if err != nil {
+				client.logger.Warn("health check failed", slog.Any("error", err))
+			}
+			healthy = err == nil
+			checkedAt = time.Now()
+		}
+		ok := healthy
+		mu.Unlock()
+
+		if !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"status": "unavailable"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	})
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHealthHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus int
+	}{
+		{name: "healthy", status: http.StatusOK, wantStatus: http.StatusOK},
+		{name: "authentication failure", status: http.StatusForbidden, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v9/me" {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+				writeJSON(w, tt.status, testUser)
+			})
+
+			rec := httptest.NewRecorder()
+			HealthHandler(client).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHealthHandler_CachesAndHidesErrors(t *testing.T) {
+	var calls int
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeError(w, http.StatusForbidden, "token abc123 is invalid")
+	})
+	handler := HealthHandler(client)
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected 503, got %d", rec.Code)
+		}
+		if body := strings.TrimSpace(rec.Body.String()); body != `{"status":"unavailable"}` {
+			t.Errorf("expected the error to be left out, got %s", body)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the result to be cached, got %d API calls", calls)
+	}
+}
@@ -0,0 +1,888 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxRecurrencePeriods bounds how many periods of a recurrence rule are
+// walked, so that a rule without an end cannot run forever
+const maxRecurrencePeriods = 10000
+
+// calendarAttendee is an ATTENDEE or ORGANIZER of an event
+type calendarAttendee struct {
+	Email    string
+	Name     string
+	PartStat string
+}
+
+// calendarEvent is a VEVENT, or one occurrence of a recurring VEVENT
+type calendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Status      string
+	Attendees   []calendarAttendee
+	// Line is where the VEVENT begins in the calendar
+	Line int
+
+	rrule        string
+	exdates      []time.Time
+	recurrenceID time.Time
+	duration     *time.Duration
+	// rruleErr is why the event's RRULE could not be expanded
+	rruleErr error
+}
+
+// contentLine is an unfolded iCalendar property
+type contentLine struct {
+	line   int
+	name   string
+	params map[string]string
+	value  string
+}
+
+// windowsZones maps the Windows time zone names some calendar servers put in
+// TZID to IANA names
+var windowsZones = map[string]string{
+	"UTC":                            "UTC",
+	"GMT Standard Time":              "Europe/London",
+	"W. Europe Standard Time":        "Europe/Berlin",
+	"Romance Standard Time":          "Europe/Paris",
+	"Central Europe Standard Time":   "Europe/Budapest",
+	"Central European Standard Time": "Europe/Warsaw",
+	"E. Europe Standard Time":        "Europe/Bucharest",
+	"FLE Standard Time":              "Europe/Kiev",
+	"Eastern Standard Time":          "America/New_York",
+	"Central Standard Time":          "America/Chicago",
+	"Mountain Standard Time":         "America/Denver",
+	"Pacific Standard Time":          "America/Los_Angeles",
+	"India Standard Time":            "Asia/Kolkata",
+	"China Standard Time":            "Asia/Shanghai",
+	"Tokyo Standard Time":            "Asia/Tokyo",
+	"AUS Eastern Standard Time":      "Australia/Sydney",
+}
+
+// readContentLines unfolds the lines of an iCalendar stream and splits them
+// into name, parameters and value
+func readContentLines(r io.Reader) ([]contentLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	var raw []string
+	var starts []int
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(raw) > 0 {
+			raw[len(raw)-1] += text[1:]
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		raw = append(raw, text)
+		starts = append(starts, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading calendar: %w", err)
+	}
+
+	lines := make([]contentLine, 0, len(raw))
+	for i, text := range raw {
+		line, err := parseContentLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", starts[i], err)
+		}
+		line.line = starts[i]
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// parseContentLine splits NAME;PARAM=VALUE:VALUE, allowing quoted parameter
+// values to contain ':' and ';'
+func parseContentLine(text string) (contentLine, error) {
+	line := contentLine{params: make(map[string]string)}
+
+	inQuotes := false
+	colon := -1
+	for i, r := range text {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return line, fmt.Errorf("missing ':' in %q", text)
+	}
+	line.value = text[colon+1:]
+
+	parts := splitUnquoted(text[:colon], ';')
+	line.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		line.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return line, nil
+}
+
+// splitUnquoted splits s at sep outside double quotes
+func splitUnquoted(s string, sep rune) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unescapeText decodes an iCalendar TEXT value
+func unescapeText(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+// parseICS reads the VEVENTs of a calendar. Times without a zone are taken
+// in defaultLoc, as are times in zones that cannot be resolved.
+func parseICS(r io.Reader, defaultLoc *time.Location) ([]calendarEvent, error) {
+	lines, err := readContentLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := calendarZones(lines)
+	resolve := func(tzid string) *time.Location {
+		if tzid == "" {
+			return defaultLoc
+		}
+		if loc, ok := zones[tzid]; ok {
+			return loc
+		}
+		return defaultLoc
+	}
+
+	var events []calendarEvent
+	var event *calendarEvent
+	var depth int // nesting inside the current VEVENT, e.g. VALARM
+	for _, line := range lines {
+		switch {
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VEVENT"):
+			event = &calendarEvent{Line: line.line}
+			depth = 0
+			continue
+		case event == nil:
+			continue
+		case line.name == "BEGIN":
+			depth++
+			continue
+		case line.name == "END" && depth > 0:
+			depth--
+			continue
+		case depth > 0:
+			continue
+		case line.name == "END" && strings.EqualFold(line.value, "VEVENT"):
+			if event.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event has no DTSTART", event.Line)
+			}
+			if event.End.IsZero() && event.duration != nil {
+				event.End = event.Start.Add(*event.duration)
+			}
+			if event.End.IsZero() {
+				event.End = event.Start
+				if event.AllDay {
+					event.End = event.Start.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *event)
+			event = nil
+			continue
+		}
+
+		if err := event.setProperty(line, resolve); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.line, err)
+		}
+	}
+
+	return events, nil
+}
+
+// setProperty applies one property of a VEVENT
+func (e *calendarEvent) setProperty(line contentLine, resolve func(string) *time.Location) error {
+	var err error
+	switch line.name {
+	case "UID":
+		e.UID = line.value
+	case "SUMMARY":
+		e.Summary = unescapeText(line.value)
+	case "DESCRIPTION":
+		e.Description = unescapeText(line.value)
+	case "LOCATION":
+		e.Location = unescapeText(line.value)
+	case "STATUS":
+		e.Status = strings.ToUpper(line.value)
+	case "DTSTART":
+		e.Start, e.AllDay, err = parseICSTime(line.value, line.params, resolve)
+	case "DTEND":
+		e.End, _, err = parseICSTime(line.value, line.params, resolve)
+	case "DURATION":
+		var d time.Duration
+		if d, err = parseICSDuration(line.value); err == nil {
+			e.duration = &d
+		}
+	case "RRULE":
+		e.rrule = line.value
+	case "EXDATE":
+		for _, value := range strings.Split(line.value, ",") {
+			t, _, err := parseICSTime(value, line.params, resolve)
+			if err != nil {
+				return err
+			}
+			e.exdates = append(e.exdates, t)
+		}
+	case "RECURRENCE-ID":
+		e.recurrenceID, _, err = parseICSTime(line.value, line.params, resolve)
+	case "ATTENDEE", "ORGANIZER":
+		attendee := calendarAttendee{
+			Email:    strings.TrimPrefix(strings.TrimPrefix(line.value, "mailto:"), "MAILTO:"),
+			Name:     line.params["CN"],
+			PartStat: strings.ToUpper(line.params["PARTSTAT"]),
+		}
+		if line.name == "ORGANIZER" {
+			attendee.PartStat = "ACCEPTED"
+		}
+		e.Attendees = append(e.Attendees, attendee)
+	}
+	return err
+}
+
+// calendarZones resolves the TZIDs of a calendar's VTIMEZONEs: by IANA or
+// Windows name, or else as the fixed standard offset the calendar defines
+func calendarZones(lines []contentLine) map[string]*time.Location {
+	zones := make(map[string]*time.Location)
+
+	var tzid string
+	var inStandard bool
+	for _, line := range lines {
+		switch {
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VTIMEZONE"):
+			tzid = ""
+		case line.name == "TZID":
+			tzid = line.value
+			if loc := loadZone(tzid); loc != nil {
+				zones[tzid] = loc
+			}
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "STANDARD"):
+			inStandard = true
+		case line.name == "END" && strings.EqualFold(line.value, "STANDARD"):
+			inStandard = false
+		case line.name == "TZOFFSETTO" && inStandard && tzid != "" && zones[tzid] == nil:
+			if offset, err := parseUTCOffset(line.value); err == nil {
+				zones[tzid] = time.FixedZone(tzid, offset)
+			}
+		case (line.name == "DTSTART" || line.name == "DTEND" || line.name == "EXDATE" ||
+			line.name == "RECURRENCE-ID") && line.params["TZID"] != "":
+			// Some calendars reference zones without defining them
+			name := line.params["TZID"]
+			if _, ok := zones[name]; !ok {
+				if loc := loadZone(name); loc != nil {
+					zones[name] = loc
+				}
+			}
+		}
+	}
+
+	return zones
+}
+
+// loadZone resolves an IANA or Windows time zone name, or returns nil
+func loadZone(name string) *time.Location {
+	if iana, ok := windowsZones[name]; ok {
+		name = iana
+	}
+	loc, err := time.LoadLocation(strings.TrimPrefix(name, "/"))
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// parseUTCOffset parses +HHMM or -HHMM into seconds east of UTC
+func parseUTCOffset(value string) (int, error) {
+	if len(value) < 5 || (value[0] != '+' && value[0] != '-') {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+	hours, err1 := strconv.Atoi(value[1:3])
+	minutes, err2 := strconv.Atoi(value[3:5])
+	if err1 != nil || err2 != nil {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+	offset := hours*3600 + minutes*60
+	if value[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// parseICSTime parses a DATE or DATE-TIME value, reporting whether it is a
+// date. UTC times end in Z; others are in their TZID or the default zone.
+func parseICSTime(value string, params map[string]string, resolve func(string) *time.Location) (time.Time, bool, error) {
+	loc := resolve(params["TZID"])
+
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return t, true, fmt.Errorf("invalid date %q", value)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return t, false, fmt.Errorf("invalid time %q", value)
+		}
+		return t, false, nil
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return t, false, fmt.Errorf("invalid time %q", value)
+	}
+	return t, false, nil
+}
+
+// parseICSDuration parses a DURATION value such as PT1H30M or P1D
+func parseICSDuration(value string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid duration %q", value)
+
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign, value = -1, value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") {
+		return 0, invalid
+	}
+	value = value[1:]
+
+	units := map[byte]time.Duration{
+		'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+	}
+
+	var total time.Duration
+	inTime := false
+	number := ""
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == 'T':
+			inTime = true
+		case c >= '0' && c <= '9':
+			number += string(c)
+		default:
+			unit, ok := units[c]
+			if !ok || number == "" || (c == 'M' && !inTime) {
+				return 0, invalid
+			}
+			n, _ := strconv.Atoi(number)
+			total += time.Duration(n) * unit
+			number = ""
+		}
+	}
+	if number != "" {
+		return 0, invalid
+	}
+
+	return sign * total, nil
+}
+
+// recurrenceRule is a parsed RRULE. Only FREQ, INTERVAL, COUNT, UNTIL,
+// BYDAY and BYMONTHDAY are supported, and BYDAY and BYMONTHDAY only where
+// checkParts allows them.
+type recurrenceRule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []weekdayNum
+	byMonthDay []int
+}
+
+// weekdayNum is a BYDAY item such as MO, 2TU or -1FR; n is zero for every
+// matching weekday in the period
+type weekdayNum struct {
+	n       int
+	weekday time.Weekday
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRRule parses an RRULE value; UNTIL values without a zone are in loc
+func parseRRule(value string, loc *time.Location) (recurrenceRule, error) {
+	rule := recurrenceRule{interval: 1}
+	wkst := "MO"
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			rule.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid COUNT %q", val)
+			}
+			rule.count = n
+		case "UNTIL":
+			until, isDate, err := parseICSTime(val, nil, func(string) *time.Location { return loc })
+			if err != nil {
+				return rule, err
+			}
+			if isDate {
+				until = until.AddDate(0, 0, 1).Add(-time.Second)
+			}
+			rule.until = until
+		case "BYDAY":
+			for _, item := range strings.Split(val, ",") {
+				item = strings.ToUpper(item)
+				if len(item) < 2 {
+					return rule, fmt.Errorf("invalid BYDAY %q", val)
+				}
+				weekday, ok := icsWeekdays[item[len(item)-2:]]
+				if !ok {
+					return rule, fmt.Errorf("invalid BYDAY %q", val)
+				}
+				day := weekdayNum{weekday: weekday}
+				if prefix := item[:len(item)-2]; prefix != "" {
+					n, err := strconv.Atoi(prefix)
+					if err != nil || n == 0 {
+						return rule, fmt.Errorf("invalid BYDAY %q", val)
+					}
+					day.n = n
+				}
+				rule.byDay = append(rule.byDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(item)
+				if err != nil || n == 0 || n > 31 || n < -31 {
+					return rule, fmt.Errorf("invalid BYMONTHDAY %q", val)
+				}
+				rule.byMonthDay = append(rule.byMonthDay, n)
+			}
+		case "WKST":
+			// Weeks are taken to start on Monday; another start only
+			// changes weekly rules picking several days every few weeks
+			wkst = strings.ToUpper(val)
+		default:
+			return rule, fmt.Errorf("unsupported RRULE part %q", part)
+		}
+	}
+
+	switch rule.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return rule, fmt.Errorf("unsupported FREQ %q", rule.freq)
+	}
+	if err := rule.checkParts(); err != nil {
+		return rule, err
+	}
+	if _, ok := icsWeekdays[wkst]; !ok {
+		return rule, fmt.Errorf("invalid WKST %q", wkst)
+	}
+	if wkst != "MO" && rule.freq == "WEEKLY" && rule.interval > 1 && len(rule.byDay) > 1 {
+		return rule, fmt.Errorf("unsupported WKST %q", wkst)
+	}
+	return rule, nil
+}
+
+// checkParts rejects BYDAY and BYMONTHDAY combinations that occurrences
+// does not apply for the rule's FREQ, rather than expanding them wrongly
+func (rule recurrenceRule) checkParts() error {
+	numbered := false
+	for _, day := range rule.byDay {
+		numbered = numbered || day.n != 0
+	}
+	switch {
+	case rule.freq == "DAILY" && numbered:
+		return errors.New("BYDAY with a number is not allowed with FREQ=DAILY")
+	case rule.freq == "WEEKLY" && numbered:
+		return errors.New("BYDAY with a number is not allowed with FREQ=WEEKLY")
+	case rule.freq == "WEEKLY" && len(rule.byMonthDay) > 0:
+		return errors.New("BYMONTHDAY is not allowed with FREQ=WEEKLY")
+	case rule.freq == "MONTHLY" && len(rule.byDay) > 0 && len(rule.byMonthDay) > 0:
+		return errors.New("unsupported BYDAY together with BYMONTHDAY")
+	case rule.freq == "YEARLY" && len(rule.byDay) > 0:
+		return errors.New("unsupported BYDAY with FREQ=YEARLY")
+	case rule.freq == "YEARLY" && len(rule.byMonthDay) > 0:
+		return errors.New("unsupported BYMONTHDAY with FREQ=YEARLY")
+	}
+	return nil
+}
+
+// matchesDay reports whether a DAILY rule's BYDAY and BYMONTHDAY keep t
+func (rule recurrenceRule) matchesDay(t time.Time) bool {
+	if len(rule.byDay) > 0 {
+		found := false
+		for _, day := range rule.byDay {
+			found = found || day.weekday == t.Weekday()
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(rule.byMonthDay) > 0 {
+		daysInMonth := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		found := false
+		for _, d := range rule.byMonthDay {
+			if d < 0 {
+				d = daysInMonth + d + 1
+			}
+			found = found || d == t.Day()
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// occurrences returns the start times of the rule's occurrences, beginning
+// with start, that start before end
+func (rule recurrenceRule) occurrences(start, end time.Time) []time.Time {
+	loc := start.Location()
+	clock := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, start.Hour(), start.Minute(), start.Second(), 0, loc)
+	}
+
+	var result []time.Time
+	emitted := 0
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		var candidates []time.Time
+		switch rule.freq {
+		case "DAILY":
+			if t := clock(start.Year(), start.Month(), start.Day()+period*rule.interval); rule.matchesDay(t) {
+				candidates = []time.Time{t}
+			}
+		case "WEEKLY":
+			// Weeks start on Monday, the RFC 5545 default for WKST
+			offset := (int(start.Weekday()) + 6) % 7
+			monday := clock(start.Year(), start.Month(), start.Day()-offset+7*period*rule.interval)
+			days := rule.byDay
+			if len(days) == 0 {
+				days = []weekdayNum{{weekday: start.Weekday()}}
+			}
+			for _, day := range days {
+				candidates = append(candidates, monday.AddDate(0, 0, (int(day.weekday)+6)%7))
+			}
+		case "MONTHLY":
+			first := time.Date(start.Year(), start.Month()+time.Month(period*rule.interval), 1, 0, 0, 0, 0, loc)
+			candidates = rule.monthDays(first, start, clock)
+		case "YEARLY":
+			year := start.Year() + period*rule.interval
+			if t := clock(year, start.Month(), start.Day()); t.Day() == start.Day() {
+				candidates = []time.Time{t}
+			}
+		}
+
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+		for _, t := range candidates {
+			if t.Before(start) {
+				continue
+			}
+			if (!rule.until.IsZero() && t.After(rule.until)) || !t.Before(end) ||
+				(rule.count > 0 && emitted >= rule.count) {
+				return result
+			}
+			emitted++
+			result = append(result, t)
+		}
+	}
+
+	return result
+}
+
+// monthDays lists the days of the month starting at first that a MONTHLY
+// rule selects, defaulting to the day of month of start
+func (rule recurrenceRule) monthDays(first, start time.Time, clock func(int, time.Month, int) time.Time) []time.Time {
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+
+	var days []int
+	switch {
+	case len(rule.byDay) > 0:
+		for _, byDay := range rule.byDay {
+			var matching []int
+			for d := 1; d <= daysInMonth; d++ {
+				if first.AddDate(0, 0, d-1).Weekday() == byDay.weekday {
+					matching = append(matching, d)
+				}
+			}
+			switch {
+			case byDay.n == 0:
+				days = append(days, matching...)
+			case byDay.n > 0 && byDay.n <= len(matching):
+				days = append(days, matching[byDay.n-1])
+			case byDay.n < 0 && -byDay.n <= len(matching):
+				days = append(days, matching[len(matching)+byDay.n])
+			}
+		}
+	case len(rule.byMonthDay) > 0:
+		for _, d := range rule.byMonthDay {
+			if d < 0 {
+				d = daysInMonth + d + 1
+			}
+			if d >= 1 && d <= daysInMonth {
+				days = append(days, d)
+			}
+		}
+	default:
+		if start.Day() <= daysInMonth {
+			days = []int{start.Day()}
+		}
+	}
+
+	candidates := make([]time.Time, 0, len(days))
+	for _, d := range days {
+		candidates = append(candidates, clock(first.Year(), first.Month(), d))
+	}
+	return candidates
+}
+
+// expandEvents expands recurring events into their occurrences and returns
+// every occurrence overlapping [from, to), in start order. Occurrences
+// listed in EXDATE are dropped and those with a RECURRENCE-ID override are
+// replaced by the override. An event whose RRULE cannot be expanded is
+// returned once, unexpanded, with rruleErr set, so that it can be reported.
+func expandEvents(events []calendarEvent, from, to time.Time) []calendarEvent {
+	overrides := make(map[string]calendarEvent)
+	for _, event := range events {
+		if !event.recurrenceID.IsZero() {
+			overrides[event.UID+"|"+event.recurrenceID.UTC().Format(time.RFC3339)] = event
+		}
+	}
+
+	var result []calendarEvent
+	overlaps := func(e calendarEvent) bool {
+		if e.End.Equal(e.Start) {
+			return !e.Start.Before(from) && e.Start.Before(to)
+		}
+		return e.Start.Before(to) && e.End.After(from)
+	}
+
+	for _, event := range events {
+		if !event.recurrenceID.IsZero() {
+			if overlaps(event) {
+				result = append(result, event)
+			}
+			continue
+		}
+		if event.rrule == "" {
+			if overlaps(event) {
+				result = append(result, event)
+			}
+			continue
+		}
+
+		rule, err := parseRRule(event.rrule, event.Start.Location())
+		if err != nil {
+			if event.Start.Before(to) {
+				event.rruleErr = fmt.Errorf("RRULE: %w", err)
+				result = append(result, event)
+			}
+			continue
+		}
+
+		duration := event.End.Sub(event.Start)
+		for _, start := range rule.occurrences(event.Start, to) {
+			if containsTime(event.exdates, start) {
+				continue
+			}
+			if _, ok := overrides[event.UID+"|"+start.UTC().Format(time.RFC3339)]; ok {
+				continue
+			}
+
+			occurrence := event
+			occurrence.Start, occurrence.End = start, start.Add(duration)
+			if overlaps(occurrence) {
+				result = append(result, occurrence)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+	return result
+}
+
+// containsTime reports whether times contains t
+func containsTime(times []time.Time, t time.Time) bool {
+	for _, other := range times {
+		if other.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// icsProductID identifies this server as the producer of exported calendars
+const icsProductID = "-//toggl-mcp//Time entries//EN"
+
+// escapeText encodes s as an iCalendar TEXT value
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icsTime formats t as an iCalendar UTC date-time
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// icsWriter writes content lines ended with CRLF and folded so that no line
+// exceeds 75 octets, without splitting UTF-8 sequences. The first write
+// error is kept and reported by flush.
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (w *icsWriter) line(name, value string) {
+	if w.err != nil {
+		return
+	}
+	text := name + ":" + value
+	limit := 75
+	for len(text) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		if _, w.err = w.w.WriteString(text[:cut] + "\r\n "); w.err != nil {
+			return
+		}
+		text = text[cut:]
+		// continuation lines lose one octet to the leading space
+		limit = 74
+	}
+	_, w.err = w.w.WriteString(text + "\r\n")
+}
+
+func (w *icsWriter) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// writeICS renders entries as a calendar with one VEVENT per entry. UIDs are
+// derived from the entry ID, so re-exporting updates events instead of
+// duplicating them. Times are written in UTC; loc is only named as the
+// calendar's display zone. Running entries end at now and are TENTATIVE.
+func writeICS(w io.Writer, entries []TimeEntry, names exportNames, loc *time.Location, now time.Time) error {
+	iw := &icsWriter{w: bufio.NewWriter(w)}
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", icsProductID)
+	iw.line("CALSCALE", "GREGORIAN")
+	iw.line("X-WR-CALNAME", "Toggl time entries")
+	if name := loc.String(); name != "Local" && name != "UTC" {
+		iw.line("X-WR-TIMEZONE", name)
+	}
+
+	for _, entry := range entries {
+		running := entry.Duration < 0 || entry.Stop == nil
+		end := now
+		if !running {
+			end = *entry.Stop
+		}
+		stamp := entry.At
+		if stamp.IsZero() {
+			stamp = now
+		}
+
+		var project, client string
+		if entry.ProjectID != nil {
+			p := names.projects[*entry.ProjectID]
+			project = p.Name
+			if p.ClientID != nil {
+				client = names.clients[*p.ClientID]
+			}
+		}
+
+		var categories []string
+		if project != "" {
+			categories = append(categories, escapeText(project))
+		}
+		for _, tag := range entry.Tags {
+			categories = append(categories, escapeText(tag))
+		}
+
+		var details []string
+		if project != "" {
+			details = append(details, "Project: "+project)
+		}
+		if client != "" {
+			details = append(details, "Client: "+client)
+		}
+		if entry.Billable {
+			details = append(details, "Billable")
+		}
+		if running {
+			details = append(details, "Running; ends at the time of export")
+		}
+
+		iw.line("BEGIN", "VEVENT")
+		iw.line("UID", fmt.Sprintf("time-entry-%d@toggl-mcp", entry.ID))
+		iw.line("DTSTAMP", icsTime(stamp))
+		iw.line("DTSTART", icsTime(entry.Start))
+		iw.line("DTEND", icsTime(end))
+		iw.line("SUMMARY", escapeText(entry.Description))
+		if len(details) > 0 {
+			iw.line("DESCRIPTION", escapeText(strings.Join(details, "\n")))
+		}
+		if len(categories) > 0 {
+			iw.line("CATEGORIES", strings.Join(categories, ","))
+		}
+		if running {
+			iw.line("STATUS", "TENTATIVE")
+		} else {
+			iw.line("STATUS", "CONFIRMED")
+		}
+		iw.line("END", "VEVENT")
+	}
+
+	iw.line("END", "VCALENDAR")
+	if err := iw.flush(); err != nil {
+		return fmt.Errorf("writing calendar: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testICS = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VTIMEZONE
+TZID:Custom Zone
+BEGIN:STANDARD
+DTSTART:19701025T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Standup
+DTSTART;TZID=Europe/Berlin:20240311T093000
+DTEND;TZID=Europe/Berlin:20240311T094500
+RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=6
+EXDATE;TZID=Europe/Berlin:20240313T093000
+ATTENDEE;CN="Doe, Jane";PARTSTAT=ACCEPTED:mailto:jane@example.com
+BEGIN:VALARM
+TRIGGER:-PT5M
+DESCRIPTION:Reminder
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+RECURRENCE-ID;TZID=Europe/Berlin:20240315T093000
+SUMMARY:Standup (moved)
+DTSTART;TZID=Europe/Berlin:20240315T110000
+DURATION:PT15M
+END:VEVENT
+BEGIN:VEVENT
+UID:review
+SUMMARY:Design review\, round 2
+DESCRIPTION:Long description that is folded
+  across two lines
+DTSTART;TZID=Custom Zone:20240312T140000
+DTEND;TZID=Custom Zone:20240312T150000
+END:VEVENT
+BEGIN:VEVENT
+UID:offsite
+SUMMARY:Offsite
+DTSTART;VALUE=DATE:20240314
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestParseICS(t *testing.T) {
+	events, err := parseICS(strings.NewReader(testICS), time.UTC)
+	if err != nil {
+		t.Fatalf("parseICS failed: %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+
+	standup := events[0]
+	if standup.Line != 11 || standup.rrule == "" || len(standup.exdates) != 1 {
+		t.Errorf("unexpected standup: %+v", standup)
+	}
+	if len(standup.Attendees) != 1 || standup.Attendees[0].Name != "Doe, Jane" || standup.Attendees[0].Email != "jane@example.com" {
+		t.Errorf("unexpected attendees: %+v", standup.Attendees)
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	if !standup.Start.Equal(time.Date(2024, 3, 11, 9, 30, 0, 0, berlin)) {
+		t.Errorf("unexpected start: %v", standup.Start)
+	}
+
+	moved := events[1]
+	if moved.End.Sub(moved.Start) != 15*time.Minute {
+		t.Errorf("expected DURATION to set the end, got %v", moved.End.Sub(moved.Start))
+	}
+
+	review := events[2]
+	if review.Summary != "Design review, round 2" {
+		t.Errorf("expected unescaped summary, got %q", review.Summary)
+	}
+	if review.Description != "Long description that is folded across two lines" {
+		t.Errorf("expected unfolded description, got %q", review.Description)
+	}
+	if _, offset := review.Start.Zone(); offset != 3600 {
+		t.Errorf("expected the VTIMEZONE's standard offset, got %d", offset)
+	}
+
+	offsite := events[3]
+	if !offsite.AllDay || offsite.End.Sub(offsite.Start) != 24*time.Hour {
+		t.Errorf("unexpected all-day event: %+v", offsite)
+	}
+}
+
+func TestParseICSErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		ics     string
+		wantErr string
+	}{
+		{name: "missing colon", ics: "BEGIN:VEVENT\nSUMMARY\nEND:VEVENT\n", wantErr: "missing ':'"},
+		{name: "missing start", ics: "BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\n", wantErr: "no DTSTART"},
+		{name: "bad time", ics: "BEGIN:VEVENT\nDTSTART:2024-03-11\nEND:VEVENT\n", wantErr: "invalid time"},
+		{name: "bad duration", ics: "BEGIN:VEVENT\nDTSTART:20240311T090000Z\nDURATION:1H\nEND:VEVENT\n", wantErr: "invalid duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseICS(strings.NewReader(tt.ics), time.UTC)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExpandEvents(t *testing.T) {
+	events, err := parseICS(strings.NewReader(testICS), time.UTC)
+	if err != nil {
+		t.Fatalf("parseICS failed: %v", err)
+	}
+
+	from := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	expanded := expandEvents(events, from, to)
+
+	var got []string
+	for _, e := range expanded {
+		got = append(got, e.Start.UTC().Format("Mon 15:04")+" "+e.Summary)
+	}
+	want := []string{
+		"Mon 08:30 Standup",
+		"Tue 13:00 Design review, round 2",
+		"Thu 00:00 Offsite",
+		"Fri 10:00 Standup (moved)",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("unexpected occurrences:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		rrule string
+		start time.Time
+		end   time.Time
+		want  []string
+	}{
+		{
+			name:  "daily with interval and count",
+			rrule: "FREQ=DAILY;INTERVAL=2;COUNT=3",
+			start: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2024-03-01 09:00", "2024-03-03 09:00", "2024-03-05 09:00"},
+		},
+		{
+			name:  "daily on weekdays skips the weekend",
+			rrule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=3",
+			start: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+			end:   time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2026-10-16 09:00", "2026-10-19 09:00", "2026-10-20 09:00"},
+		},
+		{
+			name:  "daily on days of the month",
+			rrule: "FREQ=DAILY;BYMONTHDAY=1,-1",
+			start: time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2024-02-01 09:00", "2024-02-29 09:00", "2024-03-01 09:00"},
+		},
+		{
+			name:  "weekly keeps wall clock across DST",
+			rrule: "FREQ=WEEKLY;UNTIL=20240410T000000Z",
+			start: time.Date(2024, 3, 27, 9, 0, 0, 0, berlin),
+			end:   time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2024-03-27 09:00", "2024-04-03 09:00"},
+		},
+		{
+			name:  "monthly last friday",
+			rrule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			start: time.Date(2024, 1, 26, 16, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2024-01-26 16:00", "2024-02-23 16:00", "2024-03-29 16:00"},
+		},
+		{
+			name:  "monthly on the 31st skips short months",
+			rrule: "FREQ=MONTHLY;BYMONTHDAY=31",
+			start: time.Date(2024, 1, 31, 8, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2024-01-31 08:00", "2024-03-31 08:00", "2024-05-31 08:00"},
+		},
+		{
+			name:  "yearly on leap day",
+			rrule: "FREQ=YEARLY",
+			start: time.Date(2024, 2, 29, 8, 0, 0, 0, time.UTC),
+			end:   time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2024-02-29 08:00", "2028-02-29 08:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseRRule(tt.rrule, tt.start.Location())
+			if err != nil {
+				t.Fatalf("parseRRule failed: %v", err)
+			}
+
+			var got []string
+			for _, start := range rule.occurrences(tt.start, tt.end) {
+				got = append(got, start.Format("2006-01-02 15:04"))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRRuleErrors(t *testing.T) {
+	for _, rrule := range []string{"FREQ=HOURLY", "FREQ=DAILY;INTERVAL=0", "FREQ=WEEKLY;BYDAY=XX", "FREQ=MONTHLY;BYMONTHDAY=32", "FREQ=MONTHLY;BYSETPOS=-1", "FREQ=YEARLY;BYMONTH=3",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;WKST=SU", "FREQ=WEEKLY;WKST=XX",
+		"FREQ=DAILY;BYDAY=1MO", "FREQ=WEEKLY;BYMONTHDAY=15", "FREQ=WEEKLY;BYDAY=2TU", "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+		"FREQ=YEARLY;BYDAY=1MO", "FREQ=YEARLY;BYMONTHDAY=1"} {
+		if _, err := parseRRule(rrule, time.UTC); err == nil {
+			t.Errorf("expected error for %q", rrule)
+		}
+	}
+}
+
+func TestParseRRuleWKST(t *testing.T) {
+	for _, rrule := range []string{"FREQ=WEEKLY;WKST=SU", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;WKST=MO"} {
+		if _, err := parseRRule(rrule, time.UTC); err != nil {
+			t.Errorf("unexpected error for %q: %v", rrule, err)
+		}
+	}
+}
+
+func TestExpandEvents_UnsupportedRRule(t *testing.T) {
+	events := []calendarEvent{{
+		Summary: "Planning",
+		Start:   time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+		End:     time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Line:    7,
+		rrule:   "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+	}}
+
+	from := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	expanded := expandEvents(events, from, from.AddDate(0, 0, 7))
+	if len(expanded) != 1 || expanded[0].rruleErr == nil || !strings.Contains(expanded[0].rruleErr.Error(), "BYSETPOS") {
+		t.Fatalf("expected the event to be returned with its RRULE error, got %+v", expanded)
+	}
+	if later := expandEvents(events, from.AddDate(0, 0, -30), events[0].Start); len(later) != 0 {
+		t.Errorf("expected an event starting after the range to be left out, got %+v", later)
+	}
+}
+
+func TestParseICSDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"-PT15M":  -15 * time.Minute,
+		"P1DT2H":  26 * time.Hour,
+	}
+	for value, want := range tests {
+		got, err := parseICSDuration(value)
+		if err != nil || got != want {
+			t.Errorf("parseICSDuration(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	start := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	stop := start.Add(90 * time.Minute)
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	entries := []TimeEntry{
+		{
+			BaseEntity:  BaseEntity{ID: 1, At: start},
+			Description: "Review; part 1, " + strings.Repeat("very long ", 10),
+			ProjectID:   intPtr(111),
+			Start:       start,
+			Stop:        &stop,
+			Duration:    5400,
+			Tags:        []string{"code", "über"},
+		},
+		{
+			BaseEntity:  BaseEntity{ID: 2},
+			Description: "Standup",
+			Start:       stop,
+			Duration:    -1,
+		},
+	}
+	names := exportNames{projects: map[int]Project{111: {Name: "Test Project"}}}
+	berlin := time.FixedZone("Europe/Berlin", 3600)
+
+	var buf strings.Builder
+	if err := writeICS(&buf, entries, names, berlin, now); err != nil {
+		t.Fatalf("writeICS failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+	for _, want := range []string{
+		"UID:time-entry-1@toggl-mcp\r\n",
+		"DTSTART:20240315T090000Z\r\n",
+		"CATEGORIES:Test Project,code,über\r\n",
+		"X-WR-TIMEZONE:Europe/Berlin\r\n",
+		"DTEND:20240315T120000Z\r\nSUMMARY:Standup\r\n",
+		"STATUS:TENTATIVE\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	events, err := parseICS(strings.NewReader(out), time.UTC)
+	if err != nil {
+		t.Fatalf("parseICS failed on exported calendar: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Summary != entries[0].Description || !events[0].End.Equal(stop) {
+		t.Errorf("event does not round-trip: %+v", events[0])
+	}
+	if events[1].Status != "TENTATIVE" || !events[1].End.Equal(now) {
+		t.Errorf("expected running entry to end now, got %+v", events[1])
+	}
+}
@@ -0,0 +1,700 @@
+package app
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxImportRows bounds how many rows a single import may create
+	maxImportRows = 1000
+	// maxImportFileSize bounds the size of an imported file
+	maxImportFileSize = 5 << 20
+)
+
+// importFields are the time entry fields CSV columns can map to. They match
+// the export columns, so an export can be imported again, plus task.
+var importFields = []string{
+	"date", "start", "stop", "duration_hours", "description", "project", "project_id", "task", "tags", "billable",
+}
+
+// Import row statuses
+const (
+	ImportReady     = "ready"
+	ImportDuplicate = "duplicate"
+	ImportInvalid   = "invalid"
+	ImportCreated   = "created"
+	ImportFailed    = "failed"
+)
+
+// ImportRow is the outcome of importing one CSV row
+type ImportRow struct {
+	Line        int               `json:"line,omitempty"`
+	Status      string            `json:"status"`
+	Error       string            `json:"error,omitempty"`
+	DuplicateOf int               `json:"duplicate_of,omitempty"`
+	EntryID     int               `json:"entry_id,omitempty"`
+	Entry       *TimeEntryRequest `json:"entry,omitempty"`
+	Project     string            `json:"project,omitempty"`
+	// rawDuration is the duration before rounding, zero when not rounded
+	rawDuration int
+}
+
+// ImportResult reports every row of an import, or of its preview
+type ImportResult struct {
+	Preview bool        `json:"preview"`
+	Rows    []ImportRow `json:"rows"`
+}
+
+// counts tallies the rows by status
+func (r ImportResult) counts() map[string]int {
+	counts := make(map[string]int)
+	for _, row := range r.Rows {
+		counts[row.Status]++
+	}
+	return counts
+}
+
+// importRecord is a CSV row keyed by field name
+type importRecord struct {
+	line   int
+	fields map[string]string
+}
+
+// readImportCSV reads CSV with a header row. Columns are matched to fields
+// through mapping (header to field), then by name, ignoring case and treating
+// spaces as underscores; other columns are ignored.
+func readImportCSV(r io.Reader, mapping map[string]string) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV is empty")
+		}
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+
+	normalized := make(map[string]string, len(mapping))
+	for column, field := range mapping {
+		if !containsString(importFields, field) {
+			return nil, fmt.Errorf("column %q maps to unknown field %q (available: %s)",
+				column, field, strings.Join(importFields, ", "))
+		}
+		normalized[normalizeColumn(column)] = field
+	}
+
+	fields := make([]string, len(header))
+	for i, column := range header {
+		name := normalizeColumn(column)
+		if field, ok := normalized[name]; ok {
+			fields[i] = field
+		} else if containsString(importFields, name) {
+			fields[i] = name
+		}
+	}
+
+	var records []importRecord
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		record := importRecord{line: line, fields: make(map[string]string)}
+		blank := true
+		for i, value := range values {
+			value = importCell(value)
+			if value != "" {
+				blank = false
+			}
+			if i < len(fields) && fields[i] != "" {
+				record.fields[fields[i]] = value
+			}
+		}
+		if blank {
+			continue
+		}
+
+		if len(records) == maxImportRows {
+			return nil, fmt.Errorf("CSV has more than %d rows; split it into smaller imports", maxImportRows)
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// importCell trims a CSV cell and removes the "'" an export puts before
+// text a spreadsheet would read as a formula
+func importCell(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(value[1])) {
+		value = strings.TrimSpace(value[1:])
+	}
+	return value
+}
+
+// normalizeColumn lowercases a column name and turns spaces into underscores
+func normalizeColumn(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+}
+
+// parseImportRecord turns a record into a time entry request, leaving the
+// project name, and the task, to be resolved by the caller. Times without a date are taken
+// on the row's date in loc.
+func parseImportRecord(fields map[string]string, loc *time.Location) (TimeEntryRequest, string, error) {
+	entry := TimeEntryRequest{
+		Description: fields["description"],
+		CreatedWith: "toggl-mcp",
+	}
+
+	var day time.Time
+	if value := fields["date"]; value != "" {
+		var err error
+		if day, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			return entry, "", fmt.Errorf("date %q must be YYYY-MM-DD", value)
+		}
+	}
+
+	start, err := parseImportTime(fields["start"], day, loc)
+	if err != nil {
+		return entry, "", fmt.Errorf("start: %w", err)
+	}
+	if start.IsZero() {
+		return entry, "", errors.New("start is required")
+	}
+	entry.Start = start
+
+	switch {
+	case fields["stop"] != "":
+		stop, err := parseImportTime(fields["stop"], start, loc)
+		if err != nil {
+			return entry, "", fmt.Errorf("stop: %w", err)
+		}
+		// A bare stop time earlier than the start is on the next day
+		if stop.Before(start) && !strings.Contains(fields["stop"], "T") {
+			stop = stop.AddDate(0, 0, 1)
+		}
+		entry.Duration = int(stop.Sub(start).Seconds())
+	case fields["duration_hours"] != "":
+		hours, err := parseHours(fields["duration_hours"])
+		if err != nil {
+			return entry, "", fmt.Errorf("duration_hours: %w", err)
+		}
+		entry.Duration = int(hours * 3600)
+	default:
+		return entry, "", errors.New("stop or duration_hours is required")
+	}
+	if entry.Duration <= 0 {
+		return entry, "", errors.New("duration must be positive")
+	}
+
+	if value := fields["project_id"]; value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return entry, "", fmt.Errorf("project_id %q must be a number", value)
+		}
+		entry.ProjectID = &id
+	}
+
+	if value := fields["tags"]; value != "" {
+		for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				entry.Tags = append(entry.Tags, tag)
+			}
+		}
+	}
+
+	if value := fields["billable"]; value != "" {
+		switch strings.ToLower(value) {
+		case "yes", "y":
+			entry.Billable = true
+		case "no", "n":
+		default:
+			if entry.Billable, err = strconv.ParseBool(value); err != nil {
+				return entry, "", fmt.Errorf("billable %q must be true or false", value)
+			}
+		}
+	}
+
+	return entry, fields["project"], nil
+}
+
+// parseImportTime parses an RFC 3339 timestamp, or a time of day (15:04 or
+// 15:04:05) on the date of day
+func parseImportTime(value string, day time.Time, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		clock, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		if day.IsZero() {
+			return time.Time{}, fmt.Errorf("time %q needs a date column", value)
+		}
+		day = day.In(loc)
+		return time.Date(day.Year(), day.Month(), day.Day(),
+			clock.Hour(), clock.Minute(), clock.Second(), 0, loc), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is neither RFC 3339 nor HH:MM", value)
+}
+
+// parseHours parses decimal hours ("1.5") or hours and minutes ("1:30")
+func parseHours(value string) (float64, error) {
+	if h, m, ok := strings.Cut(value, ":"); ok {
+		hours, err1 := strconv.Atoi(h)
+		minutes, err2 := strconv.Atoi(m)
+		if err1 != nil || err2 != nil || minutes < 0 || minutes >= 60 {
+			return 0, fmt.Errorf("%q must be decimal hours or H:MM", value)
+		}
+		return float64(hours) + float64(minutes)/60, nil
+	}
+
+	hours, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q must be decimal hours or H:MM", value)
+	}
+	return hours, nil
+}
+
+// prepareImport validates records, resolves project, task and tag names in the
+// workspace, rounds durations and marks rows that duplicate existing entries
+// or earlier rows
+func (c *TogglClient) prepareImport(
+	ctx context.Context,
+	workspaceID int,
+	records []importRecord,
+	rounding Rounding,
+) ([]ImportRow, error) {
+	loc := c.timeZone()
+
+	lookup, err := c.newImportLookup(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]ImportRow, len(records))
+	for i, record := range records {
+		rows[i].Line = record.line
+
+		entry, projectName, err := parseImportRecord(record.fields, loc)
+		if err != nil {
+			rows[i].Status, rows[i].Error = ImportInvalid, err.Error()
+			continue
+		}
+		lookup.resolve(&rows[i], entry, projectName)
+		lookup.resolveTask(ctx, &rows[i], record.fields["task"])
+	}
+	roundImportRows(rows, rounding)
+
+	if err := c.markDuplicates(ctx, rows, loc); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// importLookup resolves project, task and tag names in the workspace
+// imported into. Each project's tasks are listed once, when first needed.
+type importLookup struct {
+	client       *TogglClient
+	workspaceID  int
+	projectIDs   map[string]int
+	projectNames map[int]string
+	tagNames     map[string]string
+	tasks        map[int][]Task
+}
+
+// newImportLookup lists the workspace's projects, archived ones included,
+// and tags. A name shared by an active and an archived project means the
+// active one.
+func (c *TogglClient) newImportLookup(ctx context.Context, workspaceID int) (*importLookup, error) {
+	projects, err := c.GetAllProjects(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("getting projects: %w", err)
+	}
+	tags, err := c.GetTags(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("getting tags: %w", err)
+	}
+
+	activeProjects := make(map[int]bool, len(projects))
+	for _, project := range projects {
+		activeProjects[project.ID] = project.Active
+	}
+
+	lookup := &importLookup{
+		client:       c,
+		workspaceID:  workspaceID,
+		projectIDs:   make(map[string]int, len(projects)),
+		projectNames: make(map[int]string, len(projects)),
+		tagNames:     make(map[string]string, len(tags)),
+		tasks:        make(map[int][]Task),
+	}
+	for _, project := range projects {
+		key := strings.ToLower(project.Name)
+		if id, ok := lookup.projectIDs[key]; !ok || (project.Active && !activeProjects[id]) {
+			lookup.projectIDs[key] = project.ID
+		}
+		lookup.projectNames[project.ID] = project.Name
+	}
+	for _, tag := range tags {
+		lookup.tagNames[strings.ToLower(tag.Name)] = tag.Name
+	}
+	return lookup, nil
+}
+
+// resolve assigns entry to row, with the named project looked up, marking
+// the row ready or, for an unknown project, invalid
+func (l *importLookup) resolve(row *ImportRow, entry TimeEntryRequest, projectName string) {
+	entry.WorkspaceID = l.workspaceID
+
+	if projectName != "" && entry.ProjectID == nil {
+		id, ok := l.projectIDs[strings.ToLower(projectName)]
+		if !ok {
+			row.Status, row.Error = ImportInvalid, fmt.Sprintf("unknown project %q", projectName)
+			return
+		}
+		entry.ProjectID = &id
+	}
+	if entry.ProjectID != nil {
+		row.Project = l.projectNames[*entry.ProjectID]
+	}
+
+	// Reuse the spelling of existing tags rather than creating near-duplicates
+	for i, tag := range entry.Tags {
+		if name, ok := l.tagNames[strings.ToLower(tag)]; ok {
+			entry.Tags[i] = name
+		}
+	}
+
+	row.Status, row.Entry = ImportReady, &entry
+}
+
+// resolveTask sets the task of a ready row, given by name or ID, marking
+// the row invalid when its project has no such task
+func (l *importLookup) resolveTask(ctx context.Context, row *ImportRow, task string) {
+	if row.Status != ImportReady || task == "" {
+		return
+	}
+	projectID := row.Entry.ProjectID
+	if projectID == nil {
+		row.Status, row.Error = ImportInvalid, fmt.Sprintf("task %q needs a project", task)
+		return
+	}
+	tasks, ok := l.tasks[*projectID]
+	if !ok {
+		var err error
+		if tasks, err = l.client.GetTasks(ctx, l.workspaceID, *projectID, nil); err != nil {
+			row.Status, row.Error = ImportInvalid, fmt.Sprintf("getting tasks: %s", err)
+			if tasksUnavailable(err) {
+				row.Error = fmt.Sprintf("tasks are not available in workspace %d", l.workspaceID)
+			}
+			return
+		}
+		l.tasks[*projectID] = tasks
+	}
+
+	id, err := strconv.Atoi(task)
+	for _, t := range tasks {
+		if (err == nil && t.ID == id) || (err != nil && strings.EqualFold(t.Name, task)) {
+			row.Entry.TaskID = &t.ID
+			return
+		}
+	}
+	row.Status, row.Error = ImportInvalid, fmt.Sprintf("no task %q in project %q", task, row.Project)
+}
+
+// markDuplicates marks ready rows that match an existing entry, looked up
+// over the days the rows span in loc, or an earlier ready row. Rounded rows
+// match with either their rounded or their original duration.
+func (c *TogglClient) markDuplicates(ctx context.Context, rows []ImportRow, loc *time.Location) error {
+	var first, last time.Time
+	for _, row := range rows {
+		if row.Status != ImportReady {
+			continue
+		}
+		if first.IsZero() || row.Entry.Start.Before(first) {
+			first = row.Entry.Start
+		}
+		if row.Entry.Start.After(last) {
+			last = row.Entry.Start
+		}
+	}
+	if first.IsZero() {
+		return nil
+	}
+
+	first, last = first.In(loc), last.In(loc)
+	existing, err := c.GetTimeEntries(ctx,
+		time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc),
+		time.Date(last.Year(), last.Month(), last.Day()+1, 0, 0, 0, 0, loc))
+	if err != nil {
+		return fmt.Errorf("getting existing time entries: %w", err)
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Status != ImportReady {
+			continue
+		}
+		for _, entry := range existing {
+			if row.duplicates(entry.Start, entry.Duration, entry.Description) {
+				row.Status, row.DuplicateOf = ImportDuplicate, entry.ID
+				break
+			}
+		}
+		for _, earlier := range rows[:i] {
+			if row.Status == ImportReady && earlier.Status == ImportReady &&
+				row.duplicates(earlier.Entry.Start, earlier.Entry.Duration, earlier.Entry.Description) {
+				row.Status, row.Error = ImportDuplicate, fmt.Sprintf("same as line %d", earlier.Line)
+			}
+		}
+	}
+
+	return nil
+}
+
+// duplicates reports whether the row's entry, with its rounded or original
+// duration, matches an entry with the given start, duration and description
+func (row ImportRow) duplicates(start time.Time, duration int, description string) bool {
+	if isDuplicateEntry(*row.Entry, start, duration, description) {
+		return true
+	}
+	if row.rawDuration == 0 {
+		return false
+	}
+	raw := *row.Entry
+	raw.Duration = row.rawDuration
+	return isDuplicateEntry(raw, start, duration, description)
+}
+
+// isDuplicateEntry reports whether entry matches an entry with the given
+// start, duration and description, to within a minute
+func isDuplicateEntry(entry TimeEntryRequest, start time.Time, duration int, description string) bool {
+	return absDuration(entry.Start.Sub(start)) < time.Minute &&
+		absDuration(time.Duration(entry.Duration-duration)*time.Second) <= time.Minute &&
+		strings.EqualFold(strings.TrimSpace(entry.Description), strings.TrimSpace(description))
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// createImportRows creates the ready rows one by one, recording each outcome
+func (c *TogglClient) createImportRows(ctx context.Context, workspaceID int, rows []ImportRow) {
+	for i := range rows {
+		row := &rows[i]
+		if row.Status != ImportReady {
+			continue
+		}
+
+		created, err := c.CreateTimeEntry(ctx, workspaceID, *row.Entry)
+		if err != nil {
+			row.Status, row.Error = ImportFailed, err.Error()
+			continue
+		}
+		row.Status, row.EntryID = ImportCreated, created.ID
+	}
+}
+
+// formatImportResult describes each row and totals the outcomes
+func formatImportResult(result ImportResult, loc *time.Location) string {
+	var text strings.Builder
+	if result.Preview {
+		text.WriteString(fmt.Sprintf("Preview of %d rows (nothing has been created; call again with confirm=true to import):\n",
+			len(result.Rows)))
+	} else {
+		text.WriteString(fmt.Sprintf("Imported %d rows:\n", len(result.Rows)))
+	}
+
+	for _, row := range result.Rows {
+		text.WriteString(fmt.Sprintf("- Line %d: ", row.Line))
+		if row.Entry != nil {
+			start := row.Entry.Start.In(loc)
+			stop := start.Add(time.Duration(row.Entry.Duration) * time.Second)
+			text.WriteString(fmt.Sprintf("%s %s-%s %s", start.Format("2006-01-02"),
+				start.Format("15:04"), stop.Format("15:04"), row.Entry.Description))
+			if row.Project != "" {
+				text.WriteString(fmt.Sprintf(" [%s]", row.Project))
+			}
+			text.WriteString(" - ")
+		}
+
+		text.WriteString(importStatusText(row) + "\n")
+	}
+
+	text.WriteString("Summary: " + importSummary(result.Rows) + "\n")
+	return text.String()
+}
+
+// importStatusText describes the outcome of one row
+func importStatusText(row ImportRow) string {
+	switch row.Status {
+	case ImportDuplicate:
+		if row.DuplicateOf != 0 {
+			return fmt.Sprintf("duplicate of entry %d, skipped", row.DuplicateOf)
+		}
+		return fmt.Sprintf("duplicate (%s), skipped", row.Error)
+	case ImportCreated:
+		return fmt.Sprintf("created (ID: %d)", row.EntryID)
+	case ImportInvalid, ImportFailed:
+		return fmt.Sprintf("%s: %s", row.Status, row.Error)
+	default:
+		return row.Status
+	}
+}
+
+// importSummary totals the rows by outcome, e.g. "2 created, 1 failed"
+func importSummary(rows []ImportRow) string {
+	counts := ImportResult{Rows: rows}.counts()
+	var summary []string
+	for _, status := range []string{ImportReady, ImportCreated, ImportDuplicate, ImportInvalid, ImportFailed} {
+		if counts[status] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	if len(summary) == 0 {
+		return "no rows"
+	}
+	return strings.Join(summary, ", ")
+}
+
+// importTools returns the CSV import tool, which may read files in allowedDirs
+func importTools(client *TogglClient, allowedDirs []string) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"import_time_entries",
+				mcp.WithDescription("Import time entries from CSV with a header row. Columns are matched by name to: "+
+					strings.Join(importFields, ", ")+
+					". Without confirm=true only a preview is returned; with it, the ready rows are created and each row's outcome is reported. Rows matching an existing entry or an earlier row are skipped as duplicates."),
+				mcp.WithString("csv", mcp.Description("CSV text; give either csv or path")),
+				mcp.WithString("path", mcp.Description("CSV file to read; must be inside an allowed directory")),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
+				mcp.WithObject("columns",
+					mcp.Description("Maps CSV column names to fields, for columns not named after a field, e.g. {\"Hours\": \"duration_hours\"}"),
+					mcp.AdditionalProperties(map[string]interface{}{"type": "string", "enum": importFields}),
+				),
+				mcp.WithBoolean("round", mcp.Description(roundDescription)),
+				mcp.WithBoolean("confirm", mcp.Description("Create the entries; otherwise only preview them")),
+			),
+			handler: wrapHandler(client, handleImportTimeEntries(allowedDirs)),
+		},
+	}
+}
+
+func handleImportTimeEntries(allowedDirs []string) func(
+	context.Context,
+	*TogglClient,
+	mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		workspaceID, err := getWorkspaceID(req.GetArguments(), client)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace_id: %w", err)
+		}
+		rounding, err := requestedRounding(ctx, req.GetArguments(), "round")
+		if err != nil {
+			return nil, err
+		}
+
+		mapping := make(map[string]string)
+		if raw, ok := req.GetArguments()["columns"].(map[string]interface{}); ok {
+			for column, field := range raw {
+				name, ok := field.(string)
+				if !ok {
+					return nil, fmt.Errorf("columns must map column names to field names")
+				}
+				mapping[column] = name
+			}
+		}
+
+		text := getOptionalString(req.GetArguments(), "csv")
+		path := getOptionalString(req.GetArguments(), "path")
+		switch {
+		case text != "" && path != "":
+			return mcp.NewToolResultError("Give either csv or path, not both"), nil
+		case path != "":
+			if text, err = readImportFile(allowedDirs, path); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Cannot read import file: %s", err)), nil
+			}
+		case text == "":
+			return mcp.NewToolResultError("Give the CSV as csv text or as a path"), nil
+		}
+
+		records, err := readImportCSV(strings.NewReader(text), mapping)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid CSV: %s", err)), nil
+		}
+
+		rows, err := client.prepareImport(ctx, workspaceID, records, rounding)
+		if err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to prepare import: %s", apiErr.Error())), nil
+			}
+			return nil, fmt.Errorf("preparing import: %w", err)
+		}
+
+		result := ImportResult{Preview: !getOptionalBool(req.GetArguments(), "confirm"), Rows: rows}
+		if !result.Preview {
+			client.createImportRows(ctx, workspaceID, result.Rows)
+		}
+
+		if wantsJSON(ctx) {
+			return jsonResult(result)
+		}
+
+		loc := client.timeZone()
+		return mcp.NewToolResultText(formatImportResult(result, loc)), nil
+	}
+}
+
+// readImportFile reads a CSV file inside allowedDirs
+func readImportFile(allowedDirs []string, path string) (string, error) {
+	resolved, err := allowedPath(allowedDirs, path)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(resolved)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxImportFileSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxImportFileSize {
+		return "", fmt.Errorf("file is larger than %d MB", maxImportFileSize>>20)
+	}
+	return string(data), nil
+}
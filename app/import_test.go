@@ -0,0 +1,366 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestReadImportCSV(t *testing.T) {
+	input := "Date,Task,Hours,Client\n2024-03-15,Review,1.5,Acme\n,,,\n2024-03-16,Docs,2,Acme\n"
+
+	records, err := readImportCSV(strings.NewReader(input), map[string]string{
+		"Task":  "description",
+		"Hours": "duration_hours",
+	})
+	if err != nil {
+		t.Fatalf("readImportCSV failed: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected blank rows to be skipped, got %d records", len(records))
+	}
+	if records[1].line != 4 {
+		t.Errorf("expected line 4, got %d", records[1].line)
+	}
+	want := map[string]string{"date": "2024-03-15", "description": "Review", "duration_hours": "1.5"}
+	for field, value := range want {
+		if records[0].fields[field] != value {
+			t.Errorf("expected %s=%q, got %q", field, value, records[0].fields[field])
+		}
+	}
+	if _, ok := records[0].fields["client"]; ok {
+		t.Error("expected unknown columns to be ignored")
+	}
+
+	if _, err := readImportCSV(strings.NewReader(input), map[string]string{"Hours": "minutes"}); err == nil {
+		t.Error("expected error for mapping to an unknown field")
+	}
+}
+
+func TestReadImportCSV_FormulaQuotes(t *testing.T) {
+	input := "date,description,tags\n2024-03-15," + csvValue("=1+2") + "," + csvValue([]string{"-x", "y"}) + "\n2024-03-16,'quoted,\n"
+
+	records, err := readImportCSV(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("readImportCSV failed: %v", err)
+	}
+	if got := records[0].fields["description"]; got != "=1+2" {
+		t.Errorf("expected the export's quote to be removed, got %q", got)
+	}
+	if got := records[0].fields["tags"]; got != "-x;y" {
+		t.Errorf("expected the tags' quote to be removed, got %q", got)
+	}
+	if got := records[1].fields["description"]; got != "'quoted" {
+		t.Errorf("expected other quotes to be kept, got %q", got)
+	}
+}
+
+func TestParseImportRecord(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+
+	tests := []struct {
+		name         string
+		fields       map[string]string
+		wantStart    time.Time
+		wantDuration int
+		wantProject  string
+		wantErr      string
+	}{
+		{
+			name:         "date with clock times",
+			fields:       map[string]string{"date": "2024-03-15", "start": "09:00", "stop": "10:30", "project": "Website"},
+			wantStart:    time.Date(2024, 3, 15, 9, 0, 0, 0, loc),
+			wantDuration: 5400,
+			wantProject:  "Website",
+		},
+		{
+			name:         "overnight stop",
+			fields:       map[string]string{"date": "2024-03-15", "start": "23:00", "stop": "01:00"},
+			wantStart:    time.Date(2024, 3, 15, 23, 0, 0, 0, loc),
+			wantDuration: 7200,
+		},
+		{
+			name:         "rfc3339 start with hours and minutes",
+			fields:       map[string]string{"start": "2024-03-15T09:00:00Z", "duration_hours": "1:45"},
+			wantStart:    time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC),
+			wantDuration: 6300,
+		},
+		{name: "missing start", fields: map[string]string{"date": "2024-03-15", "duration_hours": "1"}, wantErr: "start is required"},
+		{name: "clock time without date", fields: map[string]string{"start": "09:00", "duration_hours": "1"}, wantErr: "needs a date"},
+		{name: "missing duration", fields: map[string]string{"start": "2024-03-15T09:00:00Z"}, wantErr: "stop or duration_hours"},
+		{name: "bad billable", fields: map[string]string{"start": "2024-03-15T09:00:00Z", "duration_hours": "1", "billable": "maybe"}, wantErr: "billable"},
+		{name: "zero duration", fields: map[string]string{"start": "2024-03-15T09:00:00Z", "duration_hours": "0"}, wantErr: "positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, project, err := parseImportRecord(tt.fields, loc)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !entry.Start.Equal(tt.wantStart) {
+				t.Errorf("expected start %v, got %v", tt.wantStart, entry.Start)
+			}
+			if entry.Duration != tt.wantDuration {
+				t.Errorf("expected duration %d, got %d", tt.wantDuration, entry.Duration)
+			}
+			if project != tt.wantProject {
+				t.Errorf("expected project %q, got %q", tt.wantProject, project)
+			}
+		})
+	}
+}
+
+// importHandler serves one archived project, one tag and one existing entry, and
+// counts created entries
+func importHandler(created *int32) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v9/workspaces/456/projects":
+			writeArchivedProjects(w, r, testProject)
+		case r.URL.Path == "/api/v9/workspaces/456/projects/111/tasks":
+			writeJSON(w, http.StatusOK, testTasks)
+		case r.URL.Path == "/api/v9/workspaces/456/tags":
+			writeJSON(w, http.StatusOK, []Tag{{BaseEntity: BaseEntity{ID: 1}, Name: "Meeting"}})
+		case r.URL.Path == "/api/v9/me/time_entries":
+			writeJSON(w, http.StatusOK, []TimeEntry{{
+				BaseEntity:  BaseEntity{ID: 555, WorkspaceID: 456},
+				Description: "Standup",
+				Start:       time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC),
+				Duration:    900,
+			}})
+		case r.URL.Path == "/api/v9/workspaces/456/time_entries" && r.Method == http.MethodPost:
+			var req TimeEntryRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Description == "Fails" {
+				writeError(w, http.StatusBadRequest, "bad entry")
+				return
+			}
+			n := atomic.AddInt32(created, 1)
+			writeJSON(w, http.StatusOK, TimeEntry{BaseEntity: BaseEntity{ID: 1000 + int(n)}, Description: req.Description})
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	}
+}
+
+const importCSV = `date,start,stop,description,project,tags
+2024-03-15,09:00,09:15,Standup,,meeting
+2024-03-15,10:00,11:30,Review,test project,meeting;code
+2024-03-15,10:00,11:30,Review,Test Project,
+2024-03-15,12:00,13:00,Lunch talk,Unknown,
+2024-03-15,14:00,15:00,Fails,,
+`
+
+func TestHandleImportTimeEntries(t *testing.T) {
+	var created int32
+	_, client := testServer(t, importHandler(&created))
+	client.location = time.UTC
+
+	call := func(args map[string]interface{}) string {
+		t.Helper()
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		result, err := handleImportTimeEntries(nil)(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	preview := call(map[string]interface{}{"csv": importCSV, "workspace_id": float64(456)})
+	if created != 0 {
+		t.Fatalf("preview created %d entries", created)
+	}
+	for _, want := range []string{
+		"Preview of 5 rows",
+		"Line 2: 2024-03-15 09:00-09:15 Standup - duplicate of entry 555, skipped",
+		"Line 3: 2024-03-15 10:00-11:30 Review [Test Project] - ready",
+		"Line 4: 2024-03-15 10:00-11:30 Review [Test Project] - duplicate (same as line 3), skipped",
+		`Line 5: invalid: unknown project "Unknown"`,
+		"Summary: 2 ready, 2 duplicate, 1 invalid",
+	} {
+		if !strings.Contains(preview, want) {
+			t.Errorf("expected preview to contain %q, got:\n%s", want, preview)
+		}
+	}
+
+	result := call(map[string]interface{}{"csv": importCSV, "workspace_id": float64(456), "confirm": true})
+	if created != 1 {
+		t.Errorf("expected 1 created entry, got %d", created)
+	}
+	for _, want := range []string{
+		"Line 3: 2024-03-15 10:00-11:30 Review [Test Project] - created (ID: 1001)",
+		"Line 6: 2024-03-15 14:00-15:00 Fails - failed:",
+		"Summary: 1 created, 2 duplicate, 1 invalid, 1 failed",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected result to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestImportLookupPrefersActiveProjects(t *testing.T) {
+	active := testProject
+	active.ID = 112
+	active.Active = true
+	archived := testProject
+	archived.Active = false
+
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/workspaces/456/projects":
+			writeJSON(w, http.StatusOK, []Project{active, archived, {BaseEntity: BaseEntity{ID: 113}, Name: "Old"}})
+		default:
+			writeJSON(w, http.StatusOK, []Tag{})
+		}
+	})
+
+	lookup, err := client.newImportLookup(context.Background(), 456)
+	if err != nil {
+		t.Fatalf("newImportLookup failed: %v", err)
+	}
+	if id := lookup.projectIDs["test project"]; id != 112 {
+		t.Errorf("expected the active project, got %d", id)
+	}
+	if id := lookup.projectIDs["old"]; id != 113 {
+		t.Errorf("expected the archived project, got %d", id)
+	}
+}
+
+func TestImportResolvesTagSpelling(t *testing.T) {
+	var created int32
+	_, client := testServer(t, importHandler(&created))
+	client.location = time.UTC
+
+	records, err := readImportCSV(strings.NewReader(importCSV), nil)
+	if err != nil {
+		t.Fatalf("readImportCSV failed: %v", err)
+	}
+	rows, err := client.prepareImport(context.Background(), 456, records, Rounding{})
+	if err != nil {
+		t.Fatalf("prepareImport failed: %v", err)
+	}
+
+	if tags := rows[1].Entry.Tags; strings.Join(tags, ",") != "Meeting,code" {
+		t.Errorf("expected existing tag spelling to be reused, got %v", tags)
+	}
+}
+
+func TestImportRoundedDuplicates(t *testing.T) {
+	var created int32
+	_, client := testServer(t, importHandler(&created))
+	client.location = time.UTC
+
+	records, err := readImportCSV(strings.NewReader(`date,start,stop,description
+2024-03-15,09:00,09:15,Standup
+2024-03-15,10:00,10:20,Review
+2024-03-15,10:00,10:25,Review
+`), nil)
+	if err != nil {
+		t.Fatalf("readImportCSV failed: %v", err)
+	}
+	rounding := Rounding{Mode: RoundUp, Increment: 30 * time.Minute}
+	rows, err := client.prepareImport(context.Background(), 456, records, rounding)
+	if err != nil {
+		t.Fatalf("prepareImport failed: %v", err)
+	}
+
+	if rows[0].Status != ImportDuplicate || rows[0].DuplicateOf != 555 {
+		t.Errorf("expected the rounded row to match the existing entry by its tracked duration, got %+v", rows[0])
+	}
+	if rows[1].Status != ImportReady || rows[1].Entry.Duration != 1800 {
+		t.Errorf("expected a rounded ready row, got %+v", rows[1])
+	}
+	if rows[2].Status != ImportDuplicate || rows[2].Error != "same as line 3" {
+		t.Errorf("expected rows equal once rounded to be duplicates, got %+v", rows[2])
+	}
+}
+
+func TestImportResolvesTasks(t *testing.T) {
+	var created int32
+	_, client := testServer(t, importHandler(&created))
+	client.location = time.UTC
+
+	records, err := readImportCSV(strings.NewReader(`date,start,stop,description,project,task
+2024-03-15,09:00,10:00,Sketch,Test Project,design
+2024-03-15,10:00,11:00,Check,Test Project,12
+2024-03-15,11:00,12:00,Ship,Test Project,Deploy
+2024-03-15,12:00,13:00,Loose,,Design
+2024-03-15,13:00,14:00,Elsewhere,Test Project,99
+`), nil)
+	if err != nil {
+		t.Fatalf("readImportCSV failed: %v", err)
+	}
+	rows, err := client.prepareImport(context.Background(), 456, records, Rounding{})
+	if err != nil {
+		t.Fatalf("prepareImport failed: %v", err)
+	}
+
+	if rows[0].Entry.TaskID == nil || *rows[0].Entry.TaskID != 11 || rows[1].Entry.TaskID == nil || *rows[1].Entry.TaskID != 12 {
+		t.Errorf("expected tasks by name and ID, got %+v and %+v", rows[0].Entry, rows[1].Entry)
+	}
+	for i, want := range map[int]string{2: `no task "Deploy"`, 3: "needs a project", 4: `no task "99"`} {
+		if rows[i].Status != ImportInvalid || !strings.Contains(rows[i].Error, want) {
+			t.Errorf("row %d: expected invalid with %q, got %s %q", i, want, rows[i].Status, rows[i].Error)
+		}
+	}
+}
+
+func TestHandleImportTimeEntriesFromFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hours.csv")
+	if err := os.WriteFile(path, []byte(importCSV), 0o600); err != nil {
+		t.Fatalf("writing CSV: %v", err)
+	}
+
+	var created int32
+	_, client := testServer(t, importHandler(&created))
+	client.location = time.UTC
+
+	tests := []struct {
+		name        string
+		allowedDirs []string
+		args        map[string]interface{}
+		want        string
+	}{
+		{name: "allowed", allowedDirs: []string{dir}, args: map[string]interface{}{"path": path}, want: "Preview of 5 rows"},
+		{name: "no allowed dirs", args: map[string]interface{}{"path": path}, want: "no allowed directories"},
+		{name: "both sources", allowedDirs: []string{dir}, args: map[string]interface{}{"path": path, "csv": importCSV}, want: "not both"},
+		{name: "no source", want: "Give the CSV"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := mcp.CallToolRequest{}
+			req.Params.Arguments = map[string]interface{}{"workspace_id": float64(456)}
+			for k, v := range tt.args {
+				req.GetArguments()[k] = v
+			}
+
+			result, err := handleImportTimeEntries(tt.allowedDirs)(context.Background(), client, req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, text)
+			}
+		})
+	}
+}
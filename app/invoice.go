@@ -0,0 +1,447 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultInvoiceDueDays is the payment term of invoices when none is configured
+const defaultInvoiceDueDays = 30
+
+// Invoice formats
+const (
+	InvoiceMarkdown = "markdown"
+	InvoiceHTML     = "html"
+	InvoiceJSON     = "json"
+)
+
+// defaultMarkdownInvoice is the built-in Markdown invoice template
+const defaultMarkdownInvoice = `# Invoice{{if .Number}} {{.Number}}{{end}}
+{{with .Sender}}{{if .Name}}
+**{{.Name}}**
+{{end}}{{range lines .Address}}
+{{.}}
+{{end}}{{if .Email}}
+{{.Email}}
+{{end}}{{if .TaxID}}
+Tax ID: {{.TaxID}}
+{{end}}{{end}}
+**Bill to:** {{.Client}}
+
+| | |
+|---|---|
+| Issue date | {{.IssueDate}} |
+{{if .DueDate}}| Due date | {{.DueDate}} |
+{{end}}| Period | {{.PeriodFrom}} to {{.PeriodTo}} |
+
+| Description | Hours | Rate ({{.Currency}}/h) | Amount ({{.Currency}}) |
+|---|--:|--:|--:|
+{{range .Lines}}| {{cell .Description}} | {{hours .Seconds}} | {{amount .Rate}} | {{amount .Amount}} |
+{{end}}| **Total** | **{{hours .TotalSeconds}}** | | **{{amount .Total}}** |
+{{if .Rounding}}
+Durations rounded {{.Rounding}}.
+{{end}}{{if .Notes}}
+{{.Notes}}
+{{end}}`
+
+// defaultHTMLInvoice is the built-in HTML invoice template
+const defaultHTMLInvoice = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice{{if .Number}} {{.Number}}{{end}}</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 0.3em 0.6em; border-bottom: 1px solid #ccc; text-align: left; }
+.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Invoice{{if .Number}} {{.Number}}{{end}}</h1>
+{{with .Sender}}<p>{{if .Name}}<strong>{{.Name}}</strong><br>{{end}}{{range lines .Address}}{{.}}<br>{{end}}{{if .Email}}{{.Email}}<br>{{end}}{{if .TaxID}}Tax ID: {{.TaxID}}{{end}}</p>
+{{end}}<p><strong>Bill to:</strong> {{.Client}}</p>
+<p>Issue date: {{.IssueDate}}<br>{{if .DueDate}}Due date: {{.DueDate}}<br>{{end}}Period: {{.PeriodFrom}} to {{.PeriodTo}}</p>
+<table>
+<tr><th>Description</th><th class="num">Hours</th><th class="num">Rate ({{.Currency}}/h)</th><th class="num">Amount ({{.Currency}})</th></tr>
+{{range .Lines}}<tr><td>{{.Description}}</td><td class="num">{{hours .Seconds}}</td><td class="num">{{amount .Rate}}</td><td class="num">{{amount .Amount}}</td></tr>
+{{end}}<tr><th>Total</th><th class="num">{{hours .TotalSeconds}}</th><th></th><th class="num">{{amount .Total}}</th></tr>
+</table>
+{{if .Rounding}}<p>Durations rounded {{.Rounding}}.</p>
+{{end}}{{if .Notes}}<p>{{.Notes}}</p>
+{{end}}</body>
+</html>
+`
+
+// invoiceFuncs are the functions available in invoice templates
+var invoiceFuncs = map[string]interface{}{
+	// hours formats seconds as decimal hours, e.g. "2.50"
+	"hours": func(seconds int) string { return fmt.Sprintf("%.2f", float64(seconds)/3600) },
+	// amount formats an amount with two decimals
+	"amount": func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+	// cell escapes a value for a Markdown table cell, so that a "|" in a
+	// project or task name does not start a new column
+	"cell": func(s string) string {
+		return strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(s)
+	},
+	// lines splits a multi-line value such as an address
+	"lines": func(s string) []string {
+		if s = strings.TrimSpace(s); s == "" {
+			return nil
+		}
+		return strings.Split(s, "\n")
+	},
+}
+
+// Invoicing holds what generated invoices need from the config
+type Invoicing struct {
+	Sender  InvoiceSender
+	DueDays int
+	Notes   string
+
+	markdown *texttemplate.Template
+	html     *htmltemplate.Template
+}
+
+// DefaultInvoicing uses the built-in templates without sender details
+func DefaultInvoicing() Invoicing {
+	i, _ := DefaultConfig().Invoice.Parse()
+	return i
+}
+
+// Parse checks the config and reads its templates, falling back to the
+// built-in ones
+func (c InvoiceConfig) Parse() (Invoicing, error) {
+	i := Invoicing{Sender: c.Sender, DueDays: c.DueDays, Notes: c.Notes}
+	if c.DueDays < 0 {
+		return i, errors.New("due_days must not be negative")
+	}
+
+	markdown, err := readTemplate(c.MarkdownTemplate, defaultMarkdownInvoice)
+	if err != nil {
+		return i, fmt.Errorf("markdown_template: %w", err)
+	}
+	if i.markdown, err = texttemplate.New("invoice.md").Funcs(invoiceFuncs).Parse(markdown); err != nil {
+		return i, fmt.Errorf("markdown_template: %w", err)
+	}
+
+	html, err := readTemplate(c.HTMLTemplate, defaultHTMLInvoice)
+	if err != nil {
+		return i, fmt.Errorf("html_template: %w", err)
+	}
+	if i.html, err = htmltemplate.New("invoice.html").Funcs(invoiceFuncs).Parse(html); err != nil {
+		return i, fmt.Errorf("html_template: %w", err)
+	}
+	return i, nil
+}
+
+// readTemplate returns the contents of the file at path, or fallback
+// when path is empty
+func readTemplate(path, fallback string) (string, error) {
+	if path == "" {
+		return fallback, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// InvoiceLine is the billable time on one project, or task, at one rate
+type InvoiceLine struct {
+	Description string  `json:"description"`
+	ProjectID   *int    `json:"project_id,omitempty"`
+	TaskID      *int    `json:"task_id,omitempty"`
+	Seconds     int     `json:"seconds"`
+	Rate        float64 `json:"rate"`
+	Amount      float64 `json:"amount"`
+}
+
+// Invoice is a draft invoice for one client and period; it is also the
+// data invoice templates are executed with
+type Invoice struct {
+	Number     string        `json:"number,omitempty"`
+	IssueDate  string        `json:"issue_date"`
+	DueDate    string        `json:"due_date,omitempty"`
+	PeriodFrom string        `json:"period_from"`
+	PeriodTo   string        `json:"period_to"`
+	Sender     InvoiceSender `json:"sender"`
+	Client     string        `json:"client"`
+	Currency   string        `json:"currency"`
+	// Rounding describes the rounding applied to each entry, if any
+	Rounding     string        `json:"rounding,omitempty"`
+	Lines        []InvoiceLine `json:"lines"`
+	TotalSeconds int           `json:"total_seconds"`
+	Total        float64       `json:"total"`
+	// UnratedSeconds is billable time no rate applies to; it is not invoiced
+	UnratedSeconds int `json:"unrated_seconds,omitempty"`
+	// RunningEntries counts billable entries still running, which are left
+	// off until they are stopped
+	RunningEntries int    `json:"running_entries,omitempty"`
+	Notes          string `json:"notes,omitempty"`
+}
+
+// invoiceLines groups the billable entries by project, task and rate,
+// sorted by description and then rate. Running entries are left out and
+// counted, as their time is not final. It fails when the entries are billed
+// in more than one currency, since an invoice has only one.
+func invoiceLines(
+	entries []TimeEntry,
+	rates billingRates,
+	taskNames map[int]string,
+	rounding Rounding,
+	now time.Time,
+) (lines []InvoiceLine, currency string, unrated, running int, err error) {
+	type lineKey struct {
+		project, task int
+		rate          float64
+	}
+	grouped := make(map[lineKey]*InvoiceLine)
+	currencies := make(map[string]bool)
+
+	for _, entry := range entries {
+		if entry.Billable && isRunning(entry) {
+			running++
+			continue
+		}
+		seconds := int(entryEnd(entry, now).Sub(entry.Start).Seconds())
+		if !entry.Billable || seconds <= 0 {
+			continue
+		}
+		seconds = rounding.RoundSeconds(seconds)
+		rate, _, ok := rates.rate(entry)
+		if !ok {
+			unrated += seconds
+			continue
+		}
+		currency = rates.currency(entry)
+		currencies[currency] = true
+
+		key := lineKey{rate: rate}
+		if entry.ProjectID != nil {
+			key.project = *entry.ProjectID
+		}
+		if entry.TaskID != nil {
+			key.task = *entry.TaskID
+		}
+		line, ok := grouped[key]
+		if !ok {
+			line = &InvoiceLine{Description: noProjectName, ProjectID: entry.ProjectID, TaskID: entry.TaskID, Rate: rate}
+			if entry.ProjectID != nil {
+				line.Description = rates.projects[key.project].Name
+				if line.Description == "" {
+					line.Description = fmt.Sprintf("Project %d", key.project)
+				}
+			}
+			if key.task != 0 {
+				line.Description += " / " + taskName(taskNames, key.task)
+			}
+			grouped[key] = line
+		}
+		line.Seconds += seconds
+	}
+
+	if len(currencies) > 1 {
+		names := make([]string, 0, len(currencies))
+		for name := range currencies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, "", unrated, running, fmt.Errorf("the billable time is in several currencies (%s); invoice one project at a time",
+			strings.Join(names, ", "))
+	}
+
+	for _, line := range grouped {
+		line.Amount = roundCents(line.Rate * float64(line.Seconds) / 3600)
+		lines = append(lines, *line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Description != lines[j].Description {
+			return lines[i].Description < lines[j].Description
+		}
+		return lines[i].Rate < lines[j].Rate
+	})
+	return lines, currency, unrated, running, nil
+}
+
+// render writes the invoice in the given format
+func (i Invoicing) render(invoice Invoice, format string) (string, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case InvoiceMarkdown:
+		err = i.markdown.Execute(&buf, invoice)
+	case InvoiceHTML:
+		err = i.html.Execute(&buf, invoice)
+	default:
+		return "", fmt.Errorf("unknown invoice format %q", format)
+	}
+	if err != nil {
+		return "", fmt.Errorf("rendering invoice: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// invoiceTools returns the tool that drafts invoices from billable time
+func invoiceTools(client *TogglClient, invoicing Invoicing) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"generate_invoice",
+				mcp.WithDescription("Draft an invoice for a client from the billable time in a period. Entries are grouped into line items per project, task and hourly rate, with the same rate precedence as billable_summary. The sender details, payment term and templates come from the server config. Nothing is sent or stored."),
+				mcp.WithString("client", mcp.Required(), mcp.Description("Client to invoice, by name")),
+				mcp.WithString("start_date", mcp.Required(), mcp.Description("First day of the period, YYYY-MM-DD")),
+				mcp.WithString("end_date", mcp.Required(), mcp.Description("Day after the last day of the period, YYYY-MM-DD (exclusive)")),
+				mcp.WithString("format",
+					mcp.Enum(InvoiceMarkdown, InvoiceHTML, InvoiceJSON),
+					mcp.Description("Output format (default markdown, or json when the server outputs JSON)"),
+				),
+				mcp.WithString("number", mcp.Description("Invoice number")),
+				mcp.WithString("issue_date", mcp.Description("Issue date, YYYY-MM-DD (default today)")),
+				mcp.WithBoolean("rounded", mcp.Description("Round each entry with the configured rounding before invoicing")),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
+			),
+			handler: wrapHandler(client, handleGenerateInvoice(invoicing)),
+		},
+	}
+}
+
+// handleGenerateInvoice drafts an invoice with the configured sender and templates
+func handleGenerateInvoice(invoicing Invoicing) func(
+	context.Context,
+	*TogglClient,
+	mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := req.GetArguments()
+		workspaceID, err := getWorkspaceID(args, client)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace_id: %w", err)
+		}
+		clientName, err := getRequiredString(args, "client")
+		if err != nil {
+			return nil, err
+		}
+		format := getOptionalString(args, "format")
+		switch format {
+		case "":
+			format = InvoiceMarkdown
+			if wantsJSON(ctx) {
+				format = InvoiceJSON
+			}
+		case InvoiceMarkdown, InvoiceHTML, InvoiceJSON:
+		default:
+			return nil, fmt.Errorf("format must be %s, %s or %s", InvoiceMarkdown, InvoiceHTML, InvoiceJSON)
+		}
+		rounding, err := requestedRounding(ctx, args, "rounded")
+		if err != nil {
+			return nil, err
+		}
+		from, to, err := dateRangeArgs(args, client)
+		if err != nil {
+			return nil, err
+		}
+		issued := time.Now().In(from.Location())
+		if _, ok := args["issue_date"]; ok {
+			if issued, err = getRequiredDate(args, "issue_date"); err != nil {
+				return nil, err
+			}
+		}
+
+		rates, clientNames, err := client.billingRates(ctx, workspaceID)
+		if err != nil {
+			return apiErrorResult("generate invoice", err)
+		}
+		clientID := 0
+		for id, name := range clientNames {
+			if strings.EqualFold(name, clientName) {
+				clientID, clientName = id, name
+			}
+		}
+		if clientID == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("No client named %q in workspace %d", clientName, workspaceID)), nil
+		}
+
+		entries, err := client.GetTimeEntries(ctx, from, to)
+		if err != nil {
+			return apiErrorResult("generate invoice", err)
+		}
+		var selected []TimeEntry
+		for _, entry := range entries {
+			if entry.WorkspaceID != workspaceID || entry.ProjectID == nil {
+				continue
+			}
+			if project := rates.projects[*entry.ProjectID]; project.ClientID != nil && *project.ClientID == clientID {
+				selected = append(selected, entry)
+			}
+		}
+
+		invoice := Invoice{
+			Number:     getOptionalString(args, "number"),
+			IssueDate:  issued.Format("2006-01-02"),
+			PeriodFrom: from.Format("2006-01-02"),
+			PeriodTo:   to.AddDate(0, 0, -1).Format("2006-01-02"),
+			Sender:     invoicing.Sender,
+			Client:     clientName,
+			Notes:      invoicing.Notes,
+		}
+		if invoicing.DueDays > 0 {
+			invoice.DueDate = issued.AddDate(0, 0, invoicing.DueDays).Format("2006-01-02")
+		}
+		if rounding.Enabled() {
+			invoice.Rounding = rounding.String()
+		}
+		taskNames, err := client.entryTaskNames(ctx, workspaceID, selected)
+		if err != nil {
+			return apiErrorResult("generate invoice", err)
+		}
+		invoice.Lines, invoice.Currency, invoice.UnratedSeconds, invoice.RunningEntries, err = invoiceLines(
+			selected, rates, taskNames, rounding, time.Now())
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Cannot draft an invoice for %s: %v", clientName, err)), nil
+		}
+		if len(invoice.Lines) == 0 {
+			text := fmt.Sprintf("No billable time with a rate for %s between %s and %s",
+				clientName, invoice.PeriodFrom, invoice.PeriodTo)
+			if invoice.RunningEntries > 0 {
+				text += fmt.Sprintf(" (%s still running)", plural(invoice.RunningEntries, "billable entry is", "billable entries are"))
+			}
+			return mcp.NewToolResultText(text), nil
+		}
+		for _, line := range invoice.Lines {
+			invoice.TotalSeconds += line.Seconds
+			invoice.Total = roundCents(invoice.Total + line.Amount)
+		}
+
+		if format == InvoiceJSON {
+			return jsonResult(invoice)
+		}
+		text, err := invoicing.render(invoice, format)
+		if err != nil {
+			return nil, err
+		}
+		result := mcp.NewToolResultText(text)
+		if invoice.UnratedSeconds > 0 {
+			result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf(
+				"Note: %s of billable time has no rate and is not on the invoice; set a project or workspace rate to include it",
+				formatHours(invoice.UnratedSeconds))))
+		}
+		if invoice.RunningEntries > 0 {
+			result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf(
+				"Note: %s still running and not on the invoice; stop them and generate the invoice again to include them",
+				plural(invoice.RunningEntries, "billable entry is", "billable entries are"))))
+		}
+		return result, nil
+	}
+}
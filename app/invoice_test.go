@@ -0,0 +1,211 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestInvoiceLines(t *testing.T) {
+	usd := "USD"
+	rates := billingRates{
+		projects: map[int]Project{
+			111: {BaseEntity: BaseEntity{ID: 111}, Name: "Website", Rate: floatPtr(100)},
+			222: {BaseEntity: BaseEntity{ID: 222}, Name: "Support"},
+			333: {BaseEntity: BaseEntity{ID: 333}, Name: "Hosting", Rate: floatPtr(50), Currency: &usd},
+		},
+		workspace: Workspace{DefaultCurrency: "EUR"},
+	}
+	entry := func(id, project int, start, end string, billable bool) TimeEntry {
+		e := lintEntry(id, "Work", start, end, true)
+		e.ProjectID, e.Billable = intPtr(project), billable
+		return e
+	}
+	entries := []TimeEntry{
+		entry(1, 111, "09:00", "10:00", true),
+		entry(2, 111, "10:00", "10:40", true),
+		entry(3, 111, "11:00", "11:30", true),
+		entry(4, 111, "12:00", "13:00", false),
+		entry(5, 222, "13:00", "14:00", true),
+	}
+	entries[2].Rate = floatPtr(150)
+	running := entry(7, 111, "15:00", "16:00", true)
+	running.Stop, running.Duration = nil, -1
+	entries = append(entries, running)
+	now := time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)
+
+	lines, currency, unrated, runningCount, err := invoiceLines(entries, rates, nil, Rounding{Mode: RoundUp, Increment: 15 * time.Minute}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []InvoiceLine{
+		{Description: "Website", ProjectID: intPtr(111), Seconds: 6300, Rate: 100, Amount: 175},
+		{Description: "Website", ProjectID: intPtr(111), Seconds: 1800, Rate: 150, Amount: 75},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), lines)
+	}
+	for i, line := range lines {
+		w := want[i]
+		if line.Description != w.Description || line.Seconds != w.Seconds || line.Rate != w.Rate || line.Amount != w.Amount {
+			t.Errorf("line %d = %+v, want %+v", i, line, w)
+		}
+	}
+	if currency != "EUR" || unrated != 3600 || runningCount != 1 {
+		t.Errorf("unexpected currency %q, unrated %d or running %d", currency, unrated, runningCount)
+	}
+
+	entries = append(entries, entry(6, 333, "14:00", "15:00", true))
+	if _, _, _, _, err := invoiceLines(entries, rates, nil, Rounding{}, now); err == nil ||
+		!strings.Contains(err.Error(), "several currencies (EUR, USD)") {
+		t.Errorf("expected a currency error, got %v", err)
+	}
+}
+
+func TestInvoiceConfigParse(t *testing.T) {
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "invoice.md.tmpl")
+	if err := os.WriteFile(custom, []byte("{{.Sender.Name}} bills {{.Client}} {{amount .Total}}"), 0o600); err != nil {
+		t.Fatalf("writing template: %v", err)
+	}
+	broken := filepath.Join(dir, "broken.html.tmpl")
+	if err := os.WriteFile(broken, []byte("{{if .Number}"), 0o600); err != nil {
+		t.Fatalf("writing template: %v", err)
+	}
+
+	invoicing, err := InvoiceConfig{Sender: InvoiceSender{Name: "Jane"}, MarkdownTemplate: custom}.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text, err := invoicing.render(Invoice{Sender: invoicing.Sender, Client: "Acme", Total: 12.5}, InvoiceMarkdown)
+	if err != nil || text != "Jane bills Acme 12.50" {
+		t.Errorf("render() = %q, %v", text, err)
+	}
+
+	markdown, err := DefaultInvoicing().render(Invoice{
+		Client: "Acme",
+		Lines:  []InvoiceLine{{Description: "Web | App / Design", Seconds: 3600, Rate: 100, Amount: 100}},
+	}, InvoiceMarkdown)
+	if err != nil || !strings.Contains(markdown, `| Web \| App / Design | 1.00 |`) {
+		t.Errorf("expected | to be escaped in table cells, got %q, %v", markdown, err)
+	}
+
+	tests := []struct {
+		name    string
+		config  InvoiceConfig
+		wantErr string
+	}{
+		{name: "negative due days", config: InvoiceConfig{DueDays: -1}, wantErr: "due_days"},
+		{name: "missing template", config: InvoiceConfig{MarkdownTemplate: filepath.Join(dir, "missing")}, wantErr: "markdown_template"},
+		{name: "broken template", config: InvoiceConfig{HTMLTemplate: broken}, wantErr: "html_template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.config.Parse(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHandleGenerateInvoice(t *testing.T) {
+	project := testProject
+	project.ClientID = intPtr(7)
+	project.Rate = floatPtr(100)
+	other := Project{BaseEntity: BaseEntity{ID: 222, WorkspaceID: 456}, Name: "Other", ClientID: intPtr(8), Rate: floatPtr(80)}
+	billable := lintEntry(1, "Work", "09:00", "11:30", true)
+	billable.Billable = true
+	elsewhere := lintEntry(2, "Other", "11:30", "12:00", false)
+	elsewhere.ProjectID, elsewhere.Billable = intPtr(222), true
+
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/workspaces/456":
+			writeJSON(w, http.StatusOK, Workspace{ID: 456, DefaultCurrency: "EUR"})
+		case "/api/v9/workspaces/456/projects":
+			writeJSON(w, http.StatusOK, []Project{project, other})
+		case "/api/v9/workspaces/456/clients":
+			writeJSON(w, http.StatusOK, []Client{
+				{BaseEntity: BaseEntity{ID: 7}, Name: "Acme & Co"},
+				{BaseEntity: BaseEntity{ID: 8}, Name: "Globex"},
+			})
+		case "/api/v9/me":
+			writeJSON(w, http.StatusOK, testUser)
+		case "/api/v9/workspaces/456/workspace_users":
+			writeJSON(w, http.StatusOK, []WorkspaceUser{})
+		case "/api/v9/me/time_entries":
+			writeJSON(w, http.StatusOK, []TimeEntry{billable, elsewhere})
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	})
+	client.location = time.UTC
+
+	invoicing, err := InvoiceConfig{
+		Sender:  InvoiceSender{Name: "Jane Doe", Address: "1 Main Street\n12345 Berlin"},
+		DueDays: 14,
+		Notes:   "Thank you!",
+	}.Parse()
+	if err != nil {
+		t.Fatalf("parsing config: %v", err)
+	}
+	handler := handleGenerateInvoice(invoicing)
+
+	call := func(args map[string]interface{}) *mcp.CallToolResult {
+		t.Helper()
+		args["workspace_id"] = float64(456)
+		args["start_date"], args["end_date"] = "2024-03-01", "2024-04-01"
+		args["issue_date"] = "2024-04-02"
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		result, err := handler(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	text := call(map[string]interface{}{"client": "acme & co", "number": "2024-004"}).Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"# Invoice 2024-004\n",
+		"**Jane Doe**\n\n1 Main Street\n\n12345 Berlin\n",
+		"**Bill to:** Acme & Co",
+		"| Due date | 2024-04-16 |",
+		"| Period | 2024-03-01 to 2024-03-31 |",
+		"| Test Project | 2.50 | 100.00 | 250.00 |",
+		"| **Total** | **2.50** | | **250.00** |",
+		"Thank you!",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Other") {
+		t.Errorf("expected only the client's projects in:\n%s", text)
+	}
+
+	html := call(map[string]interface{}{"client": "Acme & Co", "format": "html"}).Content[0].(mcp.TextContent).Text
+	if !strings.Contains(html, "<strong>Bill to:</strong> Acme &amp; Co") || !strings.Contains(html, "12345 Berlin<br>") {
+		t.Errorf("unexpected HTML:\n%s", html)
+	}
+
+	var invoice Invoice
+	data := call(map[string]interface{}{"client": "Acme & Co", "format": "json"}).Content[0].(mcp.TextContent).Text
+	if err := json.Unmarshal([]byte(data), &invoice); err != nil {
+		t.Fatalf("decoding invoice: %v", err)
+	}
+	if invoice.Total != 250 || invoice.Currency != "EUR" || invoice.IssueDate != "2024-04-02" || len(invoice.Lines) != 1 {
+		t.Errorf("unexpected invoice: %+v", invoice)
+	}
+
+	if result := call(map[string]interface{}{"client": "Initech"}); !result.IsError {
+		t.Errorf("expected an error for an unknown client")
+	}
+}
@@ -0,0 +1,500 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultJournalLimit = 500
+
+	changeCreate = "create"
+	changeUpdate = "update"
+	changeDelete = "delete"
+
+	entityTimeEntry = "time_entry"
+	entityProject   = "project"
+	entityTask      = "task"
+)
+
+// Change is a single mutation recorded in the journal. Before holds the
+// entity as it was prior to the change and After the entity the API returned.
+type Change struct {
+	ID          int             `json:"id"`
+	Time        time.Time       `json:"time"`
+	Tool        string          `json:"tool,omitempty"`
+	Operation   string          `json:"operation"`
+	EntityType  string          `json:"entity_type"`
+	WorkspaceID int             `json:"workspace_id"`
+	EntityID    int             `json:"entity_id"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	UndoneBy    int             `json:"undone_by,omitempty"`
+}
+
+// Journal is a persistent log of the mutations made through the server
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	limit   int
+	nextID  int
+	changes []Change
+}
+
+// NewJournal opens the journal stored at path, creating it on first write.
+// An empty path keeps the journal in memory only.
+func NewJournal(path string) (*Journal, error) {
+	j := &Journal{path: path, limit: defaultJournalLimit, nextID: 1}
+	if path == "" {
+		return j, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &j.changes); err != nil {
+		return nil, fmt.Errorf("decoding journal: %w", err)
+	}
+	for _, c := range j.changes {
+		if c.ID >= j.nextID {
+			j.nextID = c.ID + 1
+		}
+	}
+
+	return j, nil
+}
+
+// record appends a change, assigning its ID, and persists the journal
+func (j *Journal) record(c Change) (Change, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	c.ID = j.nextID
+	j.nextID++
+	if c.Time.IsZero() {
+		c.Time = time.Now()
+	}
+
+	j.changes = append(j.changes, c)
+	if len(j.changes) > j.limit {
+		j.changes = j.changes[len(j.changes)-j.limit:]
+	}
+
+	return c, j.save()
+}
+
+// recent returns up to n changes, newest first
+func (j *Journal) recent(n int) []Change {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	result := make([]Change, 0, n)
+	for i := len(j.changes) - 1; i >= 0 && len(result) < n; i-- {
+		result = append(result, j.changes[i])
+	}
+	return result
+}
+
+// get returns the change with the given ID
+func (j *Journal) get(id int) (Change, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, c := range j.changes {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return Change{}, ErrChangeNotFound
+}
+
+// markUndone links a change to the change that reversed it
+func (j *Journal) markUndone(id, undoneBy int) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i := range j.changes {
+		if j.changes[i].ID == id {
+			j.changes[i].UndoneBy = undoneBy
+			return j.save()
+		}
+	}
+	return ErrChangeNotFound
+}
+
+// save writes the journal atomically. Callers must hold j.mu.
+func (j *Journal) save() error {
+	if j.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(j.changes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding journal: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return fmt.Errorf("creating journal directory: %w", err)
+	}
+
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("replacing journal: %w", err)
+	}
+	return nil
+}
+
+// recordChange notes a mutation in the call's audit record and, if the client
+// has a journal, records it there. Failing to persist the journal is logged
+// rather than returned, since the change itself has already been applied.
+func (c *TogglClient) recordChange(ctx context.Context, change Change, before, after interface{}) {
+	if rec := auditFromContext(ctx); rec != nil {
+		rec.addEntity(change.EntityType, change.EntityID)
+	}
+
+	if c.journal == nil {
+		return
+	}
+
+	change.Tool = toolNameFromContext(ctx)
+	if before != nil {
+		if data, err := json.Marshal(before); err == nil {
+			change.Before = data
+		}
+	}
+	if after != nil {
+		if data, err := json.Marshal(after); err == nil {
+			change.After = data
+		}
+	}
+
+	recorded, err := c.journal.record(change)
+	if err != nil {
+		c.logger.Warn("failed to record change in journal",
+			slog.Any("error", err),
+			slog.String("operation", change.Operation),
+			slog.String("entity_type", change.EntityType),
+		)
+		return
+	}
+
+	if undoing, ok := ctx.Value(undoingKey{}).(int); ok {
+		if err := c.journal.markUndone(undoing, recorded.ID); err != nil {
+			c.logger.Warn("failed to mark change as undone",
+				slog.Any("error", err),
+				slog.Int("change_id", undoing),
+			)
+		}
+	}
+}
+
+// undoingKey marks a context as reversing the journal change it holds
+type undoingKey struct{}
+
+// journalTools returns the tools for inspecting and reverting journaled changes
+func journalTools(client *TogglClient) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"list_recent_changes",
+				mcp.WithDescription("List recent changes made through this server (start, stop, create, update, delete) that can be reverted with undo_change"),
+				mcp.WithNumber("limit", mcp.Description("Maximum number of changes to list (default 20)")),
+			),
+			handler: wrapHandler(client, handleListRecentChanges),
+		},
+		{
+			tool: mcp.NewTool(
+				"undo_change",
+				mcp.WithDescription("Revert a change listed by list_recent_changes: restores the previous fields of updated entries/projects, deletes created ones and recreates deleted ones (with a new ID)"),
+				mcp.WithNumber("change_id", mcp.Required()),
+			),
+			handler: wrapHandler(client, handleUndoChange),
+		},
+	}
+}
+
+// describeChange renders a one-line summary of a journal change
+func describeChange(c Change) string {
+	name := ""
+	var snapshot struct {
+		Description string `json:"description"`
+		Name        string `json:"name"`
+	}
+	for _, raw := range []json.RawMessage{c.After, c.Before} {
+		if len(raw) > 0 && json.Unmarshal(raw, &snapshot) == nil {
+			name = snapshot.Description + snapshot.Name
+			if name != "" {
+				break
+			}
+		}
+	}
+
+	status := ""
+	if c.UndoneBy != 0 {
+		status = fmt.Sprintf(" [undone by #%d]", c.UndoneBy)
+	}
+
+	tool := c.Tool
+	if tool == "" {
+		tool = "unknown tool"
+	}
+
+	return fmt.Sprintf("#%d %s %s %s %q (ID: %d) via %s%s",
+		c.ID, c.Time.Format(time.RFC3339), c.Operation, c.EntityType, name, c.EntityID, tool, status)
+}
+
+func handleListRecentChanges(
+	_ context.Context,
+	client *TogglClient,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	if client.journal == nil {
+		return mcp.NewToolResultError("The change journal is disabled"), nil
+	}
+
+	limit := 20
+	if n := getOptionalNumber(req.GetArguments(), "limit"); n != nil && *n > 0 {
+		limit = *n
+	}
+
+	changes := client.journal.recent(limit)
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d recent changes:\n", len(changes)))
+	for _, c := range changes {
+		result.WriteString("- " + describeChange(c) + "\n")
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+func handleUndoChange(
+	ctx context.Context,
+	client *TogglClient,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	changeID, err := getRequiredNumber(req.GetArguments(), "change_id")
+	if err != nil {
+		return nil, fmt.Errorf("invalid change_id: %w", err)
+	}
+
+	if client.journal == nil {
+		return mcp.NewToolResultError("The change journal is disabled"), nil
+	}
+
+	change, err := client.journal.get(changeID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Cannot undo change #%d: %s", changeID, err)), nil
+	}
+	if change.UndoneBy != 0 {
+		return mcp.NewToolResultError(
+			fmt.Sprintf("Change #%d was already undone by change #%d", change.ID, change.UndoneBy),
+		), nil
+	}
+
+	ctx = context.WithValue(ctx, undoingKey{}, change.ID)
+
+	summary, err := undo(ctx, client, change)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return mcp.NewToolResultError(
+				fmt.Sprintf("Failed to undo change #%d: %s", change.ID, apiErr.Error()),
+			), nil
+		}
+		return nil, fmt.Errorf("undoing change: %w", err)
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Undid change #%d: %s", change.ID, summary)), nil
+}
+
+// undo applies the inverse of change and describes what it did
+func undo(ctx context.Context, client *TogglClient, change Change) (string, error) {
+	switch {
+	case change.EntityType == entityTimeEntry && change.Operation == changeCreate:
+		if err := client.DeleteTimeEntry(ctx, change.WorkspaceID, change.EntityID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("deleted time entry %d", change.EntityID), nil
+
+	case change.EntityType == entityProject && change.Operation == changeCreate:
+		if err := client.DeleteProject(ctx, change.WorkspaceID, change.EntityID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("deleted project %d", change.EntityID), nil
+
+	case change.EntityType == entityTask && change.Operation == changeCreate:
+		var after Task
+		if err := json.Unmarshal(change.After, &after); err != nil {
+			return "", fmt.Errorf("decoding created task: %w", err)
+		}
+		if err := client.DeleteTask(ctx, change.WorkspaceID, after.ProjectID, change.EntityID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("deleted task %d", change.EntityID), nil
+	}
+
+	if len(change.Before) == 0 {
+		return "", fmt.Errorf("change #%d has no recorded prior state", change.ID)
+	}
+
+	switch change.EntityType {
+	case entityTimeEntry:
+		var before TimeEntry
+		if err := json.Unmarshal(change.Before, &before); err != nil {
+			return "", fmt.Errorf("decoding prior state: %w", err)
+		}
+
+		if change.Operation == changeDelete {
+			created, err := client.CreateTimeEntry(ctx, change.WorkspaceID, timeEntryRequestFrom(before))
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("recreated time entry %q as ID %d", created.Description, created.ID), nil
+		}
+
+		if _, err := client.UpdateTimeEntry(ctx, change.WorkspaceID, change.EntityID, timeEntryFields(before)); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("restored time entry %d", change.EntityID), nil
+
+	case entityProject:
+		var before Project
+		if err := json.Unmarshal(change.Before, &before); err != nil {
+			return "", fmt.Errorf("decoding prior state: %w", err)
+		}
+
+		if change.Operation == changeDelete {
+			created, err := client.CreateProject(ctx, change.WorkspaceID, projectFields(before))
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("recreated project %q as ID %d (time entries are not reassigned)", created.Name, created.ID), nil
+		}
+
+		if _, err := client.UpdateProject(ctx, change.WorkspaceID, change.EntityID, projectFields(before)); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("restored project %d", change.EntityID), nil
+
+	case entityTask:
+		var before Task
+		if err := json.Unmarshal(change.Before, &before); err != nil {
+			return "", fmt.Errorf("decoding prior state: %w", err)
+		}
+
+		if change.Operation == changeDelete {
+			created, err := client.CreateTask(ctx, change.WorkspaceID, before.ProjectID, taskFields(before))
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("recreated task %q as ID %d (time entries are not reassigned)", created.Name, created.ID), nil
+		}
+
+		if _, err := client.UpdateTask(ctx, change.WorkspaceID, before.ProjectID, change.EntityID, taskFields(before)); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("restored task %d", change.EntityID), nil
+	}
+
+	return "", fmt.Errorf("cannot undo %s of %s", change.Operation, change.EntityType)
+}
+
+// timeEntryRequestFrom builds a create payload reproducing entry
+func timeEntryRequestFrom(entry TimeEntry) TimeEntryRequest {
+	duration := entry.Duration
+	if duration < 0 {
+		duration = -1
+	}
+	return TimeEntryRequest{
+		Description: entry.Description,
+		Start:       entry.Start,
+		Duration:    duration,
+		ProjectID:   entry.ProjectID,
+		TaskID:      entry.TaskID,
+		Tags:        entry.Tags,
+		Billable:    entry.Billable,
+		Rate:        entry.Rate,
+		CreatedWith: "toggl-mcp",
+	}
+}
+
+// timeEntryFields builds an update payload that restores every editable field
+// of entry, including clearing fields that were empty
+func timeEntryFields(entry TimeEntry) map[string]interface{} {
+	fields := map[string]interface{}{
+		"description": entry.Description,
+		"start":       entry.Start,
+		"stop":        entry.Stop,
+		"duration":    entry.Duration,
+		"project_id":  entry.ProjectID,
+		"task_id":     entry.TaskID,
+		"tags":        entry.Tags,
+		"billable":    entry.Billable,
+	}
+	if entry.Stop == nil {
+		fields["duration"] = -1
+	}
+	if entry.Tags == nil {
+		fields["tags"] = []string{}
+	}
+	// Only plans with entry rates return one, and no tool sets it
+	if entry.Rate != nil {
+		fields["rate"] = *entry.Rate
+	}
+	return fields
+}
+
+// projectFields builds a payload that restores the editable fields of
+// project, billing and budget fields included, clearing those that were empty
+func projectFields(project Project) map[string]interface{} {
+	fields := map[string]interface{}{
+		"name":            project.Name,
+		"active":          project.Active,
+		"client_id":       project.ClientID,
+		"rate":            project.Rate,
+		"estimated_hours": project.EstimatedHours,
+		"fixed_fee":       project.FixedFee,
+	}
+	if project.Color != "" {
+		fields["color"] = project.Color
+	}
+	if project.Billable != nil {
+		fields["billable"] = *project.Billable
+	}
+	if project.Currency != nil {
+		fields["currency"] = *project.Currency
+	}
+	if project.StartDate != "" {
+		fields["start_date"] = project.StartDate
+	}
+	return fields
+}
+
+// taskFields builds a payload that restores the editable fields of task
+func taskFields(task Task) map[string]interface{} {
+	return map[string]interface{}{
+		"name":              task.Name,
+		"active":            task.Active,
+		"user_id":           task.UserID,
+		"estimated_seconds": task.EstimatedSeconds,
+	}
+}
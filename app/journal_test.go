@@ -0,0 +1,365 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// journaledTestServer creates a test client that records changes in an in-memory journal
+func journaledTestServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) (*TogglClient, *Journal) {
+	_, client := testServer(t, handler)
+	journal, err := NewJournal("")
+	if err != nil {
+		t.Fatalf("NewJournal failed: %v", err)
+	}
+	client.journal = journal
+	return client, journal
+}
+
+func TestJournal_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "journal.json")
+
+	journal, err := NewJournal(path)
+	if err != nil {
+		t.Fatalf("NewJournal failed: %v", err)
+	}
+
+	first, err := journal.record(Change{Operation: changeCreate, EntityType: entityTimeEntry, EntityID: 1})
+	if err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+	if _, err := journal.record(Change{Operation: changeDelete, EntityType: entityProject, EntityID: 2}); err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+	if err := journal.markUndone(first.ID, 99); err != nil {
+		t.Fatalf("markUndone failed: %v", err)
+	}
+
+	reopened, err := NewJournal(path)
+	if err != nil {
+		t.Fatalf("reopening journal failed: %v", err)
+	}
+
+	recent := reopened.recent(10)
+	if len(recent) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(recent))
+	}
+	if recent[0].EntityID != 2 || recent[1].UndoneBy != 99 {
+		t.Errorf("unexpected changes after reload: %+v", recent)
+	}
+
+	next, _ := reopened.record(Change{Operation: changeUpdate})
+	if next.ID != 3 {
+		t.Errorf("expected IDs to continue at 3, got %d", next.ID)
+	}
+
+	if _, err := reopened.get(42); !errors.Is(err, ErrChangeNotFound) {
+		t.Errorf("expected ErrChangeNotFound, got %v", err)
+	}
+}
+
+func TestJournal_Limit(t *testing.T) {
+	journal, _ := NewJournal("")
+	journal.limit = 3
+
+	for i := 0; i < 5; i++ {
+		journal.record(Change{EntityID: i})
+	}
+
+	recent := journal.recent(10)
+	if len(recent) != 3 || recent[2].EntityID != 2 {
+		t.Errorf("expected the 3 newest changes, got %+v", recent)
+	}
+}
+
+func TestClientMutations_Journaled(t *testing.T) {
+	running := testTimeEntry
+	running.Stop = nil
+	running.Duration = -1
+
+	client, journal := journaledTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v9/me/time_entries/789":
+			writeJSON(w, http.StatusOK, running)
+		case r.Method == http.MethodPatch:
+			writeJSON(w, http.StatusOK, testTimeEntry)
+		case r.Method == http.MethodPost:
+			writeJSON(w, http.StatusOK, testProject)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	ctx := context.WithValue(context.Background(), toolNameKey{}, "stop_time_entry")
+	if _, err := client.StopTimeEntry(ctx, 456, 789); err != nil {
+		t.Fatalf("StopTimeEntry failed: %v", err)
+	}
+	if _, err := client.CreateProject(context.Background(), 456, map[string]interface{}{"name": "Test Project"}); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	changes := journal.recent(10)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+
+	stop := changes[1]
+	if stop.Tool != "stop_time_entry" || stop.Operation != changeUpdate || stop.EntityID != 789 {
+		t.Errorf("unexpected stop change: %+v", stop)
+	}
+	var before TimeEntry
+	if err := json.Unmarshal(stop.Before, &before); err != nil || before.Duration != -1 {
+		t.Errorf("expected running entry as prior state, got %s", stop.Before)
+	}
+
+	if changes[0].Operation != changeCreate || changes[0].EntityType != entityProject || len(changes[0].Before) != 0 {
+		t.Errorf("unexpected create change: %+v", changes[0])
+	}
+}
+
+func TestHandleUndoChange(t *testing.T) {
+	var requests []string
+	var lastBody map[string]interface{}
+
+	client, journal := journaledTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		lastBody = nil
+		json.NewDecoder(r.Body).Decode(&lastBody)
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, testTimeEntry)
+		case http.MethodPost, http.MethodPut:
+			writeJSON(w, http.StatusOK, testTimeEntry)
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	stopped := testTimeEntry
+	running := testTimeEntry
+	running.Stop = nil
+	running.Duration = -1
+
+	created, _ := journal.record(Change{Operation: changeCreate, EntityType: entityTimeEntry, WorkspaceID: 456, EntityID: 789})
+	updated, _ := journal.record(Change{
+		Operation:   changeUpdate,
+		EntityType:  entityTimeEntry,
+		WorkspaceID: 456,
+		EntityID:    789,
+		Before:      mustJSON(t, running),
+		After:       mustJSON(t, stopped),
+	})
+	deleted, _ := journal.record(Change{
+		Operation:   changeDelete,
+		EntityType:  entityTimeEntry,
+		WorkspaceID: 456,
+		EntityID:    789,
+		Before:      mustJSON(t, stopped),
+	})
+
+	undo := func(id int) *mcp.CallToolResult {
+		t.Helper()
+		requests = nil
+		result, err := handleUndoChange(context.Background(), client, mcp.CallToolRequest{
+			Params: testCallToolParams{Arguments: map[string]interface{}{"change_id": float64(id)}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	t.Run("undo create deletes", func(t *testing.T) {
+		result := undo(created.ID)
+		if result.IsError || requests[len(requests)-1] != "DELETE /api/v9/workspaces/456/time_entries/789" {
+			t.Errorf("unexpected requests %v: %v", requests, result.Content)
+		}
+	})
+
+	t.Run("undo update restores prior fields", func(t *testing.T) {
+		result := undo(updated.ID)
+		if result.IsError || requests[len(requests)-1] != "PUT /api/v9/workspaces/456/time_entries/789" {
+			t.Fatalf("unexpected requests %v: %v", requests, result.Content)
+		}
+		if lastBody["duration"] != float64(-1) || lastBody["stop"] != nil {
+			t.Errorf("expected running state to be restored, got %v", lastBody)
+		}
+	})
+
+	t.Run("undo delete recreates", func(t *testing.T) {
+		result := undo(deleted.ID)
+		content := result.Content[0].(mcp.TextContent).Text
+		if result.IsError || !strings.Contains(content, "recreated time entry") {
+			t.Errorf("unexpected result: %s", content)
+		}
+		if lastBody["description"] != testTimeEntry.Description {
+			t.Errorf("expected recreated description, got %v", lastBody)
+		}
+	})
+
+	t.Run("undone changes are marked", func(t *testing.T) {
+		change, _ := journal.get(created.ID)
+		if change.UndoneBy == 0 {
+			t.Fatal("expected change to be marked undone")
+		}
+		if result := undo(created.ID); !result.IsError {
+			t.Error("expected second undo to be rejected")
+		}
+	})
+
+	t.Run("unknown change", func(t *testing.T) {
+		if result := undo(12345); !result.IsError {
+			t.Error("expected unknown change to be rejected")
+		}
+	})
+}
+
+func TestHandleUndoChange_Billable(t *testing.T) {
+	var lastBody map[string]interface{}
+	client, journal := journaledTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		lastBody = nil
+		json.NewDecoder(r.Body).Decode(&lastBody)
+		writeJSON(w, http.StatusOK, testTimeEntry)
+	})
+
+	billable := testTimeEntry
+	billable.Billable = true
+	billable.Rate = floatPtr(80)
+	nonBillable := testTimeEntry
+	nonBillable.Billable = false
+
+	patched, _ := journal.record(Change{
+		Tool:        "bulk_update_time_entries",
+		Operation:   changeUpdate,
+		EntityType:  entityTimeEntry,
+		WorkspaceID: 456,
+		EntityID:    789,
+		Before:      mustJSON(t, billable),
+		After:       mustJSON(t, nonBillable),
+	})
+	deleted, _ := journal.record(Change{
+		Operation:   changeDelete,
+		EntityType:  entityTimeEntry,
+		WorkspaceID: 456,
+		EntityID:    789,
+		Before:      mustJSON(t, billable),
+	})
+
+	for _, change := range []Change{patched, deleted} {
+		result, err := handleUndoChange(context.Background(), client, mcp.CallToolRequest{
+			Params: testCallToolParams{Arguments: map[string]interface{}{"change_id": float64(change.ID)}},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("undo of %s failed: %v %v", change.Operation, err, result)
+		}
+		if lastBody["billable"] != true || lastBody["rate"] != float64(80) {
+			t.Errorf("expected undo of %s to restore billable and rate, got %v", change.Operation, lastBody)
+		}
+	}
+}
+
+func TestHandleUndoChange_ProjectBilling(t *testing.T) {
+	var requests []string
+	var bodies []map[string]interface{}
+	client, journal := journaledTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		bodies = append(bodies, body)
+		writeJSON(w, http.StatusOK, testProject)
+	})
+
+	billable, currency := true, "EUR"
+	billed := testProject
+	billed.Billable = &billable
+	billed.Rate = floatPtr(95)
+	billed.Currency = &currency
+	billed.EstimatedHours = intPtr(40)
+	billed.FixedFee = floatPtr(3000)
+	billed.StartDate = "2024-01-08"
+	cleared := testProject
+
+	updated, _ := journal.record(Change{
+		Operation:   changeUpdate,
+		EntityType:  entityProject,
+		WorkspaceID: 456,
+		EntityID:    testProject.ID,
+		Before:      mustJSON(t, cleared),
+		After:       mustJSON(t, billed),
+	})
+	deleted, _ := journal.record(Change{
+		Operation:   changeDelete,
+		EntityType:  entityProject,
+		WorkspaceID: 456,
+		EntityID:    testProject.ID,
+		Before:      mustJSON(t, billed),
+	})
+
+	for _, change := range []Change{deleted, updated} {
+		result, err := handleUndoChange(context.Background(), client, mcp.CallToolRequest{
+			Params: testCallToolParams{Arguments: map[string]interface{}{"change_id": float64(change.ID)}},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("undo of %s failed: %v %v", change.Operation, err, result)
+		}
+	}
+
+	if requests[0] != "POST /api/v9/workspaces/456/projects" || requests[len(requests)-1] != "PUT /api/v9/workspaces/456/projects/111" {
+		t.Fatalf("unexpected requests %v", requests)
+	}
+	want := map[string]interface{}{
+		"billable": true, "rate": float64(95), "currency": "EUR",
+		"estimated_hours": float64(40), "fixed_fee": float64(3000), "start_date": "2024-01-08",
+	}
+	for field, value := range want {
+		if bodies[0][field] != value {
+			t.Errorf("expected recreated %s %v, got %v", field, value, bodies[0][field])
+		}
+	}
+	for _, field := range []string{"rate", "estimated_hours", "fixed_fee"} {
+		if value, ok := bodies[len(bodies)-1][field]; !ok || value != nil {
+			t.Errorf("expected undoing the update to clear %s, got %v", field, bodies[len(bodies)-1])
+		}
+	}
+}
+
+func TestHandleListRecentChanges(t *testing.T) {
+	client, journal := journaledTestServer(t, nil)
+	journal.record(Change{
+		Tool:        "start_time_entry",
+		Operation:   changeCreate,
+		EntityType:  entityTimeEntry,
+		WorkspaceID: 456,
+		EntityID:    789,
+		After:       mustJSON(t, testTimeEntry),
+	})
+
+	result, err := handleListRecentChanges(context.Background(), client, mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{"Found 1 recent changes", "#1", "create time_entry", `"Test Entry"`, "via start_time_entry"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in result: %s", want, content)
+		}
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshaling fixture: %v", err)
+	}
+	return data
+}
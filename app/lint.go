@@ -0,0 +1,328 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Lint issue kinds
+const (
+	LintOverlap     = "overlap"
+	LintGap         = "gap"
+	LintNoProject   = "no_project"
+	LintBadDuration = "bad_duration"
+	LintLongRunning = "long_running"
+)
+
+// Defaults for the lint thresholds
+const (
+	defaultMinGap     = 15 * time.Minute
+	defaultMaxRunning = 10 * time.Hour
+)
+
+// LintIssue is one problem found among time entries, with a suggested fix
+type LintIssue struct {
+	Kind       string    `json:"kind"`
+	EntryIDs   []int     `json:"entry_ids,omitempty"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Message    string    `json:"message"`
+	Suggestion string    `json:"suggestion"`
+}
+
+// LintReport lists the issues found in a date range, in time order
+type LintReport struct {
+	From    string      `json:"from"`
+	To      string      `json:"to"`
+	Entries int         `json:"entries"`
+	Issues  []LintIssue `json:"issues"`
+}
+
+// lintOptions holds the thresholds of a lint run
+type lintOptions struct {
+	workHours WorkHours
+	// minGap is the shortest untracked stretch inside working hours reported
+	minGap time.Duration
+	// maxRunning is how long a timer may run before it is reported
+	maxRunning time.Duration
+}
+
+// isRunning reports whether entry is a running timer, which the API marks
+// with a negative duration
+func isRunning(entry TimeEntry) bool {
+	return entry.Duration < 0
+}
+
+// entryEnd is when entry stops; running entries stop at now
+func entryEnd(entry TimeEntry, now time.Time) time.Time {
+	switch {
+	case isRunning(entry):
+		return now
+	case entry.Stop != nil:
+		return *entry.Stop
+	default:
+		return entry.Start.Add(time.Duration(entry.Duration) * time.Second)
+	}
+}
+
+// sortEntries sorts entries by start time, then ID
+func sortEntries(entries []TimeEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Start.Equal(entries[j].Start) {
+			return entries[i].Start.Before(entries[j].Start)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+}
+
+// lintTimeEntries checks the entries between from and to, whose location
+// messages are written in. Gaps are only looked for in the past.
+func lintTimeEntries(entries []TimeEntry, from, to time.Time, opts lintOptions, now time.Time) []LintIssue {
+	loc := from.Location()
+	sorted := append([]TimeEntry(nil), entries...)
+	sortEntries(sorted)
+
+	var issues []LintIssue
+	var latest *TimeEntry
+	for i := range sorted {
+		entry := &sorted[i]
+		start, end := entry.Start, entryEnd(*entry, now)
+		at := start.In(loc).Format("2006-01-02 15:04")
+
+		if entry.ProjectID == nil {
+			issues = append(issues, LintIssue{
+				Kind: LintNoProject, EntryIDs: []int{entry.ID}, Start: start, End: end,
+				Message:    fmt.Sprintf("%s: %s has no project", at, describeEntry(*entry)),
+				Suggestion: "Assign a project so the time shows up in project reports",
+			})
+		}
+
+		if isRunning(*entry) {
+			if running := now.Sub(start); running > opts.maxRunning {
+				issues = append(issues, LintIssue{
+					Kind: LintLongRunning, EntryIDs: []int{entry.ID}, Start: start, End: end,
+					Message: fmt.Sprintf("%s: %s has been running for %s",
+						at, describeEntry(*entry), formatHours(int(running.Seconds()))),
+					Suggestion: "Stop it, or set its stop time if the timer was forgotten",
+				})
+			}
+		} else if !end.After(start) {
+			problem := "a zero duration"
+			if end.Before(start) {
+				problem = "a negative duration"
+			}
+			issues = append(issues, LintIssue{
+				Kind: LintBadDuration, EntryIDs: []int{entry.ID}, Start: start, End: end,
+				Message:    fmt.Sprintf("%s: %s has %s", at, describeEntry(*entry), problem),
+				Suggestion: "Set a stop time after its start, or delete it",
+			})
+			continue
+		}
+
+		if latest != nil {
+			if latestEnd := entryEnd(*latest, now); start.Before(latestEnd) {
+				issues = append(issues, overlapIssue(*latest, *entry, loc, now))
+				if !end.After(latestEnd) {
+					continue
+				}
+			}
+		}
+		latest = entry
+	}
+
+	issues = append(issues, gapIssues(sorted, from, to, opts, now)...)
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Start.Before(issues[j].Start)
+	})
+	return issues
+}
+
+// overlapIssue describes next starting before prev has ended
+func overlapIssue(prev, next TimeEntry, loc *time.Location, now time.Time) LintIssue {
+	prevEnd, nextEnd := entryEnd(prev, now), entryEnd(next, now)
+	end := prevEnd
+	if nextEnd.Before(end) {
+		end = nextEnd
+	}
+	clock := func(t time.Time) string { return t.In(loc).Format("15:04") }
+
+	prevRequest := TimeEntryRequest{
+		Description: prev.Description,
+		Start:       prev.Start,
+		Duration:    int(prevEnd.Sub(prev.Start).Seconds()),
+	}
+
+	var suggestion string
+	switch {
+	case isDuplicateEntry(prevRequest, next.Start, int(nextEnd.Sub(next.Start).Seconds()), next.Description):
+		suggestion = fmt.Sprintf("They look like duplicates; delete entry %d", next.ID)
+	case !nextEnd.After(prevEnd):
+		suggestion = fmt.Sprintf("Entry %d lies within entry %d; delete it, or split entry %d around it",
+			next.ID, prev.ID, prev.ID)
+	default:
+		suggestion = fmt.Sprintf("Set the stop of entry %d to %s, or the start of entry %d to %s",
+			prev.ID, clock(next.Start), next.ID, clock(prevEnd))
+	}
+
+	return LintIssue{
+		Kind:     LintOverlap,
+		EntryIDs: []int{prev.ID, next.ID},
+		Start:    next.Start,
+		End:      end,
+		Message: fmt.Sprintf("%s %s-%s: %s and %s overlap by %s",
+			next.Start.In(loc).Format("2006-01-02"), clock(next.Start), clock(end),
+			describeEntry(prev), describeEntry(next), formatHours(int(end.Sub(next.Start).Seconds()))),
+		Suggestion: suggestion,
+	}
+}
+
+// gapIssues reports untracked stretches of at least opts.minGap inside the
+// working hours of each day between from and to, up to now
+func gapIssues(sorted []TimeEntry, from, to time.Time, opts lintOptions, now time.Time) []LintIssue {
+	var issues []LintIssue
+	for _, gap := range findGaps(sorted, from, to, opts.workHours, opts.minGap, now) {
+		issues = append(issues, LintIssue{
+			Kind:  LintGap,
+			Start: gap.start,
+			End:   gap.end,
+			Message: fmt.Sprintf("%s %s-%s: nothing tracked for %s",
+				gap.start.Format("2006-01-02"), gap.start.Format("15:04"), gap.end.Format("15:04"),
+				formatHours(int(gap.end.Sub(gap.start).Seconds()))),
+			Suggestion: "Fill it with fill_gaps, unless it was a break",
+		})
+	}
+	return issues
+}
+
+// describeEntry names an entry in messages
+func describeEntry(entry TimeEntry) string {
+	description := entry.Description
+	if description == "" {
+		description = "(no description)"
+	}
+	return fmt.Sprintf("%q (ID %d)", description, entry.ID)
+}
+
+// formatLintReport lists the issues with their suggestions
+func formatLintReport(report LintReport) string {
+	period := report.From
+	if report.To != report.From {
+		period += " to " + report.To
+	}
+	if len(report.Issues) == 0 {
+		return fmt.Sprintf("No issues found in %d time entries for %s", report.Entries, period)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d issues in %d time entries for %s:\n", len(report.Issues), report.Entries, period)
+	for _, issue := range report.Issues {
+		fmt.Fprintf(&b, "\n- [%s] %s\n  Suggestion: %s", issue.Kind, issue.Message, issue.Suggestion)
+	}
+	return b.String()
+}
+
+// lintTools returns the tools that check time entries for mistakes, looking
+// for gaps inside workHours
+func lintTools(client *TogglClient, workHours WorkHours) []toolDefinition {
+	thresholds := []mcp.ToolOption{
+		mcp.WithNumber("min_gap_minutes", mcp.Description("Shortest untracked stretch inside working hours to report (default 15)")),
+		mcp.WithNumber("max_running_hours", mcp.Description("Report timers running longer than this (default 10)")),
+	}
+
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool("lint_time_entries", append([]mcp.ToolOption{
+				mcp.WithDescription("Check the time entries in a date range for overlaps, gaps inside working hours, entries without a project, zero or negative durations and long-running timers, and suggest fixes. Nothing is changed."),
+				mcp.WithString("start_date", mcp.Required(), mcp.Description("First day, YYYY-MM-DD")),
+				mcp.WithString("end_date", mcp.Required(), mcp.Description("Day after the last day, YYYY-MM-DD (exclusive)")),
+			}, thresholds...)...),
+			handler: wrapHandler(client, handleLintTimeEntries(workHours, false)),
+		},
+		{
+			tool: mcp.NewTool("analyze_day", append([]mcp.ToolOption{
+				mcp.WithDescription("Check one day's time entries for overlaps, gaps inside working hours, entries without a project, zero or negative durations and long-running timers, and suggest fixes. Nothing is changed."),
+				mcp.WithString("date", mcp.Description("Day to check, YYYY-MM-DD (default today)")),
+			}, thresholds...)...),
+			handler: wrapHandler(client, handleLintTimeEntries(workHours, true)),
+		},
+	}
+}
+
+// handleLintTimeEntries checks a date range, or with day set a single date
+func handleLintTimeEntries(workHours WorkHours, day bool) func(
+	context.Context,
+	*TogglClient,
+	mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := req.GetArguments()
+		loc := client.timeZone()
+		now := time.Now()
+
+		var startDate, endDate time.Time
+		var err error
+		switch {
+		case !day:
+			if startDate, err = getRequiredDate(args, "start_date"); err != nil {
+				return nil, err
+			}
+			if endDate, err = getRequiredDate(args, "end_date"); err != nil {
+				return nil, err
+			}
+		case getOptionalString(args, "date") != "":
+			if startDate, err = getRequiredDate(args, "date"); err != nil {
+				return nil, err
+			}
+			endDate = startDate.AddDate(0, 0, 1)
+		default:
+			startDate = now.In(loc)
+			endDate = startDate.AddDate(0, 0, 1)
+		}
+		from := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+		to := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc)
+		if !to.After(from) {
+			return nil, errors.New("end_date must be after start_date")
+		}
+
+		opts := lintOptions{workHours: workHours, minGap: defaultMinGap, maxRunning: defaultMaxRunning}
+		if n := getOptionalNumber(args, "min_gap_minutes"); n != nil {
+			if *n <= 0 {
+				return nil, errors.New("min_gap_minutes must be positive")
+			}
+			opts.minGap = time.Duration(*n) * time.Minute
+		}
+		if n := getOptionalNumber(args, "max_running_hours"); n != nil {
+			if *n <= 0 {
+				return nil, errors.New("max_running_hours must be positive")
+			}
+			opts.maxRunning = time.Duration(*n) * time.Hour
+		}
+
+		entries, err := client.GetTimeEntries(ctx, from, to)
+		if err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get time entries: %s", apiErr.Error())), nil
+			}
+			return nil, fmt.Errorf("getting time entries: %w", err)
+		}
+
+		report := LintReport{
+			From:    from.Format("2006-01-02"),
+			To:      to.AddDate(0, 0, -1).Format("2006-01-02"),
+			Entries: len(entries),
+			Issues:  lintTimeEntries(entries, from, to, opts, now),
+		}
+		if wantsJSON(ctx) {
+			return jsonResult(report)
+		}
+		return mcp.NewToolResultText(formatLintReport(report)), nil
+	}
+}
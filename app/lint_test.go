@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// lintEntry builds a finished entry on 2024-03-15 (a Friday) in UTC
+func lintEntry(id int, description string, start, end string, project bool) TimeEntry {
+	day := "2024-03-15T"
+	s, _ := time.Parse(time.RFC3339, day+start+":00Z")
+	e, _ := time.Parse(time.RFC3339, day+end+":00Z")
+	entry := TimeEntry{
+		BaseEntity:  BaseEntity{ID: id, WorkspaceID: 456},
+		Description: description,
+		Start:       s,
+		Stop:        &e,
+		Duration:    int(e.Sub(s).Seconds()),
+	}
+	if project {
+		entry.ProjectID = intPtr(111)
+	}
+	return entry
+}
+
+func TestLintTimeEntries(t *testing.T) {
+	from := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	now := time.Date(2024, 3, 16, 12, 0, 0, 0, time.UTC)
+	opts := lintOptions{workHours: DefaultWorkHours(), minGap: 15 * time.Minute, maxRunning: 10 * time.Hour}
+
+	running := TimeEntry{
+		BaseEntity:  BaseEntity{ID: 7},
+		Description: "Forgotten",
+		ProjectID:   intPtr(111),
+		Start:       time.Date(2024, 3, 15, 16, 30, 0, 0, time.UTC),
+		Duration:    -1,
+	}
+	entries := []TimeEntry{
+		lintEntry(2, "Review", "10:00", "11:00", true),
+		lintEntry(1, "Standup", "09:00", "10:15", true),
+		lintEntry(3, "Review", "10:00", "11:00", true),
+		lintEntry(4, "Lunch call", "10:30", "10:45", false),
+		lintEntry(5, "Empty", "12:00", "12:00", true),
+		lintEntry(6, "Planning", "13:00", "14:00", true),
+		running,
+	}
+
+	var got []string
+	for _, issue := range lintTimeEntries(entries, from, to, opts, now) {
+		got = append(got, issue.Kind+": "+issue.Message)
+	}
+	want := []string{
+		`overlap: 2024-03-15 10:00-10:15: "Standup" (ID 1) and "Review" (ID 2) overlap by 0h 15m`,
+		`overlap: 2024-03-15 10:00-11:00: "Review" (ID 2) and "Review" (ID 3) overlap by 1h 00m`,
+		`no_project: 2024-03-15 10:30: "Lunch call" (ID 4) has no project`,
+		`overlap: 2024-03-15 10:30-10:45: "Review" (ID 2) and "Lunch call" (ID 4) overlap by 0h 15m`,
+		`gap: 2024-03-15 11:00-13:00: nothing tracked for 2h 00m`,
+		`bad_duration: 2024-03-15 12:00: "Empty" (ID 5) has a zero duration`,
+		`gap: 2024-03-15 14:00-16:30: nothing tracked for 2h 30m`,
+		`long_running: 2024-03-15 16:30: "Forgotten" (ID 7) has been running for 19h 30m`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected issues:\n got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestOverlapSuggestions(t *testing.T) {
+	now := time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		prev, next TimeEntry
+		want       string
+	}{
+		{
+			name: "duplicate",
+			prev: lintEntry(1, "Review", "10:00", "11:00", true),
+			next: lintEntry(2, "review", "10:00", "11:00", true),
+			want: "delete entry 2",
+		},
+		{
+			name: "contained",
+			prev: lintEntry(1, "Workshop", "10:00", "12:00", true),
+			next: lintEntry(2, "Call", "10:30", "11:00", true),
+			want: "Entry 2 lies within entry 1",
+		},
+		{
+			name: "partial",
+			prev: lintEntry(1, "Workshop", "10:00", "11:00", true),
+			next: lintEntry(2, "Call", "10:30", "11:30", true),
+			want: "Set the stop of entry 1 to 10:30, or the start of entry 2 to 11:00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue := overlapIssue(tt.prev, tt.next, time.UTC, now)
+			if !strings.Contains(issue.Suggestion, tt.want) {
+				t.Errorf("suggestion %q does not contain %q", issue.Suggestion, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleLintTimeEntries(t *testing.T) {
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start_date") != "2024-03-16T00:00:00Z" {
+			t.Errorf("unexpected start_date %q", r.URL.Query().Get("start_date"))
+		}
+		writeJSON(w, http.StatusOK, []TimeEntry{lintEntry(1, "Weekend", "10:00", "10:00", true)})
+	})
+	client.location = time.UTC
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"date": "2024-03-16"}
+	result, err := handleLintTimeEntries(DefaultWorkHours(), true)(context.Background(), client, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{"Found 1 issues in 1 time entries for 2024-03-16:", "[bad_duration]", "Suggestion: "} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+
+	for _, key := range []string{"min_gap_minutes", "max_running_hours"} {
+		for _, value := range []float64{0, -5} {
+			req.Params.Arguments = map[string]interface{}{"date": "2024-03-16", key: value}
+			if _, err := handleLintTimeEntries(DefaultWorkHours(), true)(context.Background(), client, req); err == nil {
+				t.Errorf("expected %s=%v to be rejected", key, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// validProfileName restricts profile names to characters that are safe in file names
+var validProfileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Profile is a named Toggl account the server can act as
+type Profile struct {
+	APIToken           string `json:"api_token,omitempty" yaml:"api_token,omitempty" toml:"api_token,omitempty"`
+	APITokenFile       string `json:"api_token_file,omitempty" yaml:"api_token_file,omitempty" toml:"api_token_file,omitempty"`
+	APITokenCmd        string `json:"api_token_cmd,omitempty" yaml:"api_token_cmd,omitempty" toml:"api_token_cmd,omitempty"`
+	DefaultWorkspaceID int    `json:"default_workspace_id,omitempty" yaml:"default_workspace_id,omitempty" toml:"default_workspace_id,omitzero"`
+	Timezone           string `json:"timezone,omitempty" yaml:"timezone,omitempty" toml:"timezone,omitempty"`
+}
+
+// ProfilesConfig is the on-disk format of the profiles file
+type ProfilesConfig struct {
+	Default  string             `json:"default"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// LoadProfilesConfig reads a profiles file
+func LoadProfilesConfig(path string) (ProfilesConfig, error) {
+	var cfg ProfilesConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("reading profiles: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing profiles %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Profiles keeps one TogglClient per configured profile
+type Profiles struct {
+	defaultName string
+	names       []string
+	profiles    map[string]Profile
+	clients     map[string]*TogglClient
+}
+
+// NewProfiles validates cfg and creates a client for every profile with
+// newClient, then applies the profile's token source, default workspace and
+// time zone to it where they are set. Tokens read from a file or command are
+// filled into the profile passed to newClient.
+func NewProfiles(
+	cfg ProfilesConfig,
+	newClient func(name string, profile Profile) *TogglClient,
+) (*Profiles, error) {
+	if len(cfg.Profiles) == 0 {
+		return nil, errors.New("no profiles configured")
+	}
+
+	p := &Profiles{
+		defaultName: cfg.Default,
+		profiles:    cfg.Profiles,
+		clients:     make(map[string]*TogglClient, len(cfg.Profiles)),
+	}
+
+	for name, profile := range cfg.Profiles {
+		if !validProfileName.MatchString(name) {
+			return nil, fmt.Errorf("profile %q: names may only contain letters, digits, '-' and '_'", name)
+		}
+		var opts []ClientOption
+		if n := countSet(profile.APIToken, profile.APITokenFile, profile.APITokenCmd); n == 0 {
+			return nil, fmt.Errorf("profile %q: one of api_token, api_token_file or api_token_cmd is required", name)
+		} else if n > 1 {
+			return nil, fmt.Errorf("profile %q: only one of api_token, api_token_file and api_token_cmd may be set", name)
+		}
+		if source := tokenSource(profile.APITokenFile, profile.APITokenCmd); source != nil {
+			token, err := source(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf("profile %q: %w", name, err)
+			}
+			profile.APIToken = token
+			opts = append(opts, WithTokenSource(source))
+		}
+		if profile.DefaultWorkspaceID != 0 {
+			opts = append(opts, WithDefaultWorkspace(profile.DefaultWorkspaceID))
+		}
+		if profile.Timezone != "" {
+			loc, err := time.LoadLocation(profile.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("profile %q: invalid timezone: %w", name, err)
+			}
+			opts = append(opts, WithLocation(loc))
+		}
+
+		client := newClient(name, profile)
+		for _, opt := range opts {
+			opt(client)
+		}
+
+		p.names = append(p.names, name)
+		p.clients[name] = client
+	}
+	sort.Strings(p.names)
+
+	if p.defaultName == "" {
+		if len(p.names) > 1 {
+			return nil, errors.New("a default profile is required when more than one profile is configured")
+		}
+		p.defaultName = p.names[0]
+	}
+	if _, ok := p.clients[p.defaultName]; !ok {
+		return nil, fmt.Errorf("default profile %q is not configured", p.defaultName)
+	}
+
+	return p, nil
+}
+
+// Default returns the client of the default profile
+func (p *Profiles) Default() *TogglClient {
+	return p.clients[p.defaultName]
+}
+
+// Client returns the client of the named profile
+func (p *Profiles) Client(name string) (*TogglClient, bool) {
+	c, ok := p.clients[name]
+	return c, ok
+}
+
+// Names returns the profile names in sorted order
+func (p *Profiles) Names() []string {
+	return p.names
+}
+
+// withProfileArgument adds the optional profile argument to a tool's schema
+func (p *Profiles) withProfileArgument(tool mcp.Tool) mcp.Tool {
+	mcp.WithString("profile",
+		mcp.Description("Profile to act as; defaults to "+p.defaultName),
+		mcp.Enum(p.names...),
+	)(&tool)
+	return tool
+}
+
+// middleware runs handler with the client of the profile named in the
+// call's profile argument, if any
+func (p *Profiles) middleware(
+	handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error),
+) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := getOptionalString(req.GetArguments(), "profile")
+		if name == "" {
+			return handler(ctx, req)
+		}
+
+		client, ok := p.Client(name)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf(
+				"Unknown profile %q (available: %s)", name, strings.Join(p.names, ", "),
+			)), nil
+		}
+
+		return handler(WithRequestClient(ctx, client), req)
+	}
+}
+
+// profileTools returns the tools for inspecting the configured profiles
+func profileTools(p *Profiles) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"list_profiles",
+				mcp.WithDescription("List the Toggl account profiles tools can act as via their profile argument"),
+			),
+			handler: p.handleListProfiles,
+		},
+	}
+}
+
+func (p *Profiles) handleListProfiles(
+	_ context.Context,
+	_ mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d profiles:\n", len(p.names)))
+
+	for _, name := range p.names {
+		profile := p.profiles[name]
+
+		var details []string
+		if name == p.defaultName {
+			details = append(details, "default")
+		}
+		if profile.DefaultWorkspaceID != 0 {
+			details = append(details, fmt.Sprintf("Workspace ID: %d", profile.DefaultWorkspaceID))
+		}
+		if profile.Timezone != "" {
+			details = append(details, "Timezone: "+profile.Timezone)
+		}
+
+		result.WriteString("- " + name)
+		if len(details) > 0 {
+			result.WriteString(" (" + strings.Join(details, ", ") + ")")
+		}
+		result.WriteString("\n")
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
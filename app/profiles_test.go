@@ -0,0 +1,216 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestNewProfiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         ProfilesConfig
+		wantDefault string
+		wantErr     string
+	}{
+		{
+			name: "single profile is the default",
+			cfg: ProfilesConfig{Profiles: map[string]Profile{
+				"personal": {APIToken: "p-token"},
+			}},
+			wantDefault: "p-token",
+		},
+		{
+			name: "explicit default",
+			cfg: ProfilesConfig{Default: "client", Profiles: map[string]Profile{
+				"personal": {APIToken: "p-token"},
+				"client":   {APIToken: "c-token"},
+			}},
+			wantDefault: "c-token",
+		},
+		{
+			name:    "no profiles",
+			cfg:     ProfilesConfig{},
+			wantErr: "no profiles configured",
+		},
+		{
+			name: "ambiguous default",
+			cfg: ProfilesConfig{Profiles: map[string]Profile{
+				"personal": {APIToken: "p-token"},
+				"client":   {APIToken: "c-token"},
+			}},
+			wantErr: "default profile is required",
+		},
+		{
+			name: "unknown default",
+			cfg: ProfilesConfig{Default: "work", Profiles: map[string]Profile{
+				"personal": {APIToken: "p-token"},
+			}},
+			wantErr: `default profile "work" is not configured`,
+		},
+		{
+			name: "missing token",
+			cfg: ProfilesConfig{Profiles: map[string]Profile{
+				"personal": {},
+			}},
+			wantErr: "one of api_token, api_token_file or api_token_cmd is required",
+		},
+		{
+			name: "conflicting tokens",
+			cfg: ProfilesConfig{Profiles: map[string]Profile{
+				"personal": {APIToken: "p-token", APITokenCmd: "pass show toggl"},
+			}},
+			wantErr: "only one of",
+		},
+		{
+			name: "unreadable token file",
+			cfg: ProfilesConfig{Profiles: map[string]Profile{
+				"personal": {APITokenFile: "/nonexistent/toggl-token"},
+			}},
+			wantErr: "reading token file",
+		},
+		{
+			name: "invalid timezone",
+			cfg: ProfilesConfig{Profiles: map[string]Profile{
+				"personal": {APIToken: "p-token", Timezone: "Mars/Olympus"},
+			}},
+			wantErr: "invalid timezone",
+		},
+		{
+			name: "unsafe name",
+			cfg: ProfilesConfig{Profiles: map[string]Profile{
+				"../personal": {APIToken: "p-token"},
+			}},
+			wantErr: "names may only contain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profiles, err := NewProfiles(tt.cfg, func(_ string, p Profile) *TogglClient {
+				return NewTogglClient(p.APIToken)
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := profiles.Default().APIToken; got != tt.wantDefault {
+				t.Errorf("expected default client with %q, got %q", tt.wantDefault, got)
+			}
+		})
+	}
+}
+
+func TestNewProfilesTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("file-token\n"), 0o600); err != nil {
+		t.Fatalf("writing token: %v", err)
+	}
+
+	profiles, err := NewProfiles(ProfilesConfig{Profiles: map[string]Profile{
+		"personal": {APITokenFile: path},
+	}}, func(_ string, p Profile) *TogglClient {
+		return NewTogglClient(p.APIToken)
+	})
+	if err != nil {
+		t.Fatalf("NewProfiles failed: %v", err)
+	}
+
+	client := profiles.Default()
+	if client.APIToken != "file-token" || client.tokenSource == nil {
+		t.Errorf("expected token read from file with a token source, got %q", client.APIToken)
+	}
+}
+
+func TestProfilesSelection(t *testing.T) {
+	var gotToken, gotPath string
+	ts, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotToken, _, _ = r.BasicAuth()
+		gotPath = r.URL.Path
+		writeJSON(w, http.StatusOK, testTimeEntry)
+	})
+
+	profiles, err := NewProfiles(ProfilesConfig{
+		Default: "personal",
+		Profiles: map[string]Profile{
+			"personal": {APIToken: "p-token"},
+			"client":   {APIToken: "c-token", DefaultWorkspaceID: 999, Timezone: "Europe/Berlin"},
+		},
+	}, func(_ string, p Profile) *TogglClient {
+		return NewTogglClient(p.APIToken, WithHTTPClient(&http.Client{
+			Transport: &testTransport{testURL: ts.URL},
+		}))
+	})
+	if err != nil {
+		t.Fatalf("NewProfiles failed: %v", err)
+	}
+
+	s := server.NewMCPServer("test-server", "1.0.0")
+	if err := SetupTools(s, profiles.Default(), WithProfiles(profiles)); err != nil {
+		t.Fatalf("SetupTools failed: %v", err)
+	}
+
+	handler := profiles.middleware(wrapHandler(profiles.Default(), handleStartTimeEntry))
+	call := func(args map[string]interface{}) *mcp.CallToolResult {
+		t.Helper()
+		result, err := handler(context.Background(), mcp.CallToolRequest{
+			Params: testCallToolParams{Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	t.Run("default profile", func(t *testing.T) {
+		call(map[string]interface{}{"description": "Work", "workspace_id": 456.0})
+		if gotToken != "p-token" || gotPath != "/api/v9/workspaces/456/time_entries" {
+			t.Errorf("unexpected request: token %q, path %s", gotToken, gotPath)
+		}
+	})
+
+	t.Run("named profile with default workspace", func(t *testing.T) {
+		call(map[string]interface{}{"description": "Work", "profile": "client"})
+		if gotToken != "c-token" || gotPath != "/api/v9/workspaces/999/time_entries" {
+			t.Errorf("unexpected request: token %q, path %s", gotToken, gotPath)
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		result := call(map[string]interface{}{"description": "Work", "profile": "nope"})
+		if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "available: client, personal") {
+			t.Errorf("expected unknown profile error, got %+v", result)
+		}
+	})
+
+	t.Run("list_profiles", func(t *testing.T) {
+		result, err := profiles.handleListProfiles(context.Background(), mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		for _, want := range []string{
+			"Found 2 profiles",
+			"- client (Workspace ID: 999, Timezone: Europe/Berlin)",
+			"- personal (default)",
+		} {
+			if !strings.Contains(text, want) {
+				t.Errorf("expected %q in:\n%s", want, text)
+			}
+		}
+		if strings.Contains(text, "token") {
+			t.Errorf("list_profiles must not reveal tokens:\n%s", text)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Rounding modes
+const (
+	RoundUp      = "up"
+	RoundDown    = "down"
+	RoundNearest = "nearest"
+)
+
+// errNoRounding is returned when rounding is asked for but not configured
+var errNoRounding = errors.New("no rounding is configured (set rounding.mode or rounding.minimum)")
+
+// Rounding rounds tracked durations to the increments they are billed in
+type Rounding struct {
+	// Mode is RoundUp, RoundDown or RoundNearest; empty leaves durations
+	// as they are, apart from Minimum
+	Mode      string
+	Increment time.Duration
+	// Minimum is the shortest duration billed; shorter durations other
+	// than zero are raised to it
+	Minimum time.Duration
+}
+
+// Rounding converts the config, which Config.Validate has checked
+func (c RoundingConfig) Rounding() Rounding {
+	return Rounding{
+		Mode:      c.Mode,
+		Increment: time.Duration(c.Increment),
+		Minimum:   time.Duration(c.Minimum),
+	}
+}
+
+// Enabled reports whether r changes any durations
+func (r Rounding) Enabled() bool {
+	return (r.Mode != "" && r.Increment > 0) || r.Minimum > 0
+}
+
+// Round rounds d to the increment, then raises it to the minimum. Zero and
+// negative durations are returned unchanged, and other durations are never
+// rounded below one increment, so that rounding down cannot erase time.
+func (r Rounding) Round(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	if r.Increment > 0 {
+		switch r.Mode {
+		case RoundUp:
+			d = (d + r.Increment - 1) / r.Increment * r.Increment
+		case RoundDown:
+			d = max(d/r.Increment*r.Increment, r.Increment)
+		case RoundNearest:
+			d = max((d+r.Increment/2)/r.Increment*r.Increment, r.Increment)
+		}
+	}
+	return max(d, r.Minimum)
+}
+
+// RoundSeconds rounds a duration in seconds; the negative durations of
+// running entries are returned unchanged
+func (r Rounding) RoundSeconds(seconds int) int {
+	return int(r.Round(time.Duration(seconds) * time.Second).Seconds())
+}
+
+// String describes r, e.g. "up to 15m, at least 30m"
+func (r Rounding) String() string {
+	var parts []string
+	if r.Mode != "" && r.Increment > 0 {
+		parts = append(parts, fmt.Sprintf("%s to %s", r.Mode, shortDuration(r.Increment)))
+	}
+	if r.Minimum > 0 {
+		parts = append(parts, "at least "+shortDuration(r.Minimum))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// shortDuration formats d without zero units, e.g. "15m" rather than "15m0s"
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// roundTimeEntry changes the duration of a stopped entry to its rounded
+// value, moving its stop time. Running and already rounded entries are
+// returned as they are.
+func (c *TogglClient) roundTimeEntry(
+	ctx context.Context,
+	workspaceID int,
+	entry TimeEntry,
+	rounding Rounding,
+) (TimeEntry, error) {
+	rounded := rounding.RoundSeconds(entry.Duration)
+	if isRunning(entry) || rounded == entry.Duration {
+		return entry, nil
+	}
+
+	fields := timeEntryFields(entry)
+	fields["stop"] = entry.Start.Add(time.Duration(rounded) * time.Second)
+	fields["duration"] = rounded
+	return c.UpdateTimeEntry(ctx, workspaceID, entry.ID, fields)
+}
+
+// roundImportRows rounds the durations of the rows ready to be created,
+// keeping the durations as read for duplicate detection
+func roundImportRows(rows []ImportRow, rounding Rounding) {
+	for i := range rows {
+		if rows[i].Status == ImportReady && rows[i].Entry != nil {
+			rows[i].rawDuration = rows[i].Entry.Duration
+			rows[i].Entry.Duration = rounding.RoundSeconds(rows[i].Entry.Duration)
+		}
+	}
+}
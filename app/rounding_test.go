@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRoundingRound(t *testing.T) {
+	tests := []struct {
+		name     string
+		rounding Rounding
+		in       time.Duration
+		want     time.Duration
+	}{
+		{name: "none", rounding: Rounding{}, in: 7 * time.Minute, want: 7 * time.Minute},
+		{name: "up", rounding: Rounding{Mode: RoundUp, Increment: 6 * time.Minute}, in: 7 * time.Minute, want: 12 * time.Minute},
+		{name: "up when exact", rounding: Rounding{Mode: RoundUp, Increment: 6 * time.Minute}, in: 12 * time.Minute, want: 12 * time.Minute},
+		{name: "down", rounding: Rounding{Mode: RoundDown, Increment: 15 * time.Minute}, in: 29 * time.Minute, want: 15 * time.Minute},
+		{name: "nearest below half", rounding: Rounding{Mode: RoundNearest, Increment: 15 * time.Minute}, in: 22 * time.Minute, want: 15 * time.Minute},
+		{name: "nearest at half", rounding: Rounding{Mode: RoundNearest, Increment: 15 * time.Minute}, in: 22*time.Minute + 30*time.Second, want: 30 * time.Minute},
+		{name: "down never to zero", rounding: Rounding{Mode: RoundDown, Increment: 15 * time.Minute}, in: 10 * time.Minute, want: 15 * time.Minute},
+		{name: "nearest never to zero", rounding: Rounding{Mode: RoundNearest, Increment: 15 * time.Minute}, in: 5 * time.Minute, want: 15 * time.Minute},
+		{name: "minimum", rounding: Rounding{Minimum: 15 * time.Minute}, in: time.Minute, want: 15 * time.Minute},
+		{name: "down to the minimum", rounding: Rounding{Mode: RoundDown, Increment: 15 * time.Minute, Minimum: 15 * time.Minute}, in: 10 * time.Minute, want: 15 * time.Minute},
+		{name: "zero", rounding: Rounding{Mode: RoundUp, Increment: 15 * time.Minute, Minimum: 15 * time.Minute}, in: 0, want: 0},
+		{name: "running", rounding: Rounding{Mode: RoundUp, Increment: 15 * time.Minute}, in: -time.Second, want: -time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rounding.Round(tt.in); got != tt.want {
+				t.Errorf("Round(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundingString(t *testing.T) {
+	tests := []struct {
+		rounding Rounding
+		want     string
+	}{
+		{rounding: Rounding{}, want: "none"},
+		{rounding: Rounding{Mode: RoundUp, Increment: 15 * time.Minute}, want: "up to 15m"},
+		{rounding: Rounding{Mode: RoundNearest, Increment: time.Hour, Minimum: 90 * time.Second}, want: "nearest to 1h, at least 1m30s"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rounding.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestHandleStopTimeEntryRounded(t *testing.T) {
+	start := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	stop := start.Add(67 * time.Minute)
+	stopped := TimeEntry{
+		BaseEntity:  BaseEntity{ID: 789, WorkspaceID: 456},
+		Description: "Review",
+		Start:       start,
+		Stop:        &stop,
+		Duration:    67 * 60,
+	}
+
+	var updated map[string]interface{}
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v9/me/time_entries/current":
+			running := stopped
+			running.Stop, running.Duration = nil, -1
+			writeJSON(w, http.StatusOK, running)
+		case strings.HasSuffix(r.URL.Path, "/stop"):
+			writeJSON(w, http.StatusOK, stopped)
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v9/workspaces/456/time_entries/789":
+			json.NewDecoder(r.Body).Decode(&updated)
+			rounded := stopped
+			rounded.Duration = int(updated["duration"].(float64))
+			writeJSON(w, http.StatusOK, rounded)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	call := func(ctx context.Context) (*mcp.CallToolResult, error) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]interface{}{"workspace_id": float64(456), "round": true}
+		return handleStopTimeEntry(ctx, client, req)
+	}
+
+	if _, err := call(context.Background()); err == nil || !strings.Contains(err.Error(), "no rounding is configured") {
+		t.Fatalf("expected an error without configured rounding, got %v", err)
+	}
+
+	rounding := Rounding{Mode: RoundUp, Increment: 15 * time.Minute}
+	result, err := call(context.WithValue(context.Background(), roundingKey{}, rounding))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if text != "Stopped time entry: Review (ID: 789) [1h 7m 0s, rounded 1h 15m 0s]" {
+		t.Errorf("unexpected result: %s", text)
+	}
+	if updated["duration"] != float64(4500) || updated["stop"] != "2024-03-15T10:15:00Z" {
+		t.Errorf("unexpected update: %v", updated)
+	}
+
+	// Rounding down an entry shorter than the increment keeps one increment
+	short := start.Add(10 * time.Minute)
+	stopped.Stop, stopped.Duration = &short, 600
+	down := Rounding{Mode: RoundDown, Increment: 15 * time.Minute}
+	if _, err := call(context.WithValue(context.Background(), roundingKey{}, down)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated["duration"] != float64(900) {
+		t.Errorf("expected the short entry to keep one increment, got %v", updated)
+	}
+}
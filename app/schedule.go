@@ -0,0 +1,103 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule is the contracted working time: hours due per weekday, except on
+// public holidays and vacation days
+type Schedule struct {
+	Hours [7]time.Duration
+	// Holidays and Vacation are keyed by YYYY-MM-DD
+	Holidays map[string]bool
+	Vacation map[string]bool
+}
+
+// Reasons a working day has no hours due
+const (
+	dayHoliday  = "holiday"
+	dayVacation = "vacation"
+)
+
+// DefaultSchedule is eight hours a day, Monday to Friday
+func DefaultSchedule() Schedule {
+	s, _ := DefaultConfig().Schedule.Parse()
+	return s
+}
+
+// Parse checks the config and converts it into a Schedule
+func (c ScheduleConfig) Parse() (Schedule, error) {
+	s := Schedule{Holidays: make(map[string]bool), Vacation: make(map[string]bool)}
+	var set [7]bool
+	for name, hours := range c.Hours {
+		day, err := parseWeekday(name)
+		if err != nil {
+			return s, fmt.Errorf("hours: %w", err)
+		}
+		if set[day] {
+			return s, fmt.Errorf("hours: %s is set twice", day)
+		}
+		set[day] = true
+		if hours < 0 || hours > 24 {
+			return s, fmt.Errorf("hours: %s must be between 0 and 24, got %g", name, hours)
+		}
+		s.Hours[day] = time.Duration(hours * float64(time.Hour))
+	}
+
+	for _, value := range c.Holidays {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return s, fmt.Errorf("holidays: invalid date %q (use YYYY-MM-DD)", value)
+		}
+		s.Holidays[date.Format("2006-01-02")] = true
+	}
+
+	for _, value := range c.Vacation {
+		first, last, isRange := strings.Cut(value, "..")
+		if !isRange {
+			last = first
+		}
+		from, err := time.Parse("2006-01-02", strings.TrimSpace(first))
+		if err != nil {
+			return s, fmt.Errorf("vacation: invalid date %q (use YYYY-MM-DD or YYYY-MM-DD..YYYY-MM-DD)", value)
+		}
+		to, err := time.Parse("2006-01-02", strings.TrimSpace(last))
+		if err != nil || to.Before(from) {
+			return s, fmt.Errorf("vacation: invalid range %q", value)
+		}
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			s.Vacation[day.Format("2006-01-02")] = true
+		}
+	}
+	return s, nil
+}
+
+// withDayHours returns s with hours due on each of its working days, in
+// place of the days' own hours
+func (s Schedule) withDayHours(hours time.Duration) Schedule {
+	for day := range s.Hours {
+		if s.Hours[day] > 0 {
+			s.Hours[day] = hours
+		}
+	}
+	return s
+}
+
+// due returns the hours due on day and, when a working day has none due,
+// why: a holiday or vacation
+func (s Schedule) due(day time.Time) (time.Duration, string) {
+	hours := s.Hours[day.Weekday()]
+	if hours == 0 {
+		return 0, ""
+	}
+	date := day.Format("2006-01-02")
+	switch {
+	case s.Holidays[date]:
+		return 0, dayHoliday
+	case s.Vacation[date]:
+		return 0, dayVacation
+	}
+	return hours, ""
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduleConfigParse(t *testing.T) {
+	schedule, err := ScheduleConfig{
+		Hours:    map[string]float64{"mon": 8, "Tuesday": 8, "fri": 6.5},
+		Holidays: []string{"2024-03-29"},
+		Vacation: []string{"2024-03-12", "2024-03-20..2024-03-22"},
+	}.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := [7]time.Duration{0, 8 * time.Hour, 8 * time.Hour, 0, 0, 6*time.Hour + 30*time.Minute, 0}; schedule.Hours != want {
+		t.Errorf("unexpected hours: %v", schedule.Hours)
+	}
+	if len(schedule.Vacation) != 4 || !schedule.Vacation["2024-03-21"] || !schedule.Holidays["2024-03-29"] {
+		t.Errorf("unexpected days off: %+v", schedule)
+	}
+
+	tests := []struct {
+		name    string
+		config  ScheduleConfig
+		wantErr string
+	}{
+		{name: "unknown day", config: ScheduleConfig{Hours: map[string]float64{"someday": 8}}, wantErr: "unknown day"},
+		{name: "day set twice", config: ScheduleConfig{Hours: map[string]float64{"mon": 8, "monday": 6}}, wantErr: "set twice"},
+		{name: "too many hours", config: ScheduleConfig{Hours: map[string]float64{"mon": 25}}, wantErr: "between 0 and 24"},
+		{name: "bad holiday", config: ScheduleConfig{Holidays: []string{"25.12.2024"}}, wantErr: "holidays"},
+		{name: "backwards vacation", config: ScheduleConfig{Vacation: []string{"2024-08-16..2024-08-05"}}, wantErr: "vacation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.config.Parse(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestScheduleDue(t *testing.T) {
+	schedule, err := ScheduleConfig{
+		Hours:    DefaultConfig().Schedule.Hours,
+		Holidays: []string{"2024-03-29", "2024-03-30"},
+		Vacation: []string{"2024-03-28"},
+	}.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		date       string
+		want       time.Duration
+		wantReason string
+	}{
+		{date: "2024-03-27", want: 8 * time.Hour},
+		{date: "2024-03-28", wantReason: dayVacation},
+		{date: "2024-03-29", wantReason: dayHoliday},
+		{date: "2024-03-30"},
+	}
+	for _, tt := range tests {
+		day, _ := time.Parse("2006-01-02", tt.date)
+		if got, reason := schedule.due(day); got != tt.want || reason != tt.wantReason {
+			t.Errorf("due(%s) = %v, %q, want %v, %q", tt.date, got, reason, tt.want, tt.wantReason)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Search limits and defaults
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 200
+	// defaultSearchDays is how far back a search looks without a start_date
+	defaultSearchDays = 365
+	// searchPageDays is the date range fetched per request
+	searchPageDays = 30
+)
+
+// Search result orders
+const (
+	SearchNewest   = "newest"
+	SearchOldest   = "oldest"
+	SearchLongest  = "longest"
+	SearchShortest = "shortest"
+)
+
+// textMatcher reports whether a name or description matches a pattern
+type textMatcher func(string) bool
+
+// newTextMatcher matches a case-insensitive substring, or with regex set a
+// regular expression, which is also case-insensitive. An empty pattern
+// gives a nil matcher.
+func newTextMatcher(pattern string, regex bool) (textMatcher, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	if !regex {
+		pattern = strings.ToLower(pattern)
+		return func(s string) bool { return strings.Contains(strings.ToLower(s), pattern) }, nil
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+	}
+	return re.MatchString, nil
+}
+
+// searchCriteria is what a time entry must satisfy to be found; zero fields
+// match everything
+type searchCriteria struct {
+	description textMatcher
+	project     textMatcher
+	client      textMatcher
+	tags        []string
+	// allTags requires every tag rather than any of them
+	allTags     bool
+	billable    *bool
+	minDuration time.Duration
+	maxDuration time.Duration
+	runningOnly bool
+}
+
+// matches checks entry, with its project and client names, against c.
+// Running entries last until now.
+func (c searchCriteria) matches(entry TimeEntry, project, client string, now time.Time) bool {
+	if c.runningOnly && !isRunning(entry) {
+		return false
+	}
+	if c.description != nil && !c.description(entry.Description) {
+		return false
+	}
+	if c.project != nil && (project == "" || !c.project(project)) {
+		return false
+	}
+	if c.client != nil && (client == "" || !c.client(client)) {
+		return false
+	}
+	if c.billable != nil && entry.Billable != *c.billable {
+		return false
+	}
+
+	duration := entryEnd(entry, now).Sub(entry.Start)
+	if c.minDuration > 0 && duration < c.minDuration {
+		return false
+	}
+	if c.maxDuration > 0 && duration > c.maxDuration {
+		return false
+	}
+
+	if len(c.tags) > 0 {
+		found := 0
+		for _, want := range c.tags {
+			for _, tag := range entry.Tags {
+				if strings.EqualFold(tag, want) {
+					found++
+					break
+				}
+			}
+		}
+		if found == 0 || (c.allTags && found < len(c.tags)) {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchMatch is a time entry found by a search, with its names resolved
+type SearchMatch struct {
+	TimeEntry
+	Project string `json:"project,omitempty"`
+	Client  string `json:"client,omitempty"`
+}
+
+// SearchResult lists the entries a search found
+type SearchResult struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Sort    string `json:"sort"`
+	Scanned int    `json:"scanned"`
+	// Total is the number of matches, or at least that many when the search
+	// stopped early
+	Total int `json:"total"`
+	// Stopped is set when the search ended before covering the date range,
+	// having found enough entries
+	Stopped bool `json:"stopped"`
+	// Truncated is set when more entries matched than are listed
+	Truncated bool          `json:"truncated"`
+	Entries   []SearchMatch `json:"entries"`
+}
+
+// searchPages fetches the entries between from and to, searchPageDays at a
+// time, and passes each page to visit: newest page first when backwards is
+// set, oldest first otherwise. It stops when visit returns false.
+func (c *TogglClient) searchPages(
+	ctx context.Context,
+	from, to time.Time,
+	backwards bool,
+	visit func([]TimeEntry) bool,
+) error {
+	for pageStart, pageEnd := from, to; pageStart.Before(pageEnd); {
+		start, end := pageStart, pageStart.AddDate(0, 0, searchPageDays)
+		if backwards {
+			start, end = pageEnd.AddDate(0, 0, -searchPageDays), pageEnd
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		entries, err := c.GetTimeEntries(ctx, start, end)
+		if err != nil {
+			return err
+		}
+		if !visit(entries) {
+			return nil
+		}
+
+		if backwards {
+			pageEnd = start
+		} else {
+			pageStart = end
+		}
+	}
+	return nil
+}
+
+// searchTimeEntries finds the entries between from and to matching
+// criteria, sorted by order and cut to limit. Searches for the newest or
+// oldest entries stop fetching once limit entries are found.
+func (c *TogglClient) searchTimeEntries(
+	ctx context.Context,
+	from, to time.Time,
+	criteria searchCriteria,
+	order string,
+	limit int,
+	now time.Time,
+) (SearchResult, error) {
+	result := SearchResult{Sort: order}
+	names := exportNames{projects: make(map[int]Project), clients: make(map[int]string)}
+	seen := make(map[int]bool)
+	var matches []SearchMatch
+	var namesErr error
+
+	err := c.searchPages(ctx, from, to, order != SearchOldest, func(entries []TimeEntry) bool {
+		result.Scanned += len(entries)
+
+		// Names are looked up once per workspace
+		var unseen []TimeEntry
+		for _, entry := range entries {
+			if entry.ProjectID != nil && !seen[entry.WorkspaceID] {
+				unseen = append(unseen, entry)
+			}
+		}
+		for _, entry := range unseen {
+			seen[entry.WorkspaceID] = true
+		}
+		if len(unseen) > 0 {
+			more, err := c.exportNames(ctx, unseen, criteria.client != nil)
+			if err != nil {
+				namesErr = err
+				return false
+			}
+			for id, project := range more.projects {
+				names.projects[id] = project
+			}
+			for id, name := range more.clients {
+				names.clients[id] = name
+			}
+		}
+
+		for _, entry := range entries {
+			var project, client string
+			if entry.ProjectID != nil {
+				p := names.projects[*entry.ProjectID]
+				project = p.Name
+				if p.ClientID != nil {
+					client = names.clients[*p.ClientID]
+				}
+			}
+			if criteria.matches(entry, project, client, now) {
+				matches = append(matches, SearchMatch{TimeEntry: entry, Project: project, Client: client})
+			}
+		}
+
+		// Later pages only hold entries further from the requested end
+		if (order == SearchNewest || order == SearchOldest) && len(matches) >= limit {
+			result.Stopped = true
+			return false
+		}
+		return true
+	})
+	if err == nil {
+		err = namesErr
+	}
+	if err != nil {
+		return result, err
+	}
+
+	sortSearchMatches(matches, order, now)
+	result.Total = len(matches)
+	result.Truncated = len(matches) > limit
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	result.Entries = matches
+	return result, nil
+}
+
+// sortSearchMatches sorts matches by order; ties keep the newest first
+func sortSearchMatches(matches []SearchMatch, order string, now time.Time) {
+	duration := func(m SearchMatch) time.Duration { return entryEnd(m.TimeEntry, now).Sub(m.Start) }
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		switch order {
+		case SearchOldest:
+			return a.Start.Before(b.Start)
+		case SearchLongest:
+			if duration(a) != duration(b) {
+				return duration(a) > duration(b)
+			}
+		case SearchShortest:
+			if duration(a) != duration(b) {
+				return duration(a) < duration(b)
+			}
+		}
+		return a.Start.After(b.Start)
+	})
+}
+
+// formatSearchResult lists the matches, one line each
+func formatSearchResult(result SearchResult, loc *time.Location, now time.Time) string {
+	period := fmt.Sprintf("%s and %s", result.From, result.To)
+	if len(result.Entries) == 0 {
+		return fmt.Sprintf("No time entries found between %s (%d entries searched)", period, result.Scanned)
+	}
+
+	var b strings.Builder
+	found := fmt.Sprint(result.Total)
+	if result.Stopped {
+		found = "at least " + found
+	}
+	fmt.Fprintf(&b, "Found %s time entries between %s (%d entries searched, %s first):\n",
+		found, period, result.Scanned, result.Sort)
+	for _, match := range result.Entries {
+		fmt.Fprintf(&b, "- %s %s (ID: %d)", match.Start.In(loc).Format("2006-01-02 15:04"), match.Description, match.ID)
+		if match.Project != "" {
+			fmt.Fprintf(&b, " - %s", match.Project)
+			if match.Client != "" {
+				fmt.Fprintf(&b, " / %s", match.Client)
+			}
+		}
+		if len(match.Tags) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(match.Tags, ", "))
+		}
+		if isRunning(match.TimeEntry) {
+			fmt.Fprintf(&b, " %s running\n", formatHours(int(now.Sub(match.Start).Seconds())))
+		} else {
+			fmt.Fprintf(&b, " %s\n", formatHours(int(entryEnd(match.TimeEntry, now).Sub(match.Start).Seconds())))
+		}
+	}
+	switch {
+	case result.Truncated:
+		fmt.Fprintf(&b, "Showing the first %d; raise limit to see more", len(result.Entries))
+	case result.Stopped:
+		b.WriteString("The search stopped at the limit; more entries may match")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// searchTools returns the tool that searches time entries
+func searchTools(client *TogglClient) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"search_time_entries",
+				mcp.WithDescription("Search time entries by description, project or client name, tags, billable flag, duration or running state, e.g. to find when something was last worked on. Looks back a year unless start_date is given."),
+				mcp.WithString("query", mcp.Description("Text the description contains, ignoring case")),
+				mcp.WithString("project", mcp.Description("Text the project name contains")),
+				mcp.WithString("client", mcp.Description("Text the client name contains")),
+				mcp.WithBoolean("regex", mcp.Description("Treat query, project and client as regular expressions")),
+				mcp.WithArray("tags", mcp.Description("Tags the entry has"), mcp.Items(map[string]interface{}{"type": "string"})),
+				mcp.WithString("tag_mode", mcp.Description("Whether entries need any (default) or all of the tags"), mcp.Enum("any", "all")),
+				mcp.WithBoolean("billable", mcp.Description("Only billable, or only non-billable, entries")),
+				mcp.WithNumber("min_minutes", mcp.Description("Shortest duration")),
+				mcp.WithNumber("max_minutes", mcp.Description("Longest duration")),
+				mcp.WithBoolean("running_only", mcp.Description("Only the running timer")),
+				mcp.WithString("start_date", mcp.Description("First day to search, YYYY-MM-DD (default a year ago)")),
+				mcp.WithString("end_date", mcp.Description("Day after the last day to search, YYYY-MM-DD (default tomorrow)")),
+				mcp.WithString("sort", mcp.Description("Order of the results (default newest)"), mcp.Enum(SearchNewest, SearchOldest, SearchLongest, SearchShortest)),
+				mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Most entries to return (default %d, at most %d)", defaultSearchLimit, maxSearchLimit))),
+			),
+			handler: wrapHandler(client, handleSearchTimeEntries),
+		},
+	}
+}
+
+// searchCriteriaFrom reads the search filters from the tool arguments
+func searchCriteriaFrom(args map[string]interface{}) (searchCriteria, error) {
+	var criteria searchCriteria
+	var err error
+	regex := getOptionalBool(args, "regex")
+	if criteria.description, err = newTextMatcher(getOptionalString(args, "query"), regex); err != nil {
+		return criteria, err
+	}
+	if criteria.project, err = newTextMatcher(getOptionalString(args, "project"), regex); err != nil {
+		return criteria, err
+	}
+	if criteria.client, err = newTextMatcher(getOptionalString(args, "client"), regex); err != nil {
+		return criteria, err
+	}
+
+	if criteria.tags, err = getOptionalStringList(args, "tags"); err != nil {
+		return criteria, err
+	}
+	switch mode := getOptionalString(args, "tag_mode"); mode {
+	case "", "any":
+	case "all":
+		criteria.allTags = true
+	default:
+		return criteria, fmt.Errorf("tag_mode must be any or all, not %q", mode)
+	}
+
+	if billable, ok := args["billable"].(bool); ok {
+		criteria.billable = &billable
+	}
+	if n := getOptionalNumber(args, "min_minutes"); n != nil {
+		criteria.minDuration = time.Duration(*n) * time.Minute
+	}
+	if n := getOptionalNumber(args, "max_minutes"); n != nil {
+		criteria.maxDuration = time.Duration(*n) * time.Minute
+	}
+	if criteria.maxDuration > 0 && criteria.maxDuration < criteria.minDuration {
+		return criteria, errors.New("max_minutes must not be less than min_minutes")
+	}
+	criteria.runningOnly = getOptionalBool(args, "running_only")
+	return criteria, nil
+}
+
+func handleSearchTimeEntries(
+	ctx context.Context,
+	client *TogglClient,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	loc := client.timeZone()
+	now := time.Now()
+
+	criteria, err := searchCriteriaFrom(args)
+	if err != nil {
+		return nil, err
+	}
+
+	order := getOptionalString(args, "sort")
+	switch order {
+	case "":
+		order = SearchNewest
+	case SearchNewest, SearchOldest, SearchLongest, SearchShortest:
+	default:
+		return nil, fmt.Errorf("unknown sort %q", order)
+	}
+	limit := defaultSearchLimit
+	if n := getOptionalNumber(args, "limit"); n != nil {
+		if *n < 1 || *n > maxSearchLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxSearchLimit)
+		}
+		limit = *n
+	}
+
+	today := now.In(loc)
+	to := time.Date(today.Year(), today.Month(), today.Day()+1, 0, 0, 0, 0, loc)
+	if getOptionalString(args, "end_date") != "" {
+		endDate, err := getRequiredDate(args, "end_date")
+		if err != nil {
+			return nil, err
+		}
+		to = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc)
+	}
+	from := to.AddDate(0, 0, -defaultSearchDays)
+	if getOptionalString(args, "start_date") != "" {
+		startDate, err := getRequiredDate(args, "start_date")
+		if err != nil {
+			return nil, err
+		}
+		from = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+	}
+	if !to.After(from) {
+		return nil, errors.New("end_date must be after start_date")
+	}
+
+	result, err := client.searchTimeEntries(ctx, from, to, criteria, order, limit, now)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to search time entries: %s", apiErr.Error())), nil
+		}
+		return nil, fmt.Errorf("searching time entries: %w", err)
+	}
+	result.From = from.Format("2006-01-02")
+	result.To = to.AddDate(0, 0, -1).Format("2006-01-02")
+
+	if wantsJSON(ctx) {
+		return jsonResult(result)
+	}
+	return mcp.NewToolResultText(formatSearchResult(result, loc, now)), nil
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSearchCriteriaMatches(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	entry := lintEntry(1, "Billing migration", "09:00", "10:30", true)
+	entry.Tags = []string{"Backend", "urgent"}
+	entry.Billable = true
+	running := lintEntry(2, "Standup", "11:30", "11:45", false)
+	running.Duration = -1
+	yes, no := true, false
+
+	matcher := func(pattern string, regex bool) textMatcher {
+		m, err := newTextMatcher(pattern, regex)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return m
+	}
+
+	tests := []struct {
+		name     string
+		criteria searchCriteria
+		entry    TimeEntry
+		want     bool
+	}{
+		{name: "everything", criteria: searchCriteria{}, entry: entry, want: true},
+		{name: "description substring", criteria: searchCriteria{description: matcher("MIGRATION", false)}, entry: entry, want: true},
+		{name: "description regex", criteria: searchCriteria{description: matcher("^billing\\s+mig", true)}, entry: entry, want: true},
+		{name: "description regex miss", criteria: searchCriteria{description: matcher("^migration", true)}, entry: entry, want: false},
+		{name: "project", criteria: searchCriteria{project: matcher("test", false)}, entry: entry, want: true},
+		{name: "client missing", criteria: searchCriteria{client: matcher("acme", false)}, entry: entry, want: false},
+		{name: "any tag", criteria: searchCriteria{tags: []string{"backend", "frontend"}}, entry: entry, want: true},
+		{name: "all tags", criteria: searchCriteria{tags: []string{"backend", "frontend"}, allTags: true}, entry: entry, want: false},
+		{name: "billable", criteria: searchCriteria{billable: &yes}, entry: entry, want: true},
+		{name: "not billable", criteria: searchCriteria{billable: &no}, entry: entry, want: false},
+		{name: "long enough", criteria: searchCriteria{minDuration: 90 * time.Minute}, entry: entry, want: true},
+		{name: "too long", criteria: searchCriteria{maxDuration: time.Hour}, entry: entry, want: false},
+		{name: "running only", criteria: searchCriteria{runningOnly: true}, entry: entry, want: false},
+		{name: "running lasts until now", criteria: searchCriteria{runningOnly: true, minDuration: 30 * time.Minute}, entry: running, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.criteria.matches(tt.entry, "Test Project", "", now); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := newTextMatcher("(", true); err == nil {
+		t.Error("expected an invalid regular expression to be rejected")
+	}
+}
+
+func TestHandleSearchTimeEntries(t *testing.T) {
+	day := func(date string) time.Time {
+		d, _ := time.Parse("2006-01-02", date)
+		return d.Add(9 * time.Hour)
+	}
+	entry := func(id int, description, date string, minutes int) TimeEntry {
+		return TimeEntry{
+			BaseEntity:  BaseEntity{ID: id, WorkspaceID: 456},
+			ProjectID:   intPtr(111),
+			Description: description,
+			Start:       day(date),
+			Duration:    minutes * 60,
+		}
+	}
+	entries := []TimeEntry{
+		entry(1, "Billing migration kickoff", "2024-01-10", 60),
+		entry(2, "Billing migration", "2024-02-20", 120),
+		entry(3, "Code review", "2024-03-01", 30),
+		entry(4, "billing migration cleanup", "2024-03-10", 45),
+	}
+
+	var mu sync.Mutex
+	var pages []string
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/workspaces/456/projects":
+			// The project is archived, so the API only lists it with active=both
+			if r.URL.Query().Get("active") != "both" {
+				writeJSON(w, http.StatusOK, []Project{})
+				return
+			}
+			archived := testProject
+			archived.Active = false
+			writeJSON(w, http.StatusOK, []Project{archived})
+		case "/api/v9/me/time_entries":
+			start, _ := time.Parse(time.RFC3339, r.URL.Query().Get("start_date"))
+			end, _ := time.Parse(time.RFC3339, r.URL.Query().Get("end_date"))
+			mu.Lock()
+			pages = append(pages, start.Format("01-02")+".."+end.Format("01-02"))
+			mu.Unlock()
+			var page []TimeEntry
+			for _, e := range entries {
+				if !e.Start.Before(start) && e.Start.Before(end) {
+					page = append(page, e)
+				}
+			}
+			writeJSON(w, http.StatusOK, page)
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	})
+	client.location = time.UTC
+
+	call := func(args map[string]interface{}) string {
+		t.Helper()
+		pages = nil
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		result, err := handleSearchTimeEntries(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	text := call(map[string]interface{}{
+		"query": "billing migration", "start_date": "2024-01-01", "end_date": "2024-03-16", "limit": float64(1),
+	})
+	if !strings.Contains(text, "2024-03-10 09:00 billing migration cleanup (ID: 4) - Test Project 0h 45m") ||
+		strings.Contains(text, "ID: 2") || !strings.Contains(text, "Found at least 2 time entries") ||
+		!strings.Contains(text, "Showing the first 1") {
+		t.Errorf("unexpected result: %s", text)
+	}
+	if strings.Join(pages, " ") != "02-15..03-16" {
+		t.Errorf("expected the search to stop after the newest page, fetched %v", pages)
+	}
+
+	text = call(map[string]interface{}{
+		"query": "^billing", "regex": true, "sort": "longest", "start_date": "2024-01-01", "end_date": "2024-03-16",
+	})
+	if !strings.Contains(text, "Found 3 time entries between 2024-01-01 and 2024-03-15") ||
+		strings.Index(text, "(ID: 2)") > strings.Index(text, "(ID: 1)") ||
+		strings.Index(text, "(ID: 1)") > strings.Index(text, "(ID: 4)") {
+		t.Errorf("unexpected result: %s", text)
+	}
+	if strings.Join(pages, " ") != "02-15..03-16 01-16..02-15 01-01..01-16" {
+		t.Errorf("expected every page to be fetched, got %v", pages)
+	}
+
+	text = call(map[string]interface{}{"project": "other", "start_date": "2024-03-01", "end_date": "2024-03-16"})
+	if !strings.Contains(text, "No time entries found between 2024-03-01 and 2024-03-15 (2 entries searched)") {
+		t.Errorf("unexpected result: %s", text)
+	}
+
+	text = call(map[string]interface{}{"project": "test project", "start_date": "2024-03-01", "end_date": "2024-03-16"})
+	if !strings.Contains(text, "Found 2 time entries") {
+		t.Errorf("expected entries on the archived project to be found, got: %s", text)
+	}
+}
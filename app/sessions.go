@@ -0,0 +1,183 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// SessionIdleTTL is how long a streamable HTTP session is kept after its
+	// last request
+	SessionIdleTTL = 30 * time.Minute
+
+	// MaxSessions bounds the number of open streamable HTTP sessions; when it
+	// is reached the least recently used session is dropped
+	MaxSessions = 1000
+)
+
+// errSessionToken rejects a session used with another token than the one
+// that initialized it
+var errSessionToken = errors.New("session belongs to another token")
+
+// tokenKey identifies the token an HTTP request carries without holding it
+func tokenKey(r *http.Request) string {
+	if token := TokenFromRequest(r); token != "" {
+		return TokenFingerprint(token)
+	}
+	return ""
+}
+
+// storedSession is an open session, the token it is bound to and when it
+// was last used
+type storedSession struct {
+	tokenKey string
+	lastUsed time.Time
+}
+
+// SessionStore hands out streamable HTTP session IDs and binds each session
+// to the token that initialized it, so that a session ID leaked to another
+// user is useless. Sessions expire after idleTTL without requests, and at
+// most maxSessions are kept. It is used as the transport's session ID
+// manager resolver.
+type SessionStore struct {
+	mu          sync.Mutex
+	sessions    map[string]*storedSession
+	idleTTL     time.Duration
+	maxSessions int
+	now         func() time.Time
+}
+
+// NewSessionStore creates a store expiring sessions after idleTTL that keeps
+// at most maxSessions
+func NewSessionStore(idleTTL time.Duration, maxSessions int) *SessionStore {
+	return &SessionStore{
+		sessions:    make(map[string]*storedSession),
+		idleTTL:     idleTTL,
+		maxSessions: maxSessions,
+		now:         time.Now,
+	}
+}
+
+// ResolveSessionIdManager returns the session manager for the request's
+// token. The transport's idle sweeper passes a nil request; its manager may
+// end any session.
+func (s *SessionStore) ResolveSessionIdManager(r *http.Request) server.SessionIdManager {
+	if r == nil {
+		return &sessionManager{store: s, sweeper: true}
+	}
+	return &sessionManager{store: s, tokenKey: tokenKey(r)}
+}
+
+// Guard checks that GET requests, which open the server-to-client stream,
+// carry a session of their own token; the transport itself only checks the
+// sessions of POST requests
+func (s *SessionStore) Guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			sessionID := r.Header.Get(server.HeaderKeySessionID)
+			if sessionID == "" {
+				http.Error(w, "Missing session ID", http.StatusBadRequest)
+				return
+			}
+			if terminated, err := s.validate(sessionID, tokenKey(r)); terminated || err != nil {
+				http.Error(w, "Invalid session ID", http.StatusNotFound)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// expire drops idle sessions and, when the store is full, the least
+// recently used one. The caller holds s.mu.
+func (s *SessionStore) expire(now time.Time) {
+	var oldestID string
+	var oldest time.Time
+	for id, session := range s.sessions {
+		if now.Sub(session.lastUsed) > s.idleTTL {
+			delete(s.sessions, id)
+			continue
+		}
+		if oldestID == "" || session.lastUsed.Before(oldest) {
+			oldestID, oldest = id, session.lastUsed
+		}
+	}
+	if s.maxSessions > 0 && len(s.sessions) >= s.maxSessions {
+		delete(s.sessions, oldestID)
+	}
+}
+
+// generate opens a session bound to tokenKey
+func (s *SessionStore) generate(tokenKey string) string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	id := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.expire(now)
+	s.sessions[id] = &storedSession{tokenKey: tokenKey, lastUsed: now}
+	return id
+}
+
+// validate reports whether a session has ended or belongs to another token,
+// and otherwise marks it as used
+func (s *SessionStore) validate(id, tokenKey string) (terminated bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	now := s.now()
+	if !ok || now.Sub(session.lastUsed) > s.idleTTL {
+		delete(s.sessions, id)
+		return true, nil
+	}
+	if session.tokenKey != tokenKey {
+		return false, errSessionToken
+	}
+	session.lastUsed = now
+	return false, nil
+}
+
+// terminate ends a session; only its own token may end it unless sweeper is set
+func (s *SessionStore) terminate(id, tokenKey string, sweeper bool) (notAllowed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return false
+	}
+	if !sweeper && session.tokenKey != tokenKey {
+		return true
+	}
+	delete(s.sessions, id)
+	return false
+}
+
+// sessionManager is the store seen by the requests of one token
+type sessionManager struct {
+	store    *SessionStore
+	tokenKey string
+	sweeper  bool
+}
+
+func (m *sessionManager) Generate() string {
+	return m.store.generate(m.tokenKey)
+}
+
+func (m *sessionManager) Validate(sessionID string) (isTerminated bool, err error) {
+	return m.store.validate(sessionID, m.tokenKey)
+}
+
+func (m *sessionManager) Terminate(sessionID string) (isNotAllowed bool, err error) {
+	return m.store.terminate(sessionID, m.tokenKey, m.sweeper), nil
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const testInitialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0.0"}}}`
+
+// newTestHTTPTransport serves s over the streamable HTTP transport with
+// sessions kept in store, as serveHTTP does
+func newTestHTTPTransport(t *testing.T, s *server.MCPServer, store *SessionStore, opts ...server.StreamableHTTPOption) *httptest.Server {
+	t.Helper()
+	opts = append(opts, server.WithSessionIdManagerResolver(store), server.WithSessionIdleTTL(store.idleTTL))
+	transport := server.NewStreamableHTTPServer(s, opts...)
+	ts := httptest.NewServer(store.Guard(transport))
+	t.Cleanup(func() {
+		ts.Close()
+		transport.Shutdown(context.Background())
+	})
+	return ts
+}
+
+// postMCP sends a JSON-RPC payload to the transport with an optional session ID
+func postMCP(t *testing.T, url, sessionID, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set(server.HeaderKeySessionID, sessionID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("sending request: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestStreamableHTTPServer(t *testing.T) {
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, testUser)
+	})
+
+	s := server.NewMCPServer("test-server", "1.0.0")
+	if err := SetupTools(s, client); err != nil {
+		t.Fatalf("SetupTools failed: %v", err)
+	}
+
+	ts := newTestHTTPTransport(t, s, NewSessionStore(SessionIdleTTL, MaxSessions))
+
+	init := postMCP(t, ts.URL, "", testInitialize)
+	sessionID := init.Header.Get(server.HeaderKeySessionID)
+	if init.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("expected session on initialize, got %d %q", init.StatusCode, sessionID)
+	}
+
+	t.Run("notification is accepted", func(t *testing.T) {
+		resp := postMCP(t, ts.URL, sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+		if resp.StatusCode != http.StatusAccepted {
+			t.Errorf("expected 202, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("tool call in session", func(t *testing.T) {
+		resp := postMCP(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"test_connection","arguments":{}}}`)
+		var result struct {
+			Result struct {
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"result"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if len(result.Result.Content) == 0 || !strings.Contains(result.Result.Content[0].Text, "Authentication successful") {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("missing session", func(t *testing.T) {
+		resp := postMCP(t, ts.URL, "", `{"jsonrpc":"2.0","id":5,"method":"tools/list"}`)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		resp := postMCP(t, ts.URL, sessionID, `{not json`)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("GET opens a stream", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		req.Header.Set(server.HeaderKeySessionID, sessionID)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Errorf("expected an event stream, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+	})
+
+	t.Run("GET needs a session", func(t *testing.T) {
+		resp, err := http.Get(ts.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("delete ends session", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, ts.URL, nil)
+		req.Header.Set(server.HeaderKeySessionID, sessionID)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected 200, got %d", resp.StatusCode)
+		}
+
+		after := postMCP(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":6,"method":"ping"}`)
+		if after.StatusCode != http.StatusNotFound {
+			t.Errorf("expected 404 for ended session, got %d", after.StatusCode)
+		}
+	})
+}
+
+func TestSessionStore(t *testing.T) {
+	now := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	store := NewSessionStore(time.Hour, 2)
+	store.now = func() time.Time { return now }
+
+	alice := store.generate("alice")
+	if terminated, err := store.validate(alice, "alice"); terminated || err != nil {
+		t.Fatalf("expected a valid session, got %v %v", terminated, err)
+	}
+	if _, err := store.validate(alice, "mallory"); err == nil {
+		t.Error("expected a session used with another token to be rejected")
+	}
+	if store.terminate(alice, "mallory", false) != true {
+		t.Error("expected another token not to end the session")
+	}
+
+	t.Run("idle sessions expire", func(t *testing.T) {
+		now = now.Add(2 * time.Hour)
+		if terminated, _ := store.validate(alice, "alice"); !terminated {
+			t.Error("expected an idle session to have ended")
+		}
+	})
+
+	t.Run("least recently used session is dropped at the cap", func(t *testing.T) {
+		first := store.generate("alice")
+		now = now.Add(time.Minute)
+		second := store.generate("bob")
+		now = now.Add(time.Minute)
+		store.generate("carol")
+		if terminated, _ := store.validate(first, "alice"); !terminated {
+			t.Error("expected the oldest session to be dropped")
+		}
+		if terminated, _ := store.validate(second, "bob"); terminated {
+			t.Error("expected the newer session to be kept")
+		}
+	})
+
+	t.Run("sweeper ends any session", func(t *testing.T) {
+		bob := store.generate("bob")
+		manager := store.ResolveSessionIdManager(nil)
+		if notAllowed, _ := manager.Terminate(bob); notAllowed {
+			t.Error("expected the sweeper to end the session")
+		}
+		if terminated, _ := store.validate(bob, "bob"); !terminated {
+			t.Error("expected the session to have ended")
+		}
+	})
+}
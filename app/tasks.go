@@ -0,0 +1,332 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// taskDescription documents the task argument of tools that create entries
+const taskDescription = "Task, by name or ID; a name is looked up in the entry's project. Tasks need a paid Toggl plan"
+
+// tasksUnavailable reports whether err is the API refusing task requests,
+// as it does with 402 or 403 in workspaces whose plan has no tasks
+func tasksUnavailable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusPaymentRequired || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// taskError converts an error from resolving or changing a task into a
+// tool result. Invalid arguments are returned as errors.
+func taskError(action string, workspaceID int, err error) (*mcp.CallToolResult, error) {
+	if tasksUnavailable(err) {
+		return mcp.NewToolResultError(fmt.Sprintf(
+			"Failed to %s: tasks are not available in workspace %d; they need a paid Toggl plan", action, workspaceID)), nil
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to %s: %s", action, apiErr.Error())), nil
+	}
+	return nil, err
+}
+
+// resolveTask reads the task argument, a name or an ID, and returns the
+// task's ID, or nil when the argument is absent. A name is looked up among
+// the tasks of projectID, so it needs a project; with a project, an ID is
+// checked to belong to it.
+func (c *TogglClient) resolveTask(
+	ctx context.Context,
+	workspaceID int,
+	projectID *int,
+	args map[string]interface{},
+) (*int, error) {
+	var name string
+	switch v := args["task"].(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		return c.checkTaskID(ctx, workspaceID, projectID, int(v))
+	case string:
+		if id, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return c.checkTaskID(ctx, workspaceID, projectID, id)
+		}
+		if name = strings.TrimSpace(v); name == "" {
+			return nil, nil
+		}
+	default:
+		return nil, errors.New("task must be a name or an ID")
+	}
+
+	if projectID == nil {
+		return nil, fmt.Errorf("task %q is given by name, which needs a project", name)
+	}
+	tasks, err := c.GetTasks(ctx, workspaceID, *projectID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		if strings.EqualFold(task.Name, name) {
+			return &task.ID, nil
+		}
+	}
+	return nil, fmt.Errorf("no task named %q in project %d", name, *projectID)
+}
+
+// checkTaskID returns id if the task is in projectID. Without a project
+// there is nothing to check it against.
+func (c *TogglClient) checkTaskID(ctx context.Context, workspaceID int, projectID *int, id int) (*int, error) {
+	if projectID == nil {
+		return &id, nil
+	}
+	if _, err := c.GetTask(ctx, workspaceID, *projectID, id); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("no task %d in project %d", id, *projectID)
+		}
+		return nil, err
+	}
+	return &id, nil
+}
+
+// taskNames looks up the names of the tasks of the given projects. In
+// workspaces without tasks, no names are found rather than failing.
+func (c *TogglClient) taskNames(ctx context.Context, workspaceID int, projectIDs map[int]bool) (map[int]string, error) {
+	names := make(map[int]string)
+	for projectID := range projectIDs {
+		tasks, err := c.GetTasks(ctx, workspaceID, projectID, nil)
+		if tasksUnavailable(err) {
+			c.logger.Debug("skipping task names", slog.Int("workspace_id", workspaceID))
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			names[task.ID] = task.Name
+		}
+	}
+	return names, nil
+}
+
+// entryTaskNames looks up the names of the tasks entries are on
+func (c *TogglClient) entryTaskNames(ctx context.Context, workspaceID int, entries []TimeEntry) (map[int]string, error) {
+	projectIDs := make(map[int]bool)
+	for _, entry := range entries {
+		if entry.TaskID != nil && entry.ProjectID != nil {
+			projectIDs[*entry.ProjectID] = true
+		}
+	}
+	return c.taskNames(ctx, workspaceID, projectIDs)
+}
+
+// taskName returns the name of a task, falling back to its ID
+func taskName(names map[int]string, taskID int) string {
+	if name := names[taskID]; name != "" {
+		return name
+	}
+	return fmt.Sprintf("Task %d", taskID)
+}
+
+// projectArgument reads the project of a task tool, given as project_id or
+// by name; a name shared with an archived project means the active one
+func (c *TogglClient) projectArgument(ctx context.Context, workspaceID int, args map[string]interface{}) (int, error) {
+	if id := getOptionalNumber(args, "project_id"); id != nil {
+		return *id, nil
+	}
+	name := getOptionalString(args, "project")
+	if name == "" {
+		return 0, errors.New("project or project_id is required")
+	}
+	projects, err := c.GetAllProjects(ctx, workspaceID)
+	if err != nil {
+		return 0, err
+	}
+	found := 0
+	for _, project := range projects {
+		if strings.EqualFold(project.Name, name) {
+			if project.Active {
+				return project.ID, nil
+			}
+			found = project.ID
+		}
+	}
+	if found != 0 {
+		return found, nil
+	}
+	return 0, fmt.Errorf("unknown project %q", name)
+}
+
+// formatTaskLine describes a task on one line
+func formatTaskLine(task Task) string {
+	status := "inactive"
+	if task.Active {
+		status = "active"
+	}
+	line := fmt.Sprintf("- %s (ID: %d, %s", task.Name, task.ID, status)
+	if task.EstimatedSeconds != nil && *task.EstimatedSeconds > 0 {
+		line += ", estimated " + formatHours(*task.EstimatedSeconds)
+	}
+	if task.TrackedSeconds > 0 {
+		line += ", tracked " + formatHours(task.TrackedSeconds)
+	}
+	return line + ")"
+}
+
+// taskTools returns the tools that list, create and update tasks
+func taskTools(client *TogglClient) []toolDefinition {
+	project := []mcp.ToolOption{
+		mcp.WithNumber("project_id", mcp.Description("Project of the task, by ID")),
+		mcp.WithString("project", mcp.Description("Or the project, by name")),
+		mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
+	}
+
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool("get_tasks", append([]mcp.ToolOption{
+				mcp.WithDescription("Get the tasks of a project. Tasks need a paid Toggl plan."),
+				mcp.WithBoolean("active", mcp.Description("Only active (true) or inactive (false) tasks")),
+			}, project...)...),
+			handler: wrapHandler(client, handleGetTasks),
+		},
+		{
+			tool: mcp.NewTool("create_task", append([]mcp.ToolOption{
+				mcp.WithDescription("Create a task in a project. Tasks need a paid Toggl plan."),
+				mcp.WithString("name", mcp.Required()),
+				mcp.WithNumber("estimated_hours", mcp.Description("Estimated hours")),
+			}, project...)...),
+			handler: wrapHandler(client, handleCreateTask),
+		},
+		{
+			tool: mcp.NewTool("update_task", append([]mcp.ToolOption{
+				mcp.WithDescription("Rename, estimate, deactivate or reactivate a task. Tasks need a paid Toggl plan."),
+				mcp.WithNumber("task_id", mcp.Required()),
+				mcp.WithString("name", mcp.Description("New name")),
+				mcp.WithNumber("estimated_hours", mcp.Description("New estimate in hours; 0 removes it")),
+				mcp.WithBoolean("active", mcp.Description("Whether the task is active")),
+			}, project...)...),
+			handler: wrapHandler(client, handleUpdateTask),
+		},
+	}
+}
+
+func handleGetTasks(
+	ctx context.Context,
+	client *TogglClient,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	workspaceID, err := getWorkspaceID(args, client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace_id: %w", err)
+	}
+	projectID, err := client.projectArgument(ctx, workspaceID, args)
+	if err != nil {
+		return taskError("get tasks", workspaceID, err)
+	}
+	var active *bool
+	if a, ok := args["active"].(bool); ok {
+		active = &a
+	}
+
+	tasks, err := client.GetTasks(ctx, workspaceID, projectID, active)
+	if err != nil {
+		return taskError("get tasks", workspaceID, err)
+	}
+
+	if wantsJSON(ctx) {
+		return jsonResult(tasks)
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "Found %d tasks in project %d:\n", len(tasks), projectID)
+	for _, task := range tasks {
+		result.WriteString(formatTaskLine(task) + "\n")
+	}
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+func handleCreateTask(
+	ctx context.Context,
+	client *TogglClient,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	name, err := getRequiredString(args, "name")
+	if err != nil {
+		return nil, fmt.Errorf("invalid name: %w", err)
+	}
+	workspaceID, err := getWorkspaceID(args, client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace_id: %w", err)
+	}
+	projectID, err := client.projectArgument(ctx, workspaceID, args)
+	if err != nil {
+		return taskError("create task", workspaceID, err)
+	}
+
+	fields := map[string]interface{}{
+		"name":   name,
+		"active": true,
+	}
+	if hours, ok := args["estimated_hours"].(float64); ok && hours > 0 {
+		fields["estimated_seconds"] = int(hours * 3600)
+	}
+
+	task, err := client.CreateTask(ctx, workspaceID, projectID, fields)
+	if err != nil {
+		return taskError("create task", workspaceID, err)
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Created task: %s (ID: %d) in project %d", task.Name, task.ID, projectID)), nil
+}
+
+func handleUpdateTask(
+	ctx context.Context,
+	client *TogglClient,
+	req mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	taskID, err := getRequiredNumber(args, "task_id")
+	if err != nil {
+		return nil, err
+	}
+	workspaceID, err := getWorkspaceID(args, client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace_id: %w", err)
+	}
+
+	fields := make(map[string]interface{})
+	if name := getOptionalString(args, "name"); name != "" {
+		fields["name"] = name
+	}
+	if hours, ok := args["estimated_hours"].(float64); ok {
+		if hours < 0 {
+			return nil, errors.New("estimated_hours must not be negative")
+		}
+		fields["estimated_seconds"] = nil
+		if hours > 0 {
+			fields["estimated_seconds"] = int(hours * 3600)
+		}
+	}
+	if active, ok := args["active"].(bool); ok {
+		fields["active"] = active
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("no changes given: set name, estimated_hours or active")
+	}
+
+	projectID, err := client.projectArgument(ctx, workspaceID, args)
+	if err != nil {
+		return taskError("update task", workspaceID, err)
+	}
+	task, err := client.UpdateTask(ctx, workspaceID, projectID, taskID, fields)
+	if err != nil {
+		return taskError("update task", workspaceID, err)
+	}
+	return mcp.NewToolResultText("Updated task:\n" + formatTaskLine(task)), nil
+}
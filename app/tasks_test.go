@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var testTasks = []Task{
+	{BaseEntity: BaseEntity{ID: 11, WorkspaceID: 456}, Name: "Design", ProjectID: 111, Active: true},
+	{BaseEntity: BaseEntity{ID: 12, WorkspaceID: 456}, Name: "Review", ProjectID: 111, Active: true},
+}
+
+func TestResolveTask(t *testing.T) {
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/workspaces/456/projects/111/tasks":
+			writeJSON(w, http.StatusOK, testTasks)
+		case "/api/v9/workspaces/456/projects/111/tasks/12":
+			writeJSON(w, http.StatusOK, testTasks[1])
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	})
+
+	tests := []struct {
+		name      string
+		task      interface{}
+		projectID *int
+		want      *int
+		wantErr   string
+	}{
+		{name: "absent", task: nil},
+		{name: "ID", task: float64(12), want: intPtr(12)},
+		{name: "numeric string", task: "12", want: intPtr(12)},
+		{name: "ID in project", task: float64(12), projectID: intPtr(111), want: intPtr(12)},
+		{name: "ID in another project", task: "13", projectID: intPtr(111), wantErr: "no task 13 in project 111"},
+		{name: "name", task: "review", projectID: intPtr(111), want: intPtr(12)},
+		{name: "name without project", task: "Review", wantErr: "needs a project"},
+		{name: "unknown name", task: "Deploy", projectID: intPtr(111), wantErr: `no task named "Deploy"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]interface{}{}
+			if tt.task != nil {
+				args["task"] = tt.task
+			}
+			got, err := client.resolveTask(context.Background(), 456, tt.projectID, args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("resolveTask() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskTools(t *testing.T) {
+	var lastBody map[string]interface{}
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		lastBody = nil
+		json.NewDecoder(r.Body).Decode(&lastBody)
+
+		switch {
+		case r.URL.Path == "/api/v9/workspaces/456/projects":
+			writeArchivedProjects(w, r, testProject)
+		case r.URL.Path == "/api/v9/workspaces/789/projects/111/tasks":
+			writeError(w, http.StatusPaymentRequired, "upgrade your plan")
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v9/workspaces/456/projects/111/tasks":
+			writeJSON(w, http.StatusOK, testTasks)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v9/workspaces/456/projects/111/tasks":
+			writeJSON(w, http.StatusOK, Task{BaseEntity: BaseEntity{ID: 13}, Name: lastBody["name"].(string), ProjectID: 111})
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v9/workspaces/456/projects/111/tasks/12":
+			writeJSON(w, http.StatusOK, Task{BaseEntity: BaseEntity{ID: 12}, Name: "Review", ProjectID: 111})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v9/workspaces/456/time_entries":
+			writeJSON(w, http.StatusOK, testTimeEntry)
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	})
+
+	call := func(handler func(context.Context, *TogglClient, mcp.CallToolRequest) (*mcp.CallToolResult, error),
+		args map[string]interface{}) *mcp.CallToolResult {
+		t.Helper()
+		if _, ok := args["workspace_id"]; !ok {
+			args["workspace_id"] = float64(456)
+		}
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		result, err := handler(context.Background(), client, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+	text := func(result *mcp.CallToolResult) string {
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("get tasks by project name", func(t *testing.T) {
+		got := text(call(handleGetTasks, map[string]interface{}{"project": "test project"}))
+		if !strings.Contains(got, "Found 2 tasks in project 111") || !strings.Contains(got, "- Review (ID: 12, active)") {
+			t.Errorf("unexpected result: %s", got)
+		}
+	})
+
+	t.Run("create task with estimate", func(t *testing.T) {
+		got := text(call(handleCreateTask, map[string]interface{}{
+			"project_id": float64(111), "name": "Deploy", "estimated_hours": 1.5,
+		}))
+		if got != "Created task: Deploy (ID: 13) in project 111" || lastBody["estimated_seconds"] != float64(5400) {
+			t.Errorf("unexpected result %q for %v", got, lastBody)
+		}
+	})
+
+	t.Run("update task removes estimate", func(t *testing.T) {
+		result := call(handleUpdateTask, map[string]interface{}{
+			"project_id": float64(111), "task_id": float64(12), "estimated_hours": float64(0), "active": false,
+		})
+		if result.IsError || lastBody["active"] != false {
+			t.Errorf("unexpected result %v for %v", result.Content, lastBody)
+		}
+		if v, ok := lastBody["estimated_seconds"]; !ok || v != nil {
+			t.Errorf("expected estimated_seconds to be cleared, got %v", lastBody)
+		}
+	})
+
+	t.Run("start entry on a task by name", func(t *testing.T) {
+		result := call(handleStartTimeEntry, map[string]interface{}{
+			"description": "Work", "project_id": float64(111), "task": "Design",
+		})
+		if result.IsError || lastBody["task_id"] != float64(11) {
+			t.Errorf("unexpected result %v for %v", result.Content, lastBody)
+		}
+	})
+
+	t.Run("plan without tasks", func(t *testing.T) {
+		result := call(handleGetTasks, map[string]interface{}{"project_id": float64(111), "workspace_id": float64(789)})
+		if !result.IsError || !strings.Contains(text(result), "tasks are not available in workspace 789") {
+			t.Errorf("unexpected result: %v", result.Content)
+		}
+	})
+}
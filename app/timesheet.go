@@ -0,0 +1,359 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// TimesheetSettings is the layout of the weekly timesheet; the hours each
+// day should have come from the Schedule
+type TimesheetSettings struct {
+	WeekStart time.Weekday
+}
+
+// DefaultTimesheetSettings starts weeks on Monday
+func DefaultTimesheetSettings() TimesheetSettings {
+	s, _ := DefaultConfig().Timesheet.Parse()
+	return s
+}
+
+// Parse checks the config and converts it into TimesheetSettings
+func (c TimesheetConfig) Parse() (TimesheetSettings, error) {
+	var s TimesheetSettings
+	var err error
+	if s.WeekStart, err = parseWeekday(c.WeekStart); err != nil {
+		return s, fmt.Errorf("week_start: %w", err)
+	}
+	return s, nil
+}
+
+// isoWeekPattern matches ISO weeks such as 2024-W11
+var isoWeekPattern = regexp.MustCompile(`^(\d{4})-?W(\d{1,2})$`)
+
+// isoWeekMonday returns the Monday of an ISO week, the week containing the
+// year's first Thursday being week 1
+func isoWeekMonday(year, week int, loc *time.Location) (time.Time, error) {
+	jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, loc)
+	monday := jan4.AddDate(0, 0, (week-1)*7-(int(jan4.Weekday())+6)%7)
+	if y, w := monday.ISOWeek(); week < 1 || y != year || w != week {
+		return monday, fmt.Errorf("%d has no ISO week %d", year, week)
+	}
+	return monday, nil
+}
+
+// weekStart returns the first day of the week containing day
+func weekStart(day time.Time, start time.Weekday) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())-int(start)+7)%7)
+}
+
+// timesheetWeek reads the week argument, an ISO week such as 2024-W11, a
+// week number of this year or any date inside the week, and returns the
+// midnight the week starts. ISO weeks run Monday to Sunday; with another
+// week start, the week containing the ISO week's Monday is used.
+func timesheetWeek(args map[string]interface{}, start time.Weekday, today time.Time) (time.Time, error) {
+	thisYear, _ := today.ISOWeek()
+	day := today
+	var err error
+	switch v := args["week"].(type) {
+	case nil:
+	case float64:
+		day, err = isoWeekMonday(thisYear, int(v), today.Location())
+	case string:
+		v = strings.TrimSpace(v)
+		if m := isoWeekPattern.FindStringSubmatch(strings.ToUpper(v)); m != nil {
+			year, _ := strconv.Atoi(m[1])
+			week, _ := strconv.Atoi(m[2])
+			day, err = isoWeekMonday(year, week, today.Location())
+		} else if week, convErr := strconv.Atoi(v); convErr == nil {
+			day, err = isoWeekMonday(thisYear, week, today.Location())
+		} else if date, parseErr := time.Parse("2006-01-02", v); parseErr == nil {
+			day = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, today.Location())
+		} else {
+			err = fmt.Errorf("week must be an ISO week such as 2024-W11, a week number or a date (YYYY-MM-DD), got %q", v)
+		}
+	default:
+		err = errors.New("week must be an ISO week, a week number or a date")
+	}
+	if err != nil {
+		return day, err
+	}
+	return weekStart(day, start), nil
+}
+
+// TimesheetRow is the time tracked on one project on each day of a week
+type TimesheetRow struct {
+	ProjectID    *int   `json:"project_id,omitempty"`
+	Project      string `json:"project"`
+	DaySeconds   [7]int `json:"day_seconds"`
+	TotalSeconds int    `json:"total_seconds"`
+}
+
+// WeeklyTimesheet is the time tracked in a week per project and day
+type WeeklyTimesheet struct {
+	// Week is the ISO week sharing most days with the week
+	Week         string         `json:"week"`
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	Days         [7]string      `json:"days"`
+	Rows         []TimesheetRow `json:"rows"`
+	DaySeconds   [7]int         `json:"day_seconds"`
+	TotalSeconds int            `json:"total_seconds"`
+	// DayTargetSeconds are the hours due each day under the schedule;
+	// DaysOff names the working days that are holidays or vacation
+	DayTargetSeconds [7]int            `json:"day_target_seconds"`
+	DaysOff          map[string]string `json:"days_off,omitempty"`
+	// UnderTarget are the past days with less than their target
+	UnderTarget []string `json:"under_target"`
+	Rounding    string   `json:"rounding,omitempty"`
+}
+
+// buildWeeklyTimesheet totals entries per project and day of the week
+// starting at start. Entries count on the day they start. Days that have
+// ended with less than the schedule makes due are listed as under target.
+func buildWeeklyTimesheet(
+	entries []TimeEntry,
+	projectNames map[int]string,
+	start time.Time,
+	schedule Schedule,
+	rounding Rounding,
+	now time.Time,
+) WeeklyTimesheet {
+	var days [8]time.Time
+	for i := range days {
+		days[i] = start.AddDate(0, 0, i)
+	}
+	year, week := days[3].ISOWeek()
+	sheet := WeeklyTimesheet{
+		Week:        fmt.Sprintf("%d-W%02d", year, week),
+		From:        days[0].Format("2006-01-02"),
+		To:          days[6].Format("2006-01-02"),
+		Rows:        []TimesheetRow{},
+		UnderTarget: []string{},
+	}
+	for i := range sheet.Days {
+		sheet.Days[i] = days[i].Format("2006-01-02")
+		due, reason := schedule.due(days[i])
+		sheet.DayTargetSeconds[i] = int(due.Seconds())
+		if reason != "" {
+			if sheet.DaysOff == nil {
+				sheet.DaysOff = make(map[string]string)
+			}
+			sheet.DaysOff[sheet.Days[i]] = reason
+		}
+	}
+	if rounding.Enabled() {
+		sheet.Rounding = rounding.String()
+	}
+
+	rows := make(map[int]*TimesheetRow)
+	for _, entry := range entries {
+		day := -1
+		for i := 0; i < 7; i++ {
+			if !entry.Start.Before(days[i]) && entry.Start.Before(days[i+1]) {
+				day = i
+			}
+		}
+		seconds := rounding.RoundSeconds(int(entryEnd(entry, now).Sub(entry.Start).Seconds()))
+		if day < 0 || seconds <= 0 {
+			continue
+		}
+
+		var projectID int
+		if entry.ProjectID != nil {
+			projectID = *entry.ProjectID
+		}
+		row, ok := rows[projectID]
+		if !ok {
+			row = &TimesheetRow{ProjectID: entry.ProjectID, Project: noProjectName}
+			if entry.ProjectID != nil {
+				row.Project = projectNames[projectID]
+				if row.Project == "" {
+					row.Project = fmt.Sprintf("Project %d", projectID)
+				}
+			}
+			rows[projectID] = row
+		}
+		row.DaySeconds[day] += seconds
+		row.TotalSeconds += seconds
+		sheet.DaySeconds[day] += seconds
+		sheet.TotalSeconds += seconds
+	}
+
+	for _, row := range rows {
+		sheet.Rows = append(sheet.Rows, *row)
+	}
+	sort.Slice(sheet.Rows, func(i, j int) bool {
+		return sheet.Rows[i].Project < sheet.Rows[j].Project
+	})
+
+	for i := 0; i < 7; i++ {
+		if !days[i+1].After(now) && sheet.DaySeconds[i] < sheet.DayTargetSeconds[i] {
+			sheet.UnderTarget = append(sheet.UnderTarget, sheet.Days[i])
+		}
+	}
+	return sheet
+}
+
+// formatWeeklyTimesheet renders the timesheet as a Markdown table, marking
+// the days under target and listing holidays and vacation
+func formatWeeklyTimesheet(sheet WeeklyTimesheet) string {
+	under := make(map[string]bool, len(sheet.UnderTarget))
+	for _, day := range sheet.UnderTarget {
+		under[day] = true
+	}
+	labels := make([]string, 7)
+	for i, day := range sheet.Days {
+		date, _ := time.Parse("2006-01-02", day)
+		labels[i] = date.Format("Mon 2")
+	}
+	cell := func(seconds int) string {
+		if seconds == 0 {
+			return "-"
+		}
+		return formatHours(seconds)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Timesheet for week %s (%s to %s)", sheet.Week, sheet.From, sheet.To)
+	if sheet.Rounding != "" {
+		fmt.Fprintf(&b, ", rounded %s", sheet.Rounding)
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString("| Project | " + strings.Join(labels, " | ") + " | Total |\n")
+	b.WriteString("| --- |" + strings.Repeat(" ---: |", 8) + "\n")
+	for _, row := range sheet.Rows {
+		b.WriteString("| " + row.Project + " |")
+		for _, seconds := range row.DaySeconds {
+			b.WriteString(" " + cell(seconds) + " |")
+		}
+		fmt.Fprintf(&b, " %s |\n", formatHours(row.TotalSeconds))
+	}
+	b.WriteString("| **Total** |")
+	for i, seconds := range sheet.DaySeconds {
+		fmt.Fprintf(&b, " **%s**", formatHours(seconds))
+		if under[sheet.Days[i]] {
+			b.WriteString(" ⚠️")
+		}
+		b.WriteString(" |")
+	}
+	fmt.Fprintf(&b, " **%s** |\n", formatHours(sheet.TotalSeconds))
+
+	if len(sheet.UnderTarget) > 0 {
+		var days []string
+		for i, day := range sheet.Days {
+			if under[day] {
+				days = append(days, fmt.Sprintf("%s (%s of %s)", labels[i],
+					formatHours(sheet.DaySeconds[i]), formatHours(sheet.DayTargetSeconds[i])))
+			}
+		}
+		fmt.Fprintf(&b, "\n⚠️ Under target: %s\n", strings.Join(days, ", "))
+	}
+	if len(sheet.DaysOff) > 0 {
+		var days []string
+		for i, day := range sheet.Days {
+			if reason := sheet.DaysOff[day]; reason != "" {
+				days = append(days, fmt.Sprintf("%s (%s)", labels[i], reason))
+			}
+		}
+		fmt.Fprintf(&b, "\nNo hours due: %s\n", strings.Join(days, ", "))
+	}
+	return b.String()
+}
+
+// timesheetTools returns the tool that shows a week's time as a grid
+func timesheetTools(client *TogglClient, settings TimesheetSettings, schedule Schedule) []toolDefinition {
+	return []toolDefinition{
+		{
+			tool: mcp.NewTool(
+				"weekly_timesheet",
+				mcp.WithDescription("Show a week's tracked time as a timesheet grid: one row per project, one column per day, with totals. Days with less than the configured schedule makes due are highlighted; holidays and vacation days have no hours due."),
+				mcp.WithString("week",
+					mcp.Description("ISO week such as 2024-W11, a week number of this year, or any date inside the week (YYYY-MM-DD). Default: this week")),
+				mcp.WithString("format",
+					mcp.Enum("markdown", "json"),
+					mcp.Description("Output format (default markdown, or json when the server outputs JSON)"),
+				),
+				mcp.WithNumber("target_hours", mcp.Description("Hours each working day of the schedule should have instead of its own (0 flags nothing)")),
+				mcp.WithBoolean("rounded", mcp.Description("Round each entry with the configured rounding before totalling")),
+				mcp.WithNumber("workspace_id", mcp.Description(workspaceIDDescription)),
+			),
+			handler: wrapHandler(client, handleWeeklyTimesheet(settings, schedule)),
+		},
+	}
+}
+
+func handleWeeklyTimesheet(
+	settings TimesheetSettings,
+	schedule Schedule,
+) func(context.Context, *TogglClient, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, client *TogglClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := req.GetArguments()
+		workspaceID, err := getWorkspaceID(args, client)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace_id: %w", err)
+		}
+		format := getOptionalString(args, "format")
+		switch format {
+		case "":
+			format = "markdown"
+			if wantsJSON(ctx) {
+				format = OutputJSON
+			}
+		case "markdown", OutputJSON:
+		default:
+			return nil, fmt.Errorf("format must be markdown or json")
+		}
+		if hours, ok := args["target_hours"].(float64); ok {
+			if hours < 0 || hours > 24 {
+				return nil, errors.New("target_hours must be between 0 and 24")
+			}
+			schedule = schedule.withDayHours(time.Duration(hours * float64(time.Hour)))
+		}
+		rounding, err := requestedRounding(ctx, args, "rounded")
+		if err != nil {
+			return nil, err
+		}
+
+		loc := client.timeZone()
+		now := time.Now().In(loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		start, err := timesheetWeek(args, settings.WeekStart, today)
+		if err != nil {
+			return nil, err
+		}
+
+		projects, err := client.GetAllProjects(ctx, workspaceID)
+		if err != nil {
+			return apiErrorResult("build timesheet", err)
+		}
+		projectNames := make(map[int]string, len(projects))
+		for _, project := range projects {
+			projectNames[project.ID] = project.Name
+		}
+		entries, err := client.GetTimeEntries(ctx, start, start.AddDate(0, 0, 7))
+		if err != nil {
+			return apiErrorResult("build timesheet", err)
+		}
+		var selected []TimeEntry
+		for _, entry := range entries {
+			if entry.WorkspaceID == workspaceID {
+				selected = append(selected, entry)
+			}
+		}
+
+		sheet := buildWeeklyTimesheet(selected, projectNames, start, schedule, rounding, now)
+		if format == OutputJSON {
+			return jsonResult(sheet)
+		}
+		return mcp.NewToolResultText(formatWeeklyTimesheet(sheet)), nil
+	}
+}
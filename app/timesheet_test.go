@@ -0,0 +1,190 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestTimesheetWeek(t *testing.T) {
+	today := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		week    interface{}
+		start   time.Weekday
+		want    string
+		wantErr bool
+	}{
+		{name: "this week", start: time.Monday, want: "2024-03-11"},
+		{name: "this week from Sunday", start: time.Sunday, want: "2024-03-10"},
+		{name: "ISO week", week: "2024-W01", start: time.Monday, want: "2024-01-01"},
+		{name: "ISO week 53", week: "2020w53", start: time.Monday, want: "2020-12-28"},
+		{name: "ISO week from Sunday", week: "2024-W11", start: time.Sunday, want: "2024-03-10"},
+		{name: "week number", week: float64(11), start: time.Monday, want: "2024-03-11"},
+		{name: "week number as text", week: "12", start: time.Monday, want: "2024-03-18"},
+		{name: "date", week: "2024-03-17", start: time.Monday, want: "2024-03-11"},
+		{name: "date from Sunday", week: "2024-03-17", start: time.Sunday, want: "2024-03-17"},
+		{name: "missing ISO week", week: "2021-W53", start: time.Monday, wantErr: true},
+		{name: "not a week", week: "next week", start: time.Monday, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]interface{}{}
+			if tt.week != nil {
+				args["week"] = tt.week
+			}
+			got, err := timesheetWeek(args, tt.start, today)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil || got.Format("2006-01-02") != tt.want {
+				t.Errorf("timesheetWeek() = %v, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildWeeklyTimesheet(t *testing.T) {
+	// lintEntry puts entries on Friday 2024-03-15
+	entry := func(id int, project *int, day int, start, end string) TimeEntry {
+		e := lintEntry(id, "Work", start, end, false)
+		e.ProjectID = project
+		e.Start = e.Start.AddDate(0, 0, day-4)
+		stop := e.Stop.AddDate(0, 0, day-4)
+		e.Stop = &stop
+		return e
+	}
+	entries := []TimeEntry{
+		entry(1, intPtr(111), 0, "09:00", "17:00"),
+		entry(2, intPtr(111), 1, "09:00", "12:00"),
+		entry(3, intPtr(222), 1, "13:00", "17:00"),
+		entry(4, nil, 2, "09:00", "17:00"),
+		entry(5, intPtr(111), 4, "09:00", "18:00"),
+		entry(6, intPtr(222), 5, "10:00", "11:00"),
+		entry(7, intPtr(111), 7, "09:00", "17:00"),
+	}
+	names := map[int]string{111: "Website", 222: "Support"}
+	start := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 16, 12, 0, 0, 0, time.UTC)
+
+	sheet := buildWeeklyTimesheet(entries, names, start, DefaultSchedule(), Rounding{}, now)
+	if sheet.Week != "2024-W11" || sheet.From != "2024-03-11" || sheet.To != "2024-03-17" {
+		t.Errorf("unexpected week: %+v", sheet)
+	}
+	if len(sheet.Rows) != 3 || sheet.Rows[0].Project != noProjectName || sheet.Rows[1].Project != "Support" {
+		t.Fatalf("unexpected rows: %+v", sheet.Rows)
+	}
+	if website := sheet.Rows[2]; website.DaySeconds != [7]int{8 * 3600, 3 * 3600, 0, 0, 9 * 3600, 0, 0} ||
+		website.TotalSeconds != 20*3600 {
+		t.Errorf("unexpected website row: %+v", website)
+	}
+	if sheet.TotalSeconds != 33*3600 || strings.Join(sheet.UnderTarget, ",") != "2024-03-12,2024-03-14" {
+		t.Errorf("unexpected totals: %+v", sheet)
+	}
+
+	text := formatWeeklyTimesheet(sheet)
+	for _, want := range []string{
+		"Timesheet for week 2024-W11 (2024-03-11 to 2024-03-17)",
+		"| Project | Mon 11 | Tue 12 | Wed 13 | Thu 14 | Fri 15 | Sat 16 | Sun 17 | Total |",
+		"| Website | 8h 00m | 3h 00m | - | - | 9h 00m | - | - | 20h 00m |",
+		"| **Total** | **8h 00m** | **7h 00m** ⚠️ | **8h 00m** | **0h 00m** ⚠️ |",
+		"⚠️ Under target: Tue 12 (7h 00m of 8h 00m), Thu 14 (0h 00m of 8h 00m)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+
+	midweek := buildWeeklyTimesheet(entries, names, start, DefaultSchedule(), Rounding{},
+		time.Date(2024, 3, 14, 10, 0, 0, 0, time.UTC))
+	if strings.Join(midweek.UnderTarget, ",") != "2024-03-12" {
+		t.Errorf("expected only ended days under target, got %v", midweek.UnderTarget)
+	}
+
+	// The schedule's own hours apply, and holidays and vacation have none due
+	schedule := DefaultSchedule()
+	schedule.Hours[time.Tuesday] = 6 * time.Hour
+	schedule.Hours[time.Friday] = 10 * time.Hour
+	schedule.Holidays["2024-03-14"] = true
+	scheduled := buildWeeklyTimesheet(entries, names, start, schedule, Rounding{}, now)
+	if strings.Join(scheduled.UnderTarget, ",") != "2024-03-15" ||
+		scheduled.DayTargetSeconds != [7]int{8 * 3600, 6 * 3600, 8 * 3600, 0, 10 * 3600, 0, 0} {
+		t.Errorf("unexpected targets: %v, under %v", scheduled.DayTargetSeconds, scheduled.UnderTarget)
+	}
+	text = formatWeeklyTimesheet(scheduled)
+	for _, want := range []string{"⚠️ Under target: Fri 15 (9h 00m of 10h 00m)", "No hours due: Thu 14 (holiday)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestHandleWeeklyTimesheet(t *testing.T) {
+	monday := lintEntry(1, "Work", "09:00", "17:30", true)
+	monday.Start = monday.Start.AddDate(0, 0, -4)
+	stop := monday.Stop.AddDate(0, 0, -4)
+	monday.Stop = &stop
+	elsewhere := lintEntry(2, "Other", "09:00", "10:00", true)
+	elsewhere.WorkspaceID = 789
+
+	var query string
+	_, client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/workspaces/456/projects":
+			writeArchivedProjects(w, r, testProject)
+		case "/api/v9/me/time_entries":
+			query = r.URL.RawQuery
+			writeJSON(w, http.StatusOK, []TimeEntry{monday, elsewhere})
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	})
+	client.location = time.UTC
+	handler := handleWeeklyTimesheet(TimesheetSettings{WeekStart: time.Monday}, DefaultSchedule())
+
+	call := func(args map[string]interface{}) (*mcp.CallToolResult, error) {
+		args["workspace_id"] = float64(456)
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		return handler(context.Background(), client, req)
+	}
+
+	result, err := call(map[string]interface{}{"week": "2024-W11"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "| Test Project | 8h 30m | - |") ||
+		!strings.Contains(text, "Tue 12 (0h 00m of 8h 00m), Wed 13 (0h 00m of 8h 00m), Thu 14 (0h 00m of 8h 00m), Fri 15 (0h 00m of 8h 00m)") {
+		t.Errorf("unexpected timesheet:\n%s", text)
+	}
+	if !strings.Contains(query, "start_date=2024-03-11") {
+		t.Errorf("expected the week's entries to be fetched, got %q", query)
+	}
+
+	result, err = call(map[string]interface{}{"week": "2024-03-13", "format": "json", "target_hours": float64(0)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var sheet WeeklyTimesheet
+	if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &sheet); err != nil {
+		t.Fatalf("decoding timesheet: %v", err)
+	}
+	if sheet.TotalSeconds != 8*3600+1800 || len(sheet.UnderTarget) != 0 || len(sheet.Rows) != 1 {
+		t.Errorf("unexpected timesheet: %+v", sheet)
+	}
+
+	if _, err := call(map[string]interface{}{"week": "soon"}); err == nil {
+		t.Error("expected an error for an invalid week")
+	}
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// tokenCommandTimeout bounds how long a credential command may run
+const tokenCommandTimeout = 30 * time.Second
+
+// TokenSource reads the current Toggl API token, e.g. from a file or a
+// password manager, so that it never has to be stored in plain text config
+type TokenSource func(ctx context.Context) (string, error)
+
+// FileTokenSource reads the token from the file at path. Surrounding
+// whitespace, such as a trailing newline, is ignored.
+func FileTokenSource(path string) TokenSource {
+	return func(context.Context) (string, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading token file: %w", err)
+		}
+		return nonEmptyToken(string(data), "token file "+path)
+	}
+}
+
+// CommandTokenSource reads the token from the standard output of command,
+// run through the system shell, e.g. "pass show toggl"
+func CommandTokenSource(command string) TokenSource {
+	return func(ctx context.Context) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, tokenCommandTimeout)
+		defer cancel()
+
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+		} else {
+			cmd = exec.CommandContext(ctx, "sh", "-c", command)
+		}
+
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("running token command: %w: %s", err, msg)
+			}
+			return "", fmt.Errorf("running token command: %w", err)
+		}
+
+		// Password managers may print more than the secret; use the first line
+		line, _, _ := strings.Cut(string(out), "\n")
+		return nonEmptyToken(line, "token command")
+	}
+}
+
+// nonEmptyToken trims token, failing if nothing is left
+func nonEmptyToken(token, source string) (string, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", errors.New(source + " returned an empty token")
+	}
+	return token, nil
+}
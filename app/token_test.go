@@ -0,0 +1,204 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileTokenSource(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "token")
+	if err := os.WriteFile(path, []byte("  file-token\n"), 0o600); err != nil {
+		t.Fatalf("writing token: %v", err)
+	}
+	token, err := FileTokenSource(path)(context.Background())
+	if err != nil || token != "file-token" {
+		t.Errorf("expected file-token, got %q (%v)", token, err)
+	}
+
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatalf("writing token: %v", err)
+	}
+	if _, err := FileTokenSource(empty)(context.Background()); err == nil {
+		t.Error("expected error for empty token file")
+	}
+
+	if _, err := FileTokenSource(filepath.Join(dir, "missing"))(context.Background()); err == nil {
+		t.Error("expected error for missing token file")
+	}
+}
+
+func TestCommandTokenSource(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no POSIX shell available")
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		wantErr string
+	}{
+		{name: "first line", command: `printf 'cmd-token\nusername: me\n'`, want: "cmd-token"},
+		{name: "empty output", command: "true", wantErr: "empty token"},
+		{name: "failing command", command: "echo locked >&2; exit 1", wantErr: "locked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := CommandTokenSource(tt.command)(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || token != tt.want {
+				t.Errorf("expected %q, got %q (%v)", tt.want, token, err)
+			}
+		})
+	}
+}
+
+func TestTokenSourceRefreshOn401(t *testing.T) {
+	ts, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if token, _, _ := r.BasicAuth(); token != "new-token" {
+			writeError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		writeJSON(w, http.StatusOK, testTimeEntry)
+	})
+
+	var reads atomic.Int32
+	current := "new-token"
+	client := NewTogglClient("old-token",
+		WithHTTPClient(&http.Client{Transport: &testTransport{testURL: ts.URL}}),
+		WithTokenSource(func(context.Context) (string, error) {
+			reads.Add(1)
+			return current, nil
+		}),
+	)
+
+	entry, err := client.CreateTimeEntry(context.Background(), 456, TimeEntryRequest{Description: "Work"})
+	if err != nil {
+		t.Fatalf("expected retry with the re-read token to succeed: %v", err)
+	}
+	if entry.ID != testTimeEntry.ID || client.token() != "new-token" || reads.Load() != 1 {
+		t.Errorf("unexpected state: entry %d, token %q, reads %d", entry.ID, client.token(), reads.Load())
+	}
+
+	// An unchanged token is not retried
+	current = "revoked-token"
+	client.APIToken = "revoked-token"
+	if _, err := client.GetMe(context.Background()); err == nil {
+		t.Error("expected 401 when the re-read token is unchanged")
+	}
+	if reads.Load() != 2 {
+		t.Errorf("expected one more read, got %d", reads.Load())
+	}
+}
+
+func TestTokenSourceRefreshShared(t *testing.T) {
+	ts, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if token, _, _ := r.BasicAuth(); token != "new-token" {
+			writeError(w, http.StatusForbidden, "Forbidden")
+			return
+		}
+		writeJSON(w, http.StatusOK, testUser)
+	})
+
+	var reads atomic.Int32
+	release := make(chan struct{})
+	client := NewTogglClient("old-token",
+		WithHTTPClient(&http.Client{Transport: &testTransport{testURL: ts.URL}}),
+		WithTokenSource(func(context.Context) (string, error) {
+			reads.Add(1)
+			<-release
+			return "new-token", nil
+		}),
+	)
+
+	const requests = 5
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		go func() {
+			_, err := client.GetMe(context.Background())
+			errs <- err
+		}()
+	}
+	// Hold the first re-read while the other requests are rejected
+	for {
+		client.tokenMu.RLock()
+		pending := client.refreshing != nil
+		client.tokenMu.RUnlock()
+		if pending {
+			break
+		}
+		runtime.Gosched()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < requests; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("expected the retry after 403 to succeed: %v", err)
+		}
+	}
+	if reads.Load() != 1 {
+		t.Errorf("expected one shared re-read of the token, got %d", reads.Load())
+	}
+}
+
+func TestTokenSourceThrottledOn403(t *testing.T) {
+	ts, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/workspaces/456/workspace_users":
+			writeError(w, http.StatusForbidden, "admin only")
+		case "/api/v9/me":
+			writeError(w, http.StatusUnauthorized, "Unauthorized")
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	})
+
+	var reads atomic.Int32
+	client := NewTogglClient("token",
+		WithHTTPClient(&http.Client{Transport: &testTransport{testURL: ts.URL}}),
+		WithTokenSource(func(context.Context) (string, error) {
+			reads.Add(1)
+			return "token", nil
+		}),
+	)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetWorkspaceUsers(context.Background(), 456); err == nil {
+			t.Fatal("expected 403")
+		}
+	}
+	if reads.Load() != 1 {
+		t.Errorf("expected repeated 403s to re-read the token once, got %d reads", reads.Load())
+	}
+
+	// A 401 always re-reads
+	if _, err := client.GetMe(context.Background()); err == nil {
+		t.Fatal("expected 401")
+	}
+	if reads.Load() != 2 {
+		t.Errorf("expected a 401 to re-read the token, got %d reads", reads.Load())
+	}
+
+	client.rereadAt = time.Now().Add(-tokenRereadInterval)
+	client.GetWorkspaceUsers(context.Background(), 456)
+	if reads.Load() != 3 {
+		t.Errorf("expected a 403 after the interval to re-read the token, got %d reads", reads.Load())
+	}
+}
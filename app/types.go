@@ -14,6 +14,9 @@ var (
 	ErrInvalidDate      = errors.New("invalid date format")
 	ErrNoRunningEntry   = errors.New("no running time entry found")
 	ErrAPIRequest       = errors.New("API request failed")
+	ErrInvalidToken     = errors.New("confirmation token is invalid or does not match the requested IDs")
+	ErrTokenExpired     = errors.New("confirmation token has expired")
+	ErrChangeNotFound   = errors.New("change not found in journal")
 )
 
 // APIError represents an error from the Toggl API
@@ -41,12 +44,17 @@ type BaseEntity struct {
 type TimeEntry struct {
 	BaseEntity
 	ProjectID   *int       `json:"project_id,omitempty"`
+	TaskID      *int       `json:"task_id,omitempty"`
 	Description string     `json:"description,omitempty"`
 	Start       time.Time  `json:"start"`
 	Stop        *time.Time `json:"stop,omitempty"`
 	Duration    int        `json:"duration"`
 	Tags        []string   `json:"tags,omitempty"`
 	TagIDs      []int      `json:"tag_ids,omitempty"`
+	Billable    bool       `json:"billable"`
+	// Rate is the entry's own hourly rate, on plans that allow one; it
+	// takes precedence over every other rate
+	Rate *float64 `json:"rate,omitempty"`
 }
 
 // Project represents a Toggl project
@@ -56,16 +64,97 @@ type Project struct {
 	Active   bool   `json:"active"`
 	Color    string `json:"color,omitempty"`
 	ClientID *int   `json:"client_id,omitempty"`
+	// Billable is whether new entries on the project are billable by default
+	Billable *bool `json:"billable,omitempty"`
+	// Rate is the project's hourly rate, in Currency; nil when the project
+	// has none of its own
+	Rate           *float64 `json:"rate,omitempty"`
+	Currency       *string  `json:"currency,omitempty"`
+	EstimatedHours *int     `json:"estimated_hours,omitempty"`
+	// FixedFee is the agreed price of a fixed-fee project, in Currency
+	FixedFee *float64 `json:"fixed_fee,omitempty"`
+	// ActualSeconds is the time tracked on the project by all members, as
+	// reported by the API
+	ActualSeconds *int `json:"actual_seconds,omitempty"`
+	// StartDate is the day the project starts, YYYY-MM-DD
+	StartDate string `json:"start_date,omitempty"`
+}
+
+// Task is a part of a project that time can be tracked on; tasks are only
+// available on paid plans
+type Task struct {
+	BaseEntity
+	Name      string `json:"name"`
+	ProjectID int    `json:"project_id"`
+	Active    bool   `json:"active"`
+	// UserID is the member the task is assigned to, if any
+	UserID           *int `json:"user_id,omitempty"`
+	EstimatedSeconds *int `json:"estimated_seconds,omitempty"`
+	TrackedSeconds   int  `json:"tracked_seconds,omitempty"`
+}
+
+// Workspace represents a Toggl workspace
+type Workspace struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// DefaultHourlyRate applies to billable time no other rate covers
+	DefaultHourlyRate *float64 `json:"default_hourly_rate,omitempty"`
+	DefaultCurrency   string   `json:"default_currency,omitempty"`
+}
+
+// WorkspaceUser is a member of a workspace, with their hourly rate there
+type WorkspaceUser struct {
+	ID     int      `json:"id"`
+	UserID int      `json:"user_id"`
+	Rate   *float64 `json:"rate,omitempty"`
+}
+
+// Client represents a Toggl client, the customer projects are billed to
+type Client struct {
+	BaseEntity
+	Name     string `json:"name"`
+	Archived bool   `json:"archived"`
+}
+
+// Tag represents a Toggl tag
+type Tag struct {
+	BaseEntity
+	Name string `json:"name"`
 }
 
 // TimeEntryRequest represents the payload for creating a time entry
 type TimeEntryRequest struct {
+	WorkspaceID int       `json:"workspace_id,omitempty"`
 	Description string    `json:"description"`
 	Start       time.Time `json:"start"`
 	Duration    int       `json:"duration"`
 	ProjectID   *int      `json:"project_id,omitempty"`
+	TaskID      *int      `json:"task_id,omitempty"`
 	Tags        []string  `json:"tags,omitempty"`
-	CreatedWith string    `json:"created_with"`
+	Billable    bool      `json:"billable,omitempty"`
+	// Rate is the entry's own hourly rate; only set when restoring an entry
+	Rate        *float64 `json:"rate,omitempty"`
+	CreatedWith string   `json:"created_with"`
+}
+
+// PatchOperation is a JSON Patch operation, as accepted by the batch time
+// entry endpoint, e.g. {"op": "add", "path": "/tags", "value": ["meeting"]}
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// PatchResult lists the entries a batch update succeeded and failed for
+type PatchResult struct {
+	Success []int          `json:"success"`
+	Failure []PatchFailure `json:"failure"`
+}
+
+// PatchFailure is an entry a batch update failed for, with the reason
+type PatchFailure struct {
+	ID      int    `json:"id"`
+	Message string `json:"message"`
 }
 
 // UserInfo represents user account information
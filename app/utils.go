@@ -2,6 +2,7 @@ package app
 
 import (
 	"fmt"
+	"time"
 )
 
 // getRequiredNumber extracts a required number parameter
@@ -22,6 +23,36 @@ func getOptionalNumber(params map[string]interface{}, key string) *int {
 	return nil
 }
 
+// getWorkspaceID extracts the workspace_id parameter, falling back to the
+// client's default workspace
+func getWorkspaceID(params map[string]interface{}, client *TogglClient) (int, error) {
+	if id := getOptionalNumber(params, "workspace_id"); id != nil {
+		return *id, nil
+	}
+	if client.defaultWorkspaceID != 0 {
+		return client.defaultWorkspaceID, nil
+	}
+	return 0, fmt.Errorf("workspace_id must be a number")
+}
+
+// getRequiredNumberList extracts a required, non-empty list of numbers
+func getRequiredNumberList(params map[string]interface{}, key string) ([]int, error) {
+	raw, ok := params[key].([]interface{})
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("%s must be a non-empty array of numbers", key)
+	}
+
+	values := make([]int, 0, len(raw))
+	for _, item := range raw {
+		val, ok := item.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a non-empty array of numbers", key)
+		}
+		values = append(values, int(val))
+	}
+	return values, nil
+}
+
 // getRequiredString extracts a required string parameter
 func getRequiredString(params map[string]interface{}, key string) (string, error) {
 	val, ok := params[key].(string)
@@ -31,6 +62,19 @@ func getRequiredString(params map[string]interface{}, key string) (string, error
 	return val, nil
 }
 
+// getRequiredDate extracts a required date parameter in YYYY-MM-DD format
+func getRequiredDate(params map[string]interface{}, key string) (time.Time, error) {
+	value, err := getRequiredString(params, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date in YYYY-MM-DD format", key)
+	}
+	return date, nil
+}
+
 // getOptionalString extracts an optional string parameter
 func getOptionalString(params map[string]interface{}, key string) string {
 	if val, ok := params[key].(string); ok {
@@ -39,19 +83,58 @@ func getOptionalString(params map[string]interface{}, key string) string {
 	return ""
 }
 
-// formatDuration formats duration in seconds to a human-readable string
-func formatDuration(seconds int) string {
+// getOptionalBool extracts an optional boolean parameter, false when absent
+func getOptionalBool(params map[string]interface{}, key string) bool {
+	val, _ := params[key].(bool)
+	return val
+}
+
+// getOptionalStringList extracts an optional list of strings
+func getOptionalStringList(params map[string]interface{}, key string) ([]string, error) {
+	raw, ok := params[key]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array of strings", key)
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		val, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be an array of strings", key)
+		}
+		values = append(values, val)
+	}
+	return values, nil
+}
+
+// formatDuration formats duration in seconds to a human-readable string.
+// When rounding changes the duration, the rounded value follows the raw one.
+func formatDuration(seconds int, rounding Rounding) string {
 	if seconds < 0 {
 		return "[running]"
 	}
+	if rounded := rounding.RoundSeconds(seconds); rounded != seconds {
+		return fmt.Sprintf("[%s, rounded %s]", durationText(seconds), durationText(rounded))
+	}
+	return "[" + durationText(seconds) + "]"
+}
+
+// durationText formats seconds as hours, minutes and seconds, leaving out
+// leading zero units
+func durationText(seconds int) string {
 	hours := seconds / 3600
 	minutes := (seconds % 3600) / 60
 	secs := seconds % 60
 
 	if hours > 0 {
-		return fmt.Sprintf("[%dh %dm %ds]", hours, minutes, secs)
+		return fmt.Sprintf("%dh %dm %ds", hours, minutes, secs)
 	} else if minutes > 0 {
-		return fmt.Sprintf("[%dm %ds]", minutes, secs)
+		return fmt.Sprintf("%dm %ds", minutes, secs)
 	}
-	return fmt.Sprintf("[%ds]", secs)
+	return fmt.Sprintf("%ds", secs)
 }
@@ -3,6 +3,7 @@ package app
 import (
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestGetRequiredNumber(t *testing.T) {
@@ -165,6 +166,81 @@ func TestGetOptionalNumber(t *testing.T) {
 	}
 }
 
+func TestGetRequiredNumberList(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]interface{}
+		want    []int
+		wantErr bool
+	}{
+		{
+			name:   "valid list",
+			params: map[string]interface{}{"ids": []interface{}{1.0, 2.0, 3.0}},
+			want:   []int{1, 2, 3},
+		},
+		{
+			name:    "empty list",
+			params:  map[string]interface{}{"ids": []interface{}{}},
+			wantErr: true,
+		},
+		{
+			name:    "non-number element",
+			params:  map[string]interface{}{"ids": []interface{}{1.0, "2"}},
+			wantErr: true,
+		},
+		{
+			name:    "not a list",
+			params:  map[string]interface{}{"ids": 1.0},
+			wantErr: true,
+		},
+		{
+			name:    "missing key",
+			params:  map[string]interface{}{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getRequiredNumberList(tt.params, "ids")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getRequiredNumberList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) && !tt.wantErr {
+				t.Errorf("getRequiredNumberList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetWorkspaceID(t *testing.T) {
+	tests := []struct {
+		name             string
+		params           map[string]interface{}
+		defaultWorkspace int
+		want             int
+		wantErr          bool
+	}{
+		{name: "explicit", params: map[string]interface{}{"workspace_id": 456.0}, defaultWorkspace: 1, want: 456},
+		{name: "default", params: map[string]interface{}{}, defaultWorkspace: 1, want: 1},
+		{name: "missing without default", params: map[string]interface{}{}, wantErr: true},
+		{name: "wrong type without default", params: map[string]interface{}{"workspace_id": "456"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewTogglClient("token", WithDefaultWorkspace(tt.defaultWorkspace))
+			got, err := getWorkspaceID(tt.params, client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getWorkspaceID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getWorkspaceID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetRequiredString(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -326,10 +402,13 @@ func TestGetOptionalString(t *testing.T) {
 }
 
 func TestFormatDuration(t *testing.T) {
+	quarterHours := Rounding{Mode: RoundUp, Increment: 15 * time.Minute}
+
 	tests := []struct {
-		name    string
-		seconds int
-		want    string
+		name     string
+		seconds  int
+		rounding Rounding
+		want     string
 	}{
 		{
 			name:    "negative duration (running)",
@@ -396,11 +475,29 @@ func TestFormatDuration(t *testing.T) {
 			seconds: 359999,
 			want:    "[99h 59m 59s]",
 		},
+		{
+			name:     "rounded",
+			seconds:  4032,
+			rounding: quarterHours,
+			want:     "[1h 7m 12s, rounded 1h 15m 0s]",
+		},
+		{
+			name:     "already rounded",
+			seconds:  900,
+			rounding: quarterHours,
+			want:     "[15m 0s]",
+		},
+		{
+			name:     "running with rounding",
+			seconds:  -1,
+			rounding: quarterHours,
+			want:     "[running]",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := formatDuration(tt.seconds)
+			got := formatDuration(tt.seconds, tt.rounding)
 			if got != tt.want {
 				t.Errorf("formatDuration() = %v, want %v", got, tt.want)
 			}
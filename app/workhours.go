@@ -0,0 +1,89 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WorkHours is the working day: the same clock hours on each working weekday
+type WorkHours struct {
+	// Start and End are offsets from midnight
+	Start time.Duration
+	End   time.Duration
+	Days  [7]bool
+}
+
+// weekdayNames maps config day names to weekdays
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// DefaultWorkHours is nine to five, Monday to Friday
+func DefaultWorkHours() WorkHours {
+	w, _ := DefaultConfig().WorkHours.Parse()
+	return w
+}
+
+// Parse checks the config and converts it into WorkHours. Times are HH:MM;
+// days are three-letter English names, or full names.
+func (c WorkHoursConfig) Parse() (WorkHours, error) {
+	var w WorkHours
+	var err error
+	if w.Start, err = parseClock(c.Start); err != nil {
+		return w, fmt.Errorf("start: %w", err)
+	}
+	if w.End, err = parseClock(c.End); err != nil {
+		return w, fmt.Errorf("end: %w", err)
+	}
+	if w.End <= w.Start {
+		return w, errors.New("end must be after start")
+	}
+
+	for _, name := range c.Days {
+		day, err := parseWeekday(name)
+		if err != nil {
+			return w, err
+		}
+		w.Days[day] = true
+	}
+	return w, nil
+}
+
+// parseWeekday parses a three-letter English day name, or a full name
+func parseWeekday(name string) (time.Weekday, error) {
+	day, ok := weekdayNames[strings.ToLower(name[:min(len(name), 3)])]
+	if !ok || (len(name) > 3 && !strings.EqualFold(name, day.String())) {
+		return day, fmt.Errorf("unknown day %q", name)
+	}
+	return day, nil
+}
+
+// parseClock parses HH:MM as an offset from midnight; 24:00 is allowed as
+// the end of the day
+func parseClock(value string) (time.Duration, error) {
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// window returns the working hours on the day containing t, in t's
+// location. ok is false on days off. Clock times are used rather than
+// offsets so that days with a DST change keep their hours.
+func (w WorkHours) window(t time.Time) (start, end time.Time, ok bool) {
+	if !w.Days[t.Weekday()] {
+		return time.Time{}, time.Time{}, false
+	}
+	y, m, d := t.Date()
+	clock := func(offset time.Duration) time.Time {
+		return time.Date(y, m, d, int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, t.Location())
+	}
+	return clock(w.Start), clock(w.End), true
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkHoursConfigParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  WorkHoursConfig
+		want    WorkHours
+		wantErr string
+	}{
+		{
+			name:   "defaults",
+			config: DefaultConfig().WorkHours,
+			want: WorkHours{
+				Start: 9 * time.Hour,
+				End:   17 * time.Hour,
+				Days:  [7]bool{false, true, true, true, true, true, false},
+			},
+		},
+		{
+			name:   "full day names and midnight end",
+			config: WorkHoursConfig{Start: "13:30", End: "24:00", Days: []string{"Saturday", "sun"}},
+			want:   WorkHours{Start: 13*time.Hour + 30*time.Minute, End: 24 * time.Hour, Days: [7]bool{true, false, false, false, false, false, true}},
+		},
+		{name: "bad start", config: WorkHoursConfig{Start: "9", End: "17:00"}, wantErr: "start"},
+		{name: "end before start", config: WorkHoursConfig{Start: "17:00", End: "09:00"}, wantErr: "after start"},
+		{name: "unknown day", config: WorkHoursConfig{Start: "09:00", End: "17:00", Days: []string{"monsoon"}}, wantErr: "unknown day"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.config.Parse()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkHoursWindow(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	w := DefaultWorkHours()
+
+	// The clocks go forward on Sunday 2024-03-31; Monday keeps nine to five
+	start, end, ok := w.window(time.Date(2024, 4, 1, 0, 0, 0, 0, berlin))
+	if !ok || start.Format("15:04") != "09:00" || end.Sub(start) != 8*time.Hour {
+		t.Errorf("unexpected window %v - %v (%v)", start, end, ok)
+	}
+
+	if _, _, ok := w.window(time.Date(2024, 3, 31, 0, 0, 0, 0, berlin)); ok {
+		t.Error("expected Sunday to be a day off")
+	}
+}
@@ -2,9 +2,15 @@ module github.com/kyteproject/togglgo-mcp
 
 go 1.23.2
 
-require github.com/mark3labs/mcp-go v0.18.0
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/mark3labs/mcp-go v0.47.1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
+	github.com/google/jsonschema-go v0.4.2 // indirect
 	github.com/google/uuid v1.6.0 // indirect
+	github.com/spf13/cast v1.7.1 // indirect
 	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
 )
@@ -3,8 +3,15 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/kyteproject/togglgo-mcp/app"
 
@@ -12,34 +19,383 @@ import (
 )
 
 func main() {
+	configPath := flag.String("config", "", "path to a TOML, YAML or JSON config file (default: config.* in the user config directory)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted, then exit")
+	transport := flag.String("transport", "", "transport to serve MCP over: stdio, sse or http (default stdio)")
+	listen := flag.String("listen", "", "listen address for the sse and http transports (default localhost:8080)")
+	baseURL := flag.String("base-url", "", "public base URL advertised to SSE clients (defaults to relative URLs)")
+	multiUser := flag.Bool("multi-user", false, "require every request to carry its own Toggl API token (sse and http transports only)")
+	logLevel := flag.String("log-level", "", "log level: debug, info, warn or error (default info)")
+	logFormat := flag.String("log-format", "", "log format: json or text (default json)")
+	flag.Usage = usage
+	flag.Parse()
+
+	// The first argument names a CLI command; without one the server starts
+	command, args := "serve", flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	switch {
+	case command == "serve":
+		// Server flags may also follow the serve command
+		_ = flag.CommandLine.Parse(args)
+		if flag.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "unexpected argument %q\n", flag.Arg(0))
+			os.Exit(app.ExitUsage)
+		}
+	case !app.IsCommand(command):
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", command)
+		flag.Usage()
+		os.Exit(app.ExitUsage)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+
+	// Flags take precedence over the environment and the config file, but
+	// only when they are given explicitly
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "transport":
+			cfg.Server.Transport = *transport
+		case "listen":
+			cfg.Server.Listen = *listen
+		case "base-url":
+			cfg.Server.BaseURL = *baseURL
+		case "multi-user":
+			cfg.Server.MultiUser = *multiUser
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "log-format":
+			cfg.Log.Format = *logFormat
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:", err)
+		os.Exit(1)
+	}
+
+	if *printConfig {
+		if err := cfg.Redacted().WriteTOML(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Setup structured logging
-	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	logger := cfg.Log.NewLogger(os.Stderr)
 	slog.SetDefault(logger)
 
-	apiToken := os.Getenv("TOGGL_API_TOKEN")
-	if apiToken == "" {
-		logger.Error("missing API token", slog.Any("error", app.ErrNoAPIToken))
+	journalPath := cfg.JournalPath
+	if journalPath == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			journalPath = filepath.Join(dir, "togglgo-mcp", "journal.json")
+		}
+	}
+
+	loc, _ := cfg.Location()
+	newClient := func(token, journalPath string, opts ...app.ClientOption) *app.TogglClient {
+		opts = append([]app.ClientOption{
+			app.WithLogger(logger),
+			app.WithBaseURL(cfg.APIBaseURL),
+			app.WithTimeout(time.Duration(cfg.Timeout)),
+			app.WithJournal(openJournal(logger, journalPath)),
+		}, opts...)
+		if loc != nil {
+			opts = append(opts, app.WithLocation(loc))
+		}
+		return app.NewTogglClient(token, opts...)
+	}
+
+	// Tokens sent with HTTP requests get their own client and journal
+	pool := app.NewClientPool(func(token string) *app.TogglClient {
+		return newClient(token, userJournalPath(journalPath, token))
+	})
+
+	profiles, err := loadProfiles(cfg, func(name string, profile app.Profile) *app.TogglClient {
+		return newClient(profile.APIToken, profileJournalPath(journalPath, name),
+			app.WithDefaultWorkspace(cfg.DefaultWorkspaceID))
+	})
+	if err != nil {
+		logger.Error("failed to load profiles", slog.Any("error", err))
 		os.Exit(1)
 	}
 
-	togglClient := app.NewTogglClient(apiToken, app.WithLogger(logger))
+	if command != "serve" {
+		os.Exit(runCommand(command, args, cfg, profiles, newClient, journalPath))
+	}
 
-	s := server.NewMCPServer("toggl-mcp", "1.0.0")
+	hasToken := cfg.APIToken != "" || cfg.APITokenFile != "" || cfg.APITokenCmd != ""
 
-	if err := app.SetupTools(s, togglClient); err != nil {
-		logger.Error("failed to setup tools", slog.Any("error", err))
+	var togglClient *app.TogglClient
+	switch {
+	case cfg.Server.MultiUser && cfg.Server.Transport == "stdio":
+		logger.Error("multi-user mode requires the sse or http transport")
 		os.Exit(1)
+	case cfg.Server.MultiUser:
+		if hasToken || profiles != nil {
+			logger.Warn("ignoring the configured API token and profiles in multi-user mode")
+		}
+		profiles = nil
+	case profiles != nil:
+		if hasToken {
+			logger.Warn("ignoring the configured API token in favour of profiles")
+		}
+		togglClient = profiles.Default()
+	default:
+		togglClient, err = tokenClient(context.Background(), cfg, newClient, journalPath)
+		if err != nil {
+			logger.Error("failed to create client", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+
+	s := server.NewMCPServer("toggl-mcp", "1.0.0")
+
+	// Validate has already checked the working hours, invoice templates,
+	// timesheet and schedule
+	workHours, _ := cfg.WorkHours.Parse()
+	invoicing, _ := cfg.Invoice.Parse()
+	timesheet, _ := cfg.Timesheet.Parse()
+	schedule, _ := cfg.Schedule.Parse()
+	setupOpts := []app.SetupOption{
+		app.WithOutputFormat(cfg.OutputFormat),
+		app.WithWorkHours(workHours),
+		app.WithRounding(cfg.Rounding.Rounding()),
+		app.WithInvoicing(invoicing),
+		app.WithBudgetThresholds(cfg.Budget.Thresholds...),
+		app.WithTimesheet(timesheet),
+		app.WithSchedule(schedule),
+	}
+	if cfg.Tools.EnableDelete {
+		logger.Warn("delete tools enabled")
+		setupOpts = append(setupOpts, app.WithDeleteTools(time.Duration(cfg.Tools.ConfirmationTTL)))
+	}
+	if len(cfg.Tools.AllowedDirs) > 0 {
+		setupOpts = append(setupOpts, app.WithAllowedDirs(cfg.Tools.AllowedDirs...))
+	}
+	if len(cfg.Tools.Enabled) > 0 {
+		setupOpts = append(setupOpts, app.WithEnabledTools(cfg.Tools.Enabled...))
 	}
 
-	logger.Info("starting Toggl MCP server")
-	if err := server.ServeStdio(s); err != nil {
-		if !errors.Is(err, context.Canceled) {
-			logger.Error("server error", slog.Any("error", err))
+	if cfg.AuditLog.Path != "" {
+		auditLog, err := app.NewAuditLog(cfg.AuditLog.Path, int64(cfg.AuditLog.MaxSizeMB)<<20, cfg.AuditLog.MaxBackups)
+		if err != nil {
+			logger.Error("failed to open audit log", slog.Any("error", err))
 			os.Exit(1)
 		}
+		defer auditLog.Close()
+
+		setupOpts = append(setupOpts, app.WithAuditLog(auditLog))
+	}
+
+	if profiles != nil {
+		logger.Info("loaded profiles", slog.Any("profiles", profiles.Names()))
+		setupOpts = append(setupOpts, app.WithProfiles(profiles))
+	}
+
+	if err := app.SetupTools(s, togglClient, setupOpts...); err != nil {
+		logger.Error("failed to setup tools", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	logger.Info("starting Toggl MCP server", slog.String("transport", cfg.Server.Transport))
+
+	var serveErr error
+	switch cfg.Server.Transport {
+	case "stdio":
+		serveErr = server.ServeStdio(s)
+	default:
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		serveErr = serveHTTP(ctx, logger, s, togglClient, pool,
+			cfg.Server.Transport, cfg.Server.Listen, cfg.Server.BaseURL)
+	}
+
+	if serveErr != nil && !errors.Is(serveErr, context.Canceled) {
+		logger.Error("server error", slog.Any("error", serveErr))
+		os.Exit(1)
 	}
 
 	logger.Info("server stopped gracefully")
 }
+
+// usage describes the commands and the global flags
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, `Usage: toggl-mcp [flags] [command] [command flags]
+
+Commands:
+  serve      run the MCP server (default)
+  start      start a time entry
+  stop       stop the running time entry
+  current    show the running time entry
+  entries    list time entries
+  projects   list projects
+  report     summarize time per project
+  export     export time entries as CSV or JSON Lines
+  export-ics export time entries as an iCalendar file
+
+Run "toggl-mcp <command> -h" for a command's flags.
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+// clientFactory builds a client for token that journals to journalPath
+type clientFactory func(token, journalPath string, opts ...app.ClientOption) *app.TogglClient
+
+// tokenClient builds the client for the configured API token, token file or
+// token command
+func tokenClient(ctx context.Context, cfg app.Config, newClient clientFactory, journalPath string) (*app.TogglClient, error) {
+	tokenSource := cfg.TokenSource()
+	switch {
+	case tokenSource != nil:
+		token, err := tokenSource(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("reading API token: %w", err)
+		}
+		return newClient(token, journalPath,
+			app.WithDefaultWorkspace(cfg.DefaultWorkspaceID),
+			app.WithTokenSource(tokenSource)), nil
+	case cfg.APIToken != "":
+		return newClient(cfg.APIToken, journalPath,
+			app.WithDefaultWorkspace(cfg.DefaultWorkspaceID)), nil
+	default:
+		return nil, fmt.Errorf("missing API token: set TOGGL_API_TOKEN, TOGGL_API_TOKEN_FILE or TOGGL_API_TOKEN_CMD: %w",
+			app.ErrNoAPIToken)
+	}
+}
+
+// runCommand runs a CLI command with the profile's client, or with the
+// configured token when there are no profiles
+func runCommand(
+	command string,
+	args []string,
+	cfg app.Config,
+	profiles *app.Profiles,
+	newClient clientFactory,
+	journalPath string,
+) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return app.RunCommand(ctx, command, args, app.CommandEnv{
+		Stdout:   os.Stdout,
+		Stderr:   os.Stderr,
+		Rounding: cfg.Rounding.Rounding(),
+		Client: func(profile string) (*app.TogglClient, error) {
+			switch {
+			case profiles != nil && profile == "":
+				return profiles.Default(), nil
+			case profiles != nil:
+				client, ok := profiles.Client(profile)
+				if !ok {
+					return nil, fmt.Errorf("unknown profile %q (available: %s)",
+						profile, strings.Join(profiles.Names(), ", "))
+				}
+				return client, nil
+			case profile != "":
+				return nil, fmt.Errorf("unknown profile %q: no profiles are configured", profile)
+			default:
+				return tokenClient(ctx, cfg, newClient, journalPath)
+			}
+		},
+	})
+}
+
+// loadConfig assembles the configuration from defaults, the config file and
+// the environment. The file is path if given, then TOGGL_CONFIG, then the
+// first config.* found in the user config directory.
+func loadConfig(path string) (app.Config, error) {
+	cfg := app.DefaultConfig()
+
+	if path == "" {
+		path = os.Getenv("TOGGL_CONFIG")
+	}
+	if path == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			path = app.FindConfigFile(filepath.Join(dir, "togglgo-mcp"))
+		}
+	}
+
+	if path != "" {
+		if err := app.LoadConfigFile(path, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// openJournal opens the journal at path, falling back to an in-memory journal
+func openJournal(logger *slog.Logger, path string) *app.Journal {
+	journal, err := app.NewJournal(path)
+	if err != nil {
+		logger.Warn("failed to open journal, keeping changes in memory only",
+			slog.Any("error", err),
+			slog.String("path", path),
+		)
+		journal, _ = app.NewJournal("")
+	}
+	return journal
+}
+
+// loadProfiles returns the profiles defined in the config file, or else in
+// the profiles file (profiles_file, or profiles.json in the config directory
+// if it exists). It returns nil when no profiles are configured.
+func loadProfiles(cfg app.Config, newClient func(string, app.Profile) *app.TogglClient) (*app.Profiles, error) {
+	if cfg.ProfilesFile == "" && len(cfg.Profiles) > 0 {
+		return app.NewProfiles(app.ProfilesConfig{
+			Default:  cfg.DefaultProfile,
+			Profiles: cfg.Profiles,
+		}, newClient)
+	}
+
+	path := cfg.ProfilesFile
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, nil
+		}
+		path = filepath.Join(dir, "togglgo-mcp", "profiles.json")
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil
+		}
+	}
+
+	profilesCfg, err := app.LoadProfilesConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewProfiles(profilesCfg, newClient)
+}
+
+// userJournalPath derives a per-user journal path from the default one
+func userJournalPath(path, token string) string {
+	return suffixedPath(path, app.TokenFingerprint(token))
+}
+
+// profileJournalPath derives a per-profile journal path from the default one
+func profileJournalPath(path, profile string) string {
+	return suffixedPath(path, profile)
+}
+
+// suffixedPath inserts "-suffix" before the extension of path
+func suffixedPath(path, suffix string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + suffix + ext
+}
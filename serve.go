@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kyteproject/togglgo-mcp/app"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+// serveHTTP serves s over the SSE or streamable HTTP transport, together with
+// a /healthz endpoint, until ctx is cancelled. Requests carrying their own
+// Toggl API token are served with that token's client from pool.
+func serveHTTP(
+	ctx context.Context,
+	logger *slog.Logger,
+	s *server.MCPServer,
+	togglClient *app.TogglClient,
+	pool *app.ClientPool,
+	transport, addr, baseURL string,
+) error {
+	// Cancelling the base context ends long-lived SSE streams on shutdown
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", app.HealthHandler(togglClient))
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
+
+	switch transport {
+	case "sse":
+		// SSEServer.Shutdown is not used: it closes sessions that the stream
+		// handlers also close once the base context is cancelled
+		opts := []server.SSEOption{server.WithSSEContextFunc(pool.ContextFunc())}
+		if baseURL != "" {
+			opts = append(opts, server.WithBaseURL(baseURL))
+		}
+		mux.Handle("/", server.NewSSEServer(s, opts...))
+	case "http":
+		sessions := app.NewSessionStore(app.SessionIdleTTL, app.MaxSessions)
+		httpTransport := server.NewStreamableHTTPServer(s,
+			server.WithHTTPContextFunc(pool.ContextFunc()),
+			server.WithSessionIdManagerResolver(sessions),
+			server.WithSessionIdleTTL(app.SessionIdleTTL),
+		)
+		defer httpTransport.Shutdown(context.Background())
+		mux.Handle("/mcp", sessions.Guard(httpTransport))
+	default:
+		return fmt.Errorf("unknown transport %q", transport)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	logger.Info("listening for MCP clients",
+		slog.String("transport", transport),
+		slog.String("addr", addr),
+	)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	cancelBase()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down: %w", err)
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}